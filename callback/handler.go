@@ -2,6 +2,7 @@ package callback
 
 import (
 	"DomainC/cfclient"
+	"DomainC/config"
 	"DomainC/telegram"
 	"fmt"
 	"log"
@@ -10,6 +11,23 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// Zones 为跨账号 Zone 索引，由 main 设置；为空时按回调数据中的账号处理。
+var Zones *cfclient.ZoneIndex
+
+// accountFor 返回只读、暂停及解析类回调使用的账号：默认使用按钮中的账号，只有该账号中已没有此 Zone 时才以索引为准。
+// 删除 Zone 不使用它，只在按钮中的账号执行。
+func accountFor(label, domain string) *config.CF {
+	if Zones != nil {
+		if acc := Zones.AccountFor(label, domain); acc != nil {
+			return acc
+		}
+	}
+	return cfclient.GetAccountByLabel(label)
+}
+
+// DNSPicks 处理删除解析记录的选择按钮，由 main 设置。
+var DNSPicks func(token, choice string, user *tgbotapi.User)
+
 func HandleCallback(callbackData string, user *tgbotapi.User) {
 	parts := strings.Split(callbackData, "|")
 	if len(parts) < 3 {
@@ -31,7 +49,7 @@ func HandleCallback(callbackData string, user *tgbotapi.User) {
 	switch action {
 	case "pause":
 		go func() {
-			account := cfclient.GetAccountByLabel(accountLabel)
+			account := accountFor(accountLabel, domain)
 			if account == nil {
 				log.Printf("未找到账号: %s", accountLabel)
 				return
@@ -50,13 +68,16 @@ func HandleCallback(callbackData string, user *tgbotapi.User) {
 			if err != nil {
 				telegram.SendTelegramAlert(fmt.Sprintf(failMsg, err))
 			} else {
+				if Zones != nil {
+					Zones.SetPaused(account.Label, domain, paused == "yes")
+				}
 				telegram.SendTelegramAlert(successMsg)
 			}
 		}()
 
 	case "DNS":
 		go func() {
-			account := cfclient.GetAccountByLabel(accountLabel)
+			account := accountFor(accountLabel, domain)
 			if account == nil {
 				log.Printf("未找到账号: %s", accountLabel)
 				return
@@ -113,9 +134,15 @@ func HandleCallback(callbackData string, user *tgbotapi.User) {
 
 	case "delete_confirm":
 		go func() {
+			// 删除只在确认消息中的账号执行，不按索引改用其他账号
 			account := cfclient.GetAccountByLabel(accountLabel)
 			if account == nil {
 				log.Printf("未找到账号: %s", accountLabel)
+				telegram.SendTelegramAlert(fmt.Sprintf("删除域名失败: %s-----%s (未找到账号)", domain, accountLabel))
+				return
+			}
+			if Zones != nil && Zones.Lost(accountLabel, domain) {
+				telegram.SendTelegramAlert(fmt.Sprintf("删除域名失败: %s-----%s (该账号中已没有此域名，未执行删除)", domain, accountLabel))
 				return
 			}
 
@@ -125,9 +152,18 @@ func HandleCallback(callbackData string, user *tgbotapi.User) {
 				telegram.SendTelegramAlert(fmt.Sprintf("删除域名失败: %s-----%s (%v)", domain, accountLabel, err))
 				return
 			}
+			if Zones != nil {
+				Zones.Remove(account.Label, domain)
+			}
 			telegram.SendTelegramAlert(fmt.Sprintf("✅ 删除域名成功: %s-----%s (操作人:%s)", domain, accountLabel, user.UserName))
 		}()
 
+	case "dnsdel":
+		// 回调数据为 dnsdel|token|序号
+		if DNSPicks != nil {
+			go DNSPicks(parts[1], parts[2], user)
+		}
+
 	case "delete_cancel":
 		go func() {
 			telegram.SendTelegramAlert(fmt.Sprintf("已取消删除: %s-----%s (操作人:%s)", domain, accountLabel, user.UserName))
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"DomainC/config"
@@ -15,11 +16,51 @@ import (
 // DomainInfo 是 cfclient 层的域名描述，避免直接依赖 domain 包
 type DomainInfo struct {
 	Domain string
+	ZoneID string
 	Source string
 	IsCF   bool
 	Status string
 	Paused bool
+	// NameServers 为 Cloudflare 分配给该 Zone 的 NS。
+	NameServers []string
+}
+
+// DNSSECStatus 为 Zone 的 DNSSEC 设置，Status 为 active、pending、disabled、pending-disabled 或 error。
+// DS 为需要在注册商处添加的完整 DS 记录，未启用时为空。
+type DNSSECStatus struct {
+	Status    string
+	KeyTag    int
+	Algorithm string
+	Digest    string
+	DS        string
+}
+
+// CertificatePack 为 Zone 的边缘证书包，Type 为 universal、advanced 等。
+// ExpiresOn 取包内最晚到期的证书，尚未签发时为零值。
+type CertificatePack struct {
+	ID                   string
+	Type                 string
+	Hosts                []string
+	Status               string
+	CertificateAuthority string
+	ExpiresOn            time.Time
+	// Validation 为待添加的验证记录，例如 "TXT _acme-challenge.example.com abc"
+	Validation       []string
+	ValidationErrors []string
+}
+
+// CustomHostname 为 SSL for SaaS 自定义主机名，Status 为主机名状态，SSLStatus 为其证书状态。
+type CustomHostname struct {
+	ID               string
+	Hostname         string
+	Status           string
+	SSLStatus        string
+	Issuer           string
+	ExpiresOn        time.Time
+	Validation       []string
+	ValidationErrors []string
 }
+
 type ZoneDetail struct {
 	ID          string
 	Name        string
@@ -36,26 +77,62 @@ type Client interface {
 	DeleteDomain(ctx context.Context, account config.CF, domain string) error
 	GetZoneDetails(ctx context.Context, account config.CF, domain string) (ZoneDetail, error)
 	CreateZone(ctx context.Context, account config.CF, domain string) (ZoneDetail, error)
-	UpsertDNSRecord(ctx context.Context, account config.CF, domain string, params DNSRecordParams) (cloudflare.DNSRecord, error)
+	UpsertDNSRecord(ctx context.Context, account config.CF, domain string, params DNSRecordParams, overlay func(*DNSRecordParams) error) (cloudflare.DNSRecord, error)
+	SearchDNSRecords(ctx context.Context, account config.CF, domain string, filter DNSRecordFilter) ([]cloudflare.DNSRecord, error)
+	GetDNSRecord(ctx context.Context, account config.CF, domain, id string) (cloudflare.DNSRecord, error)
+	CreateDNSRecord(ctx context.Context, account config.CF, domain string, params DNSRecordParams) (cloudflare.DNSRecord, error)
+	UpdateDNSRecord(ctx context.Context, account config.CF, domain, id string, params DNSRecordParams) (cloudflare.DNSRecord, error)
+	DeleteDNSRecord(ctx context.Context, account config.CF, domain, id string) error
+	GetDNSSEC(ctx context.Context, account config.CF, domain string) (DNSSECStatus, error)
+	ListCertificatePacks(ctx context.Context, account config.CF, domain string) ([]CertificatePack, error)
+	ListCustomHostnames(ctx context.Context, account config.CF, domain string) ([]CustomHostname, error)
+}
+
+// apiClient 为每个账号保留一个长期的 API 句柄，并缓存 Zone 名称到 ID 的映射，
+// 避免每次操作都重新创建客户端并通过 ListZones 查找 Zone。
+type apiClient struct {
+	// ZoneTTL 为 Zone 缓存有效期，为 0 时使用 DefaultZoneCacheTTL。
+	ZoneTTL time.Duration
+
+	mu    sync.Mutex
+	apis  map[string]*cloudflare.API
+	zones map[string]zoneRef
+	// options 为创建 API 句柄时附加的选项，测试中用于指向本地服务器。
+	options []cloudflare.Option
+	clock   func() time.Time
 }
 
-type apiClient struct{}
+// defaultClient 由 NewClient 与包级函数共享，保证各处的 Zone 缓存一致。
+var defaultClient = &apiClient{}
 
-// NewClient 返回默认的 Cloudflare API 客户端实现
+// NewClient 返回默认的 Cloudflare API 客户端实现，所有调用方共享 API 句柄与 Zone 缓存
 func NewClient() Client {
-	return &apiClient{}
+	return defaultClient
 }
 
 // ErrZoneNotFound 在账户中未找到域名时返回
 var ErrZoneNotFound = errors.New("zone not found")
 
-// DNSRecordParams 描述需要创建或更新的解析记录
+// DNSRecordParams 描述需要创建或更新的解析记录。SRV 的 Content 为目标主机，CAA 的 Content 为值，
+// 例如 letsencrypt.org；提交前由 Validate 按类型检查。
 type DNSRecordParams struct {
 	Type    string
 	Name    string
 	Content string
 	Proxied bool
-	TTL     int
+	// TTL 为秒数，0 或 1 表示自动。
+	TTL int
+	// Priority 用于 MX 与 SRV。
+	Priority *uint16
+	// Weight、Port 用于 SRV。
+	Weight uint16
+	Port   uint16
+	// Flags、Tag 用于 CAA，Tag 为 issue、issuewild 或 iodef。
+	Flags uint8
+	Tag   string
+	// Comment 为记录备注，为空时更新记录不修改原有备注。
+	Comment string
+	Tags    []string
 }
 
 // DeleteDomain 从 Cloudflare 删除 zone
@@ -63,21 +140,18 @@ func (c *apiClient) DeleteDomain(ctx context.Context, account config.CF, domain
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.api(account)
 	if err != nil {
-		return fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
+		return err
 	}
 
-	zones, err := api.ListZonesContext(ctx, cloudflare.WithZoneFilters(domain, "", ""))
+	zone, err := c.zone(ctx, api, account, domain)
 	if err != nil {
-		return fmt.Errorf("获取 Zone 失败: %v", err)
-	}
-	if len(zones.Result) == 0 {
-		return fmt.Errorf("%w: %s", ErrZoneNotFound, domain)
+		return err
 	}
 
-	zoneID := zones.Result[0].ID
-	_, err = api.DeleteZone(ctx, zoneID)
+	_, err = api.DeleteZone(ctx, zone.ID)
+	c.forgetZone(account, domain)
 	if err != nil {
 		return fmt.Errorf("删除域名失败: %v", err)
 	}
@@ -86,7 +160,7 @@ func (c *apiClient) DeleteDomain(ctx context.Context, account config.CF, domain
 
 // 为兼容旧调用，保留包级函数，转发到默认客户端
 func DeleteDomain(account config.CF, domain string) error {
-	return NewClient().DeleteDomain(context.Background(), account, domain)
+	return defaultClient.DeleteDomain(context.Background(), account, domain)
 }
 
 // ListDNSRecords 返回指定域名的解析记录
@@ -94,33 +168,30 @@ func (c *apiClient) ListDNSRecords(ctx context.Context, account config.CF, domai
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.api(account)
 	if err != nil {
-		return nil, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
+		return nil, err
 	}
 
-	zones, err := api.ListZonesContext(ctx, cloudflare.WithZoneFilters(domain, "", ""))
+	zone, err := c.zone(ctx, api, account, domain)
 	if err != nil {
-		return nil, fmt.Errorf("获取 Zone 失败: %v", err)
-	}
-	if len(zones.Result) == 0 {
-		return nil, fmt.Errorf("%w: %s", ErrZoneNotFound, domain)
+		return nil, err
 	}
 
-	zoneID := zones.Result[0].ID
 	records, _, err := api.ListDNSRecords(
 		ctx,
-		cloudflare.ZoneIdentifier(zoneID),
+		cloudflare.ZoneIdentifier(zone.ID),
 		cloudflare.ListDNSRecordsParams{},
 	)
 	if err != nil {
+		c.forgetZone(account, domain)
 		return nil, fmt.Errorf("获取 DNS 记录失败: %v", err)
 	}
 	return records, nil
 }
 
 func ListDNSRecords(account config.CF, domain string) ([]cloudflare.DNSRecord, error) {
-	return NewClient().ListDNSRecords(context.Background(), account, domain)
+	return defaultClient.ListDNSRecords(context.Background(), account, domain)
 }
 
 // PauseDomain 设置 zone 的 paused 状态
@@ -128,54 +199,64 @@ func (c *apiClient) PauseDomain(ctx context.Context, account config.CF, domain s
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.api(account)
 	if err != nil {
-		return fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
+		return err
 	}
 
-	zones, err := api.ListZonesContext(ctx, cloudflare.WithZoneFilters(domain, "", ""))
-	if err != nil {
-		return fmt.Errorf("获取 Zone 失败: %v", err)
-	}
-	if len(zones.Result) == 0 {
+	zone, err := c.zone(ctx, api, account, domain)
+	if errors.Is(err, ErrZoneNotFound) {
 		return fmt.Errorf("未找到域名 %s", domain)
 	}
+	if err != nil {
+		return err
+	}
 
-	zoneID := zones.Result[0].ID
 	_, err = api.EditZone(
 		ctx,
-		zoneID,
+		zone.ID,
 		cloudflare.ZoneOptions{Paused: &pause},
 	)
 	if err != nil {
+		c.forgetZone(account, domain)
 		return fmt.Errorf("设置 paused 失败: %v", err)
 	}
 	return nil
 }
 
 func PauseDomain(account config.CF, domain string, pause bool) error {
-	return NewClient().PauseDomain(context.Background(), account, domain, pause)
+	return defaultClient.PauseDomain(context.Background(), account, domain, pause)
 }
 
-// GetZoneDetails 根据域名查找 Cloudflare zone
+// GetZoneDetails 根据域名查找 Cloudflare zone，状态等信息总是实时查询，同时刷新 Zone 缓存
 func (c *apiClient) GetZoneDetails(ctx context.Context, account config.CF, domain string) (ZoneDetail, error) {
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.api(account)
 	if err != nil {
-		return ZoneDetail{}, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
+		return ZoneDetail{}, err
 	}
+	return c.lookupZone(ctx, api, account, domain)
+}
 
-	zones, err := api.ListZonesContext(ctx, cloudflare.WithZoneFilters(domain, "", ""))
+// CreateZone 将域名添加到 Cloudflare
+func (c *apiClient) CreateZone(ctx context.Context, account config.CF, domain string) (ZoneDetail, error) {
+	ctx, cancel := ensureTimeout(ctx)
+	defer cancel()
+
+	api, err := c.api(account)
 	if err != nil {
-		return ZoneDetail{}, fmt.Errorf("获取 Zone 失败: %v", err)
+		return ZoneDetail{}, err
 	}
-	if len(zones.Result) == 0 {
-		return ZoneDetail{}, fmt.Errorf("%w: %s", ErrZoneNotFound, domain)
+
+	cfAccount := cloudflare.Account{ID: account.AccountID, Name: account.Label}
+	zone, err := api.CreateZone(ctx, domain, false, cfAccount, "full")
+	if err != nil {
+		return ZoneDetail{}, fmt.Errorf("创建域名失败: %v", err)
 	}
+	c.rememberZone(account, zone.Name, zone.ID)
 
-	zone := zones.Result[0]
 	return ZoneDetail{
 		ID:          zone.ID,
 		Name:        zone.Name,
@@ -185,106 +266,217 @@ func (c *apiClient) GetZoneDetails(ctx context.Context, account config.CF, domai
 	}, nil
 }
 
-// CreateZone 将域名添加到 Cloudflare
-func (c *apiClient) CreateZone(ctx context.Context, account config.CF, domain string) (ZoneDetail, error) {
+// GetDNSSEC 返回 Zone 的 DNSSEC 状态
+func (c *apiClient) GetDNSSEC(ctx context.Context, account config.CF, domain string) (DNSSECStatus, error) {
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.api(account)
 	if err != nil {
-		return ZoneDetail{}, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
+		return DNSSECStatus{}, err
 	}
 
-	cfAccount := cloudflare.Account{ID: account.AccountID, Name: account.Label}
-	zone, err := api.CreateZone(ctx, domain, false, cfAccount, "full")
+	zone, err := c.zone(ctx, api, account, domain)
 	if err != nil {
-		return ZoneDetail{}, fmt.Errorf("创建域名失败: %v", err)
+		return DNSSECStatus{}, err
 	}
 
-	return ZoneDetail{
-		ID:          zone.ID,
-		Name:        zone.Name,
-		NameServers: zone.NameServers,
-		Status:      zone.Status,
-		Paused:      zone.Paused,
+	setting, err := api.ZoneDNSSECSetting(ctx, zone.ID)
+	if err != nil {
+		c.forgetZone(account, domain)
+		return DNSSECStatus{}, fmt.Errorf("获取 DNSSEC 状态失败: %v", err)
+	}
+	return DNSSECStatus{
+		Status:    setting.Status,
+		KeyTag:    setting.KeyTag,
+		Algorithm: setting.Algorithm,
+		Digest:    setting.Digest,
+		DS:        setting.DS,
 	}, nil
 }
 
-// UpsertDNSRecord 创建或更新解析记录
-func (c *apiClient) UpsertDNSRecord(ctx context.Context, account config.CF, domain string, params DNSRecordParams) (cloudflare.DNSRecord, error) {
+// ListCertificatePacks 返回 Zone 的边缘证书包
+func (c *apiClient) ListCertificatePacks(ctx context.Context, account config.CF, domain string) ([]CertificatePack, error) {
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.api(account)
 	if err != nil {
-		return cloudflare.DNSRecord{}, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
+		return nil, err
 	}
 
-	zone, err := c.GetZoneDetails(ctx, account, domain)
+	zone, err := c.zone(ctx, api, account, domain)
 	if err != nil {
-		return cloudflare.DNSRecord{}, err
+		return nil, err
 	}
 
-	ttl := params.TTL
-	if ttl == 0 {
-		ttl = 1
+	packs, err := api.ListCertificatePacks(ctx, zone.ID)
+	if err != nil {
+		c.forgetZone(account, domain)
+		return nil, fmt.Errorf("获取证书包失败: %v", err)
 	}
-	proxied := params.Proxied
+	out := make([]CertificatePack, 0, len(packs))
+	for _, p := range packs {
+		pack := CertificatePack{
+			ID:                   p.ID,
+			Type:                 p.Type,
+			Hosts:                p.Hosts,
+			Status:               p.Status,
+			CertificateAuthority: p.CertificateAuthority,
+			Validation:           validationRecords(p.ValidationRecords),
+			ValidationErrors:     validationErrors(p.ValidationErrors),
+		}
+		for _, cert := range p.Certificates {
+			if cert.ExpiresOn.After(pack.ExpiresOn) {
+				pack.ExpiresOn = cert.ExpiresOn
+			}
+		}
+		out = append(out, pack)
+	}
+	return out, nil
+}
+
+// ListCustomHostnames 返回 Zone 的全部自定义主机名
+func (c *apiClient) ListCustomHostnames(ctx context.Context, account config.CF, domain string) ([]CustomHostname, error) {
+	ctx, cancel := ensureTimeout(ctx)
+	defer cancel()
 
-	searchParams := cloudflare.ListDNSRecordsParams{Type: params.Type}
-	if params.Name == "" {
-		searchParams.Name = zone.Name
-	} else if strings.Contains(params.Name, zone.Name) {
-		searchParams.Name = params.Name
-	} else {
-		searchParams.Name = fmt.Sprintf("%s.%s", params.Name, zone.Name)
+	api, err := c.api(account)
+	if err != nil {
+		return nil, err
 	}
 
-	existing, _, err := api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zone.ID), searchParams)
+	zone, err := c.zone(ctx, api, account, domain)
 	if err != nil {
-		return cloudflare.DNSRecord{}, fmt.Errorf("查询解析记录失败: %v", err)
+		return nil, err
 	}
 
-	if len(existing) > 0 {
-		target := existing[0]
-		record, err := api.UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(zone.ID), cloudflare.UpdateDNSRecordParams{
-			ID:      target.ID,
-			Type:    params.Type,
-			Name:    searchParams.Name,
-			Content: params.Content,
-			TTL:     ttl,
-			Proxied: &proxied,
-		})
+	var out []CustomHostname
+	for page := 1; ; page++ {
+		hostnames, info, err := api.CustomHostnames(ctx, zone.ID, page, cloudflare.CustomHostname{})
 		if err != nil {
-			return cloudflare.DNSRecord{}, fmt.Errorf("更新解析记录失败: %v", err)
+			return nil, fmt.Errorf("获取自定义主机名失败: %v", err)
+		}
+		for _, h := range hostnames {
+			host := CustomHostname{
+				ID:               h.ID,
+				Hostname:         h.Hostname,
+				Status:           string(h.Status),
+				ValidationErrors: append([]string(nil), h.VerificationErrors...),
+			}
+			if h.SSL != nil {
+				host.SSLStatus = h.SSL.Status
+				host.Issuer = h.SSL.Issuer
+				host.Validation = validationRecords(h.SSL.ValidationRecords)
+				host.ValidationErrors = append(host.ValidationErrors, validationErrors(h.SSL.ValidationErrors)...)
+				for _, cert := range h.SSL.Certificates {
+					if cert.ExpiresOn != nil && cert.ExpiresOn.After(host.ExpiresOn) {
+						host.ExpiresOn = *cert.ExpiresOn
+						host.Issuer = cert.Issuer
+					}
+				}
+			}
+			out = append(out, host)
 		}
-		return record, nil
+		if page >= info.TotalPages {
+			break
+		}
+	}
+	return out, nil
+}
+
+func validationRecords(records []cloudflare.SSLValidationRecord) []string {
+	var out []string
+	for _, r := range records {
+		switch {
+		case r.TxtName != "":
+			out = append(out, fmt.Sprintf("TXT %s %s", r.TxtName, r.TxtValue))
+		case r.CnameName != "":
+			out = append(out, fmt.Sprintf("CNAME %s %s", r.CnameName, r.CnameTarget))
+		case r.HTTPUrl != "":
+			out = append(out, fmt.Sprintf("HTTP %s %s", r.HTTPUrl, r.HTTPBody))
+		}
+	}
+	return out
+}
+
+func validationErrors(errs []cloudflare.SSLValidationError) []string {
+	var out []string
+	for _, e := range errs {
+		if e.Message != "" {
+			out = append(out, e.Message)
+		}
+	}
+	return out
+}
+
+// UpsertDNSRecord 创建或更新解析记录：同名同类型的记录不存在时按 params 创建，只有一条时更新。
+// 存在多条时只更新内容与 params 相同的那一条（例如切换代理），否则返回 ErrAmbiguousRecord，
+// 避免覆盖轮询 A 记录或多条 TXT 记录中的任意一条。
+// 更新时以原记录为基础，只替换内容，再由 overlay 写入调用方明确指定的选项，未指定的代理、TTL 等保持不变；
+// overlay 为空时只修改内容。
+func (c *apiClient) UpsertDNSRecord(ctx context.Context, account config.CF, domain string, params DNSRecordParams, overlay func(*DNSRecordParams) error) (cloudflare.DNSRecord, error) {
+	if err := params.Validate(); err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	ctx, cancel := ensureTimeout(ctx)
+	defer cancel()
+
+	api, err := c.api(account)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
 	}
 
-	record, err := api.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zone.ID), cloudflare.CreateDNSRecordParams{
-		Type:    params.Type,
-		Name:    searchParams.Name,
-		Content: params.Content,
-		TTL:     ttl,
-		Proxied: &proxied,
-	})
+	zone, err := c.zone(ctx, api, account, domain)
 	if err != nil {
-		return cloudflare.DNSRecord{}, fmt.Errorf("创建解析记录失败: %v", err)
+		return cloudflare.DNSRecord{}, err
 	}
 
-	return record, nil
+	searchParams := cloudflare.ListDNSRecordsParams{
+		Type: strings.ToUpper(params.Type),
+		Name: RecordFQDN(params.Name, zone.Name),
+	}
+	existing, _, err := api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zone.ID), searchParams)
+	if err != nil {
+		c.forgetZone(account, domain)
+		return cloudflare.DNSRecord{}, fmt.Errorf("查询解析记录失败: %v", err)
+	}
+
+	update := func(r cloudflare.DNSRecord) (cloudflare.DNSRecord, error) {
+		merged := ParamsFromRecord(r)
+		merged.Content = params.Content
+		if overlay != nil {
+			if err := overlay(&merged); err != nil {
+				return cloudflare.DNSRecord{}, err
+			}
+		}
+		if err := merged.Validate(); err != nil {
+			return cloudflare.DNSRecord{}, err
+		}
+		return c.updateRecord(ctx, api, zone, r.ID, merged)
+	}
+	switch len(existing) {
+	case 0:
+		return c.createRecord(ctx, api, zone, params)
+	case 1:
+		return update(existing[0])
+	}
+	for _, r := range existing {
+		if strings.EqualFold(strings.TrimSuffix(r.Content, "."), strings.TrimSuffix(params.Content, ".")) {
+			return update(r)
+		}
+	}
+	return cloudflare.DNSRecord{}, fmt.Errorf("%w: %s %s 共 %d 条，请按记录 ID 修改", ErrAmbiguousRecord, searchParams.Type, searchParams.Name, len(existing))
 }
+
 func (c *apiClient) FetchAllDomains(ctx context.Context, account config.CF) ([]DomainInfo, error) {
 
 	ctx, cancel := ensureTimeout(ctx)
 	defer cancel()
 
-	api, err := cloudflare.NewWithAPIToken(account.APIToken)
+	api, err := c.api(account)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"初始化 Cloudflare 客户端失败 [%s]: %v",
-			account.Label, err,
-		)
+		return nil, err
 	}
 
 	zones, err := api.ListZonesContext(ctx)
@@ -298,12 +490,15 @@ func (c *apiClient) FetchAllDomains(ctx context.Context, account config.CF) ([]D
 	out := make([]DomainInfo, 0, len(zones.Result))
 
 	for _, z := range zones.Result {
+		c.rememberZone(account, z.Name, z.ID)
 		out = append(out, DomainInfo{
-			Domain: z.Name,
-			Source: account.Label,
-			IsCF:   true,
-			Status: z.Status,
-			Paused: z.Paused,
+			Domain:      z.Name,
+			ZoneID:      z.ID,
+			Source:      account.Label,
+			IsCF:        true,
+			Status:      z.Status,
+			Paused:      z.Paused,
+			NameServers: z.NameServers,
 		})
 	}
 
@@ -311,7 +506,7 @@ func (c *apiClient) FetchAllDomains(ctx context.Context, account config.CF) ([]D
 }
 
 func FetchAllDomains(account config.CF) ([]DomainInfo, error) {
-	return NewClient().FetchAllDomains(context.Background(), account)
+	return defaultClient.FetchAllDomains(context.Background(), account)
 }
 
 // GetAccountByLabel 返回配置中与 label 匹配的 Cloudflare 账号指针，找不到则返回 nil
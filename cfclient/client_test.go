@@ -0,0 +1,197 @@
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"DomainC/config"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+// fakeAPI 模拟 Cloudflare API 中与 Zone 查找相关的接口，并统计 ListZones 次数。
+type fakeAPI struct {
+	mu        sync.Mutex
+	zoneLists int
+	deleted   bool
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	const page = `"result_info":{"page":1,"per_page":50,"total_pages":1,"count":%d,"total_count":%d}`
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+		f.zoneLists++
+		if f.deleted || r.URL.Query().Get("name") != "example.com" {
+			fmt.Fprintf(w, `{"success":true,"errors":[],"messages":[],"result":[],`+page+`}`, 0, 0)
+			return
+		}
+		fmt.Fprintf(w, `{"success":true,"errors":[],"messages":[],"result":[{"id":"zone-1","name":"example.com","status":"active"}],`+page+`}`, 1, 1)
+	case r.Method == http.MethodGet && r.URL.Path == "/zones/zone-1/dns_records":
+		fmt.Fprintf(w, `{"success":true,"errors":[],"messages":[],"result":[{"id":"rec-1","type":"A","name":"example.com","content":"192.0.2.1"}],`+page+`}`, 1, 1)
+	case r.Method == http.MethodDelete && r.URL.Path == "/zones/zone-1":
+		f.deleted = true
+		fmt.Fprint(w, `{"success":true,"errors":[],"messages":[],"result":{"id":"zone-1"}}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeAPI) lists() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.zoneLists
+}
+
+func TestAPIClientCachesZoneIDs(t *testing.T) {
+	fake := &fakeAPI{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	now := time.Now()
+	c := &apiClient{
+		ZoneTTL: time.Minute,
+		options: []cloudflare.Option{cloudflare.BaseURL(srv.URL)},
+		clock:   func() time.Time { return now },
+	}
+	account := config.CF{Label: "acc", APIToken: "token"}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		records, err := c.ListDNSRecords(ctx, account, "example.com")
+		if err != nil {
+			t.Fatalf("ListDNSRecords: %v", err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("unexpected records %+v", records)
+		}
+	}
+	if got := fake.lists(); got != 1 {
+		t.Fatalf("expected zone lookup to be cached, got %d ListZones calls", got)
+	}
+	if len(c.apis) != 1 {
+		t.Fatalf("expected one API handle per account, got %d", len(c.apis))
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := c.ListDNSRecords(ctx, account, "example.com"); err != nil {
+		t.Fatalf("ListDNSRecords after expiry: %v", err)
+	}
+	if got := fake.lists(); got != 2 {
+		t.Fatalf("expected expired entry to be refreshed, got %d ListZones calls", got)
+	}
+
+	if err := c.DeleteDomain(ctx, account, "example.com"); err != nil {
+		t.Fatalf("DeleteDomain: %v", err)
+	}
+	if _, err := c.ListDNSRecords(ctx, account, "example.com"); !errors.Is(err, ErrZoneNotFound) {
+		t.Fatalf("expected deleted zone to be looked up again, got %v", err)
+	}
+	if got := fake.lists(); got != 3 {
+		t.Fatalf("expected delete to invalidate cache, got %d ListZones calls", got)
+	}
+}
+
+// recordsAPI 返回 www.example.com 的两条 A 记录与 one.example.com 的一条已代理记录，
+// 并记录更新请求的路径与内容。
+type recordsAPI struct {
+	mu      sync.Mutex
+	updated []string
+	bodies  []string
+}
+
+func (f *recordsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	const page = `"result_info":{"page":1,"per_page":50,"total_pages":1,"count":%d,"total_count":%d}`
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+		fmt.Fprintf(w, `{"success":true,"errors":[],"messages":[],"result":[{"id":"zone-1","name":"example.com","status":"active"}],`+page+`}`, 1, 1)
+	case r.Method == http.MethodGet && r.URL.Path == "/zones/zone-1/dns_records":
+		if r.URL.Query().Get("name") == "one.example.com" {
+			fmt.Fprintf(w, `{"success":true,"errors":[],"messages":[],"result":[`+
+				`{"id":"rec-3","type":"A","name":"one.example.com","content":"192.0.2.3","proxied":true,"ttl":1,"comment":"源站"}],`+page+`}`, 1, 1)
+			return
+		}
+		if r.URL.Query().Get("name") != "www.example.com" {
+			fmt.Fprintf(w, `{"success":true,"errors":[],"messages":[],"result":[],`+page+`}`, 0, 0)
+			return
+		}
+		fmt.Fprintf(w, `{"success":true,"errors":[],"messages":[],"result":[`+
+			`{"id":"rec-1","type":"A","name":"www.example.com","content":"192.0.2.1"},`+
+			`{"id":"rec-2","type":"A","name":"www.example.com","content":"192.0.2.2"}],`+page+`}`, 2, 2)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/zones/zone-1/dns_records/"):
+		f.updated = append(f.updated, strings.TrimPrefix(r.URL.Path, "/zones/zone-1/dns_records/"))
+		body, _ := io.ReadAll(r.Body)
+		f.bodies = append(f.bodies, string(body))
+		fmt.Fprint(w, `{"success":true,"errors":[],"messages":[],"result":{"id":"rec-2","type":"A","name":"www.example.com","content":"192.0.2.2"}}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestUpsertDNSRecordRefusesAmbiguousMatch(t *testing.T) {
+	fake := &recordsAPI{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	c := &apiClient{options: []cloudflare.Option{cloudflare.BaseURL(srv.URL)}}
+	account := config.CF{Label: "acc", APIToken: "token"}
+	ctx := context.Background()
+
+	_, err := c.UpsertDNSRecord(ctx, account, "example.com", DNSRecordParams{Type: "A", Name: "www", Content: "192.0.2.9"}, nil)
+	if !errors.Is(err, ErrAmbiguousRecord) {
+		t.Fatalf("expected ErrAmbiguousRecord, got %v", err)
+	}
+	if len(fake.updated) != 0 {
+		t.Fatalf("ambiguous upsert must not update, got %v", fake.updated)
+	}
+
+	proxy := func(p *DNSRecordParams) error {
+		p.Proxied = true
+		return nil
+	}
+	if _, err := c.UpsertDNSRecord(ctx, account, "example.com", DNSRecordParams{Type: "A", Name: "www.example.com", Content: "192.0.2.2", Proxied: true}, proxy); err != nil {
+		t.Fatalf("UpsertDNSRecord: %v", err)
+	}
+	if len(fake.updated) != 1 || fake.updated[0] != "rec-2" || !strings.Contains(fake.bodies[0], `"proxied":true`) {
+		t.Fatalf("expected matching record rec-2 to be proxied, got %v %v", fake.updated, fake.bodies)
+	}
+
+	// 未指定的代理与备注沿用原记录
+	if _, err := c.UpsertDNSRecord(ctx, account, "example.com", DNSRecordParams{Type: "A", Name: "one", Content: "192.0.2.4"}, nil); err != nil {
+		t.Fatalf("UpsertDNSRecord: %v", err)
+	}
+	if len(fake.bodies) != 2 || !strings.Contains(fake.bodies[1], `"proxied":true`) ||
+		!strings.Contains(fake.bodies[1], `"content":"192.0.2.4"`) || !strings.Contains(fake.bodies[1], "源站") {
+		t.Fatalf("expected existing settings to be kept, got %v", fake.bodies)
+	}
+}
+
+func TestRecordFQDN(t *testing.T) {
+	cases := []struct{ name, want string }{
+		{"", "example.com"},
+		{"@", "example.com"},
+		{"www", "www.example.com"},
+		{"WWW.Example.com.", "www.example.com"},
+		{"example.com", "example.com"},
+		{"a.b", "a.b.example.com"},
+		{"myexample.com", "myexample.com.example.com"},
+	}
+	for _, c := range cases {
+		if got := RecordFQDN(c.name, "example.com"); got != c.want {
+			t.Errorf("RecordFQDN(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
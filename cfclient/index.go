@@ -0,0 +1,274 @@
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/config"
+)
+
+// DefaultIndexRefresh 为 Zone 索引后台刷新的默认间隔。
+const DefaultIndexRefresh = 30 * time.Minute
+
+// ZoneEntry 为索引中的一个 Zone。
+type ZoneEntry struct {
+	Domain      string
+	Account     string
+	ZoneID      string
+	Status      string
+	Paused      bool
+	NameServers []string
+}
+
+// ZoneIndex 汇总所有账号下的 Zone，按域名直接定位所属账号，避免逐个账号查询。
+// 同一域名可能同时存在于多个账号（例如迁移时新账号中为 pending），索引按账号分别记录，
+// 按域名查询时优先返回 active 的记录。
+// 索引由 FetchAllDomains 构建并在后台定期刷新，创建、删除、暂停 Zone 后由调用方更新；
+// 索引未命中或记录已过时（对应账号中已找不到该 Zone）时回退为实时查询所有账号。
+type ZoneIndex struct {
+	Client   Client
+	Accounts []config.CF
+
+	mu sync.RWMutex
+	// entries 为域名 → 账号 label → 记录。
+	entries map[string]map[string]ZoneEntry
+}
+
+func NewZoneIndex(client Client, accounts []config.CF) *ZoneIndex {
+	if client == nil {
+		client = NewClient()
+	}
+	return &ZoneIndex{Client: client, Accounts: accounts, entries: make(map[string]map[string]ZoneEntry)}
+}
+
+// Start 在后台立即构建索引，之后每隔 interval 刷新一次，直到 ctx 结束。
+func (x *ZoneIndex) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultIndexRefresh
+	}
+	go func() {
+		if err := x.Refresh(ctx); err != nil {
+			log.Printf("刷新 Zone 索引失败: %v", err)
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := x.Refresh(ctx); err != nil {
+					log.Printf("刷新 Zone 索引失败，失败的账号沿用旧数据: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Refresh 重新获取所有账号的 Zone。某个账号获取失败时保留该账号原有的记录，其余账号照常更新。
+func (x *ZoneIndex) Refresh(ctx context.Context) error {
+	fresh := make(map[string]map[string]ZoneEntry)
+	failed := make(map[string]bool)
+	var errs []error
+	for _, acc := range x.Accounts {
+		domains, err := x.Client.FetchAllDomains(ctx, acc)
+		if err != nil {
+			failed[acc.Label] = true
+			errs = append(errs, fmt.Errorf("[%s] %w", acc.Label, err))
+			continue
+		}
+		for _, d := range domains {
+			putEntry(fresh, ZoneEntry{
+				Domain:      d.Domain,
+				Account:     acc.Label,
+				ZoneID:      d.ZoneID,
+				Status:      d.Status,
+				Paused:      d.Paused,
+				NameServers: d.NameServers,
+			})
+		}
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, byAccount := range x.entries {
+		for label, entry := range byAccount {
+			if failed[label] {
+				putEntry(fresh, entry)
+			}
+		}
+	}
+	x.entries = fresh
+	return errors.Join(errs...)
+}
+
+// Lookup 返回索引中的记录，不发起请求。域名存在于多个账号时优先返回 active 的记录，
+// 其次按 Accounts 的顺序。
+func (x *ZoneIndex) Lookup(domain string) (ZoneEntry, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	var first ZoneEntry
+	found := false
+	for _, acc := range x.Accounts {
+		entry, ok := x.entries[indexKey(domain)][acc.Label]
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(entry.Status, "active") {
+			return entry, true
+		}
+		if !found {
+			first, found = entry, true
+		}
+	}
+	return first, found
+}
+
+// Find 返回域名所属账号与 Zone 的实时信息。索引命中时只查询对应账号，
+// 未命中或索引中的账号都已没有此 Zone 时查询所有账号，单个账号出错不影响其他账号。
+func (x *ZoneIndex) Find(ctx context.Context, domain string) (*config.CF, ZoneDetail, error) {
+	for {
+		entry, ok := x.Lookup(domain)
+		if !ok {
+			break
+		}
+		if acc := x.account(entry.Account); acc != nil {
+			zone, err := x.Client.GetZoneDetails(ctx, *acc, domain)
+			if err == nil {
+				x.Put(acc.Label, zone)
+				return acc, zone, nil
+			}
+			if !errors.Is(err, ErrZoneNotFound) {
+				return nil, ZoneDetail{}, err
+			}
+		}
+		x.Remove(entry.Account, domain)
+	}
+	return x.findLive(ctx, domain)
+}
+
+// findLive 查询所有账号，域名存在于多个账号时优先返回 active 的 Zone。
+func (x *ZoneIndex) findLive(ctx context.Context, domain string) (*config.CF, ZoneDetail, error) {
+	var (
+		errs  []error
+		found *config.CF
+		zone  ZoneDetail
+	)
+	for i := range x.Accounts {
+		acc := x.Accounts[i]
+		z, err := x.Client.GetZoneDetails(ctx, acc, domain)
+		if err != nil {
+			if !errors.Is(err, ErrZoneNotFound) {
+				errs = append(errs, fmt.Errorf("[%s] %w", acc.Label, err))
+			}
+			continue
+		}
+		x.Put(acc.Label, z)
+		if found == nil || (!strings.EqualFold(zone.Status, "active") && strings.EqualFold(z.Status, "active")) {
+			found, zone = &acc, z
+		}
+	}
+	if found != nil {
+		return found, zone, nil
+	}
+	if len(errs) > 0 {
+		// 失败的账号中可能有该 Zone，不能断定不存在
+		return nil, ZoneDetail{}, fmt.Errorf("其余账号中未找到 %s，部分账号查询失败: %w", domain, errors.Join(errs...))
+	}
+	return nil, ZoneDetail{}, fmt.Errorf("%w: %s", ErrZoneNotFound, domain)
+}
+
+// Put 记录或更新一个 Zone，用于创建 Zone 或实时查询之后。
+func (x *ZoneIndex) Put(label string, zone ZoneDetail) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.entries == nil {
+		x.entries = make(map[string]map[string]ZoneEntry)
+	}
+	putEntry(x.entries, ZoneEntry{
+		Domain:      zone.Name,
+		Account:     label,
+		ZoneID:      zone.ID,
+		Status:      zone.Status,
+		Paused:      zone.Paused,
+		NameServers: zone.NameServers,
+	})
+}
+
+// SetPaused 更新索引中账号 label 下 Zone 的暂停状态。
+func (x *ZoneIndex) SetPaused(label, domain string, paused bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if entry, ok := x.entries[indexKey(domain)][label]; ok {
+		entry.Paused = paused
+		x.entries[indexKey(domain)][label] = entry
+	}
+}
+
+// Remove 删除索引中账号 label 下的 Zone，用于删除 Zone 之后。
+func (x *ZoneIndex) Remove(label, domain string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	key := indexKey(domain)
+	delete(x.entries[key], label)
+	if len(x.entries[key]) == 0 {
+		delete(x.entries, key)
+	}
+}
+
+// Account 返回域名所属账号，索引未命中时返回 nil。
+func (x *ZoneIndex) Account(domain string) *config.CF {
+	entry, ok := x.Lookup(domain)
+	if !ok {
+		return nil
+	}
+	return x.account(entry.Account)
+}
+
+// AccountFor 返回对 label 账号下的 domain 进行操作时应使用的账号：
+// 索引显示 label 账号中仍有该 Zone 或索引中没有该域名时使用 label 对应的账号，
+// 只有该账号中已没有此 Zone 时才改用索引中的账号。
+func (x *ZoneIndex) AccountFor(label, domain string) *config.CF {
+	if !x.Lost(label, domain) {
+		if acc := x.account(label); acc != nil {
+			return acc
+		}
+	}
+	return x.Account(domain)
+}
+
+// Lost 报告索引是否显示 label 账号中已没有该 Zone：索引中有该域名但不在 label 账号下。
+// 索引中没有该域名时无法断定，返回 false。
+func (x *ZoneIndex) Lost(label, domain string) bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	byAccount := x.entries[indexKey(domain)]
+	_, owned := byAccount[label]
+	return len(byAccount) > 0 && !owned
+}
+
+func (x *ZoneIndex) account(label string) *config.CF {
+	for i := range x.Accounts {
+		if x.Accounts[i].Label == label {
+			return &x.Accounts[i]
+		}
+	}
+	return nil
+}
+
+func putEntry(entries map[string]map[string]ZoneEntry, entry ZoneEntry) {
+	key := indexKey(entry.Domain)
+	if entries[key] == nil {
+		entries[key] = make(map[string]ZoneEntry)
+	}
+	entries[key][entry.Account] = entry
+}
+
+func indexKey(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
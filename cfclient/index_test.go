@@ -0,0 +1,156 @@
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"DomainC/config"
+)
+
+// indexCF 按账号返回固定的 Zone，并记录 GetZoneDetails 查询过的账号。
+type indexCF struct {
+	Client
+	zones   map[string][]string
+	pending map[string]bool
+	failing map[string]bool
+	queried []string
+}
+
+func (f *indexCF) FetchAllDomains(ctx context.Context, account config.CF) ([]DomainInfo, error) {
+	if f.failing[account.Label] {
+		return nil, errors.New("boom")
+	}
+	var out []DomainInfo
+	for _, d := range f.zones[account.Label] {
+		out = append(out, DomainInfo{Domain: d, ZoneID: account.Label + "-" + d, Source: account.Label, IsCF: true, Status: f.status(account.Label, d)})
+	}
+	return out, nil
+}
+
+// status 默认为 active，pending 中列出的 "账号|域名" 为 pending。
+func (f *indexCF) status(label, domain string) string {
+	if f.pending[label+"|"+domain] {
+		return "pending"
+	}
+	return "active"
+}
+
+func (f *indexCF) GetZoneDetails(ctx context.Context, account config.CF, domain string) (ZoneDetail, error) {
+	f.queried = append(f.queried, account.Label)
+	if f.failing[account.Label] {
+		return ZoneDetail{}, errors.New("boom")
+	}
+	for _, d := range f.zones[account.Label] {
+		if d == domain {
+			return ZoneDetail{ID: account.Label + "-" + d, Name: d, Status: f.status(account.Label, d)}, nil
+		}
+	}
+	return ZoneDetail{}, ErrZoneNotFound
+}
+
+var indexAccounts = []config.CF{{Label: "a"}, {Label: "b"}, {Label: "c"}}
+
+func TestZoneIndexRefreshKeepsFailingAccount(t *testing.T) {
+	cf := &indexCF{zones: map[string][]string{"a": {"a.com"}, "b": {"b.com"}}}
+	idx := NewZoneIndex(cf, indexAccounts)
+	if err := idx.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	cf.failing = map[string]bool{"b": true}
+	cf.zones["a"] = []string{"new.com"}
+	if err := idx.Refresh(context.Background()); err == nil {
+		t.Fatal("expected refresh error")
+	}
+	if _, ok := idx.Lookup("a.com"); ok {
+		t.Fatal("a.com should be gone after refresh")
+	}
+	if e, ok := idx.Lookup("NEW.com."); !ok || e.Account != "a" || e.ZoneID != "a-new.com" {
+		t.Fatalf("unexpected entry for new.com: %+v %v", e, ok)
+	}
+	if e, ok := idx.Lookup("b.com"); !ok || e.Account != "b" {
+		t.Fatalf("b.com should be kept while account b fails: %+v %v", e, ok)
+	}
+}
+
+func TestZoneIndexFindUsesOwningAccount(t *testing.T) {
+	cf := &indexCF{zones: map[string][]string{"c": {"c.com"}}}
+	idx := NewZoneIndex(cf, indexAccounts)
+	if err := idx.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	acc, zone, err := idx.Find(context.Background(), "c.com")
+	if err != nil || acc.Label != "c" || zone.ID != "c-c.com" {
+		t.Fatalf("find: %v %+v %v", acc, zone, err)
+	}
+	if len(cf.queried) != 1 || cf.queried[0] != "c" {
+		t.Fatalf("expected single query to account c, got %v", cf.queried)
+	}
+}
+
+func TestZoneIndexFindFallsBackWhenStale(t *testing.T) {
+	cf := &indexCF{zones: map[string][]string{"a": {"moved.com"}}}
+	idx := NewZoneIndex(cf, indexAccounts)
+	if err := idx.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	// Zone 已移到账号 c，且账号 b 查询失败
+	cf.zones = map[string][]string{"c": {"moved.com"}}
+	cf.failing = map[string]bool{"b": true}
+
+	acc, _, err := idx.Find(context.Background(), "moved.com")
+	if err != nil || acc.Label != "c" {
+		t.Fatalf("find: %v %v", acc, err)
+	}
+	if e, _ := idx.Lookup("moved.com"); e.Account != "c" {
+		t.Fatalf("index not updated: %+v", e)
+	}
+
+	_, _, err = idx.Find(context.Background(), "missing.com")
+	if err == nil || errors.Is(err, ErrZoneNotFound) {
+		t.Fatalf("failing account must not be reported as not found: %v", err)
+	}
+	cf.failing = nil
+	if _, _, err := idx.Find(context.Background(), "missing.com"); !errors.Is(err, ErrZoneNotFound) {
+		t.Fatalf("expected ErrZoneNotFound, got %v", err)
+	}
+}
+
+func TestZoneIndexKeepsZonesPerAccount(t *testing.T) {
+	// 迁移中的 Zone 在账号 a 中为 pending，在账号 c 中为 active
+	cf := &indexCF{
+		zones:   map[string][]string{"a": {"dup.com"}, "c": {"dup.com"}},
+		pending: map[string]bool{"a|dup.com": true},
+	}
+	idx := NewZoneIndex(cf, indexAccounts)
+	if err := idx.Refresh(context.Background()); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	if e, ok := idx.Lookup("dup.com"); !ok || e.Account != "c" {
+		t.Fatalf("expected active zone to be preferred, got %+v %v", e, ok)
+	}
+	if acc := idx.AccountFor("a", "dup.com"); acc == nil || acc.Label != "a" {
+		t.Fatalf("expected label account to be kept while it still has the zone, got %v", acc)
+	}
+	if acc := idx.AccountFor("b", "dup.com"); acc == nil || acc.Label != "c" {
+		t.Fatalf("expected fallback to the indexed account, got %v", acc)
+	}
+	if acc := idx.AccountFor("a", "unknown.com"); acc == nil || acc.Label != "a" {
+		t.Fatalf("expected label account for zones missing from the index, got %v", acc)
+	}
+	if idx.Lost("a", "dup.com") || !idx.Lost("b", "dup.com") || idx.Lost("b", "unknown.com") {
+		t.Fatalf("Lost must only report accounts the index knows lost the zone")
+	}
+
+	idx.Remove("c", "dup.com")
+	if e, ok := idx.Lookup("dup.com"); !ok || e.Account != "a" {
+		t.Fatalf("removing one account must keep the other entry, got %+v %v", e, ok)
+	}
+	acc, zone, err := idx.findLive(context.Background(), "dup.com")
+	if err != nil || acc.Label != "c" || zone.Status != "active" {
+		t.Fatalf("expected live lookup to prefer the active zone, got %v %+v %v", acc, zone, err)
+	}
+}
@@ -0,0 +1,110 @@
+package cfclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"DomainC/config"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+// DefaultZoneCacheTTL 为 Zone 名称到 ID 缓存的默认有效期。
+const DefaultZoneCacheTTL = 10 * time.Minute
+
+// zoneRef 为缓存的 Zone 名称与 ID。
+type zoneRef struct {
+	ID      string
+	Name    string
+	expires time.Time
+}
+
+// api 返回账号对应的长期 API 句柄，同一账号的所有调用共用连接池。
+func (c *apiClient) api(account config.CF) (*cloudflare.API, error) {
+	key := account.Label + "|" + account.APIToken
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if api, ok := c.apis[key]; ok {
+		return api, nil
+	}
+	api, err := cloudflare.NewWithAPIToken(account.APIToken, c.options...)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 Cloudflare 客户端失败 [%s]: %v", account.Label, err)
+	}
+	if c.apis == nil {
+		c.apis = make(map[string]*cloudflare.API)
+	}
+	c.apis[key] = api
+	return api, nil
+}
+
+// zone 返回域名对应的 Zone，缓存未命中或过期时查询 API。
+func (c *apiClient) zone(ctx context.Context, api *cloudflare.API, account config.CF, domain string) (zoneRef, error) {
+	key := zoneKey(account, domain)
+	c.mu.Lock()
+	ref, ok := c.zones[key]
+	c.mu.Unlock()
+	if ok && c.now().Before(ref.expires) {
+		return ref, nil
+	}
+
+	detail, err := c.lookupZone(ctx, api, account, domain)
+	if err != nil {
+		return zoneRef{}, err
+	}
+	return zoneRef{ID: detail.ID, Name: detail.Name}, nil
+}
+
+// lookupZone 通过 API 查询 Zone 并刷新缓存。
+func (c *apiClient) lookupZone(ctx context.Context, api *cloudflare.API, account config.CF, domain string) (ZoneDetail, error) {
+	zones, err := api.ListZonesContext(ctx, cloudflare.WithZoneFilters(domain, "", ""))
+	if err != nil {
+		return ZoneDetail{}, fmt.Errorf("获取 Zone 失败: %v", err)
+	}
+	if len(zones.Result) == 0 {
+		c.forgetZone(account, domain)
+		return ZoneDetail{}, fmt.Errorf("%w: %s", ErrZoneNotFound, domain)
+	}
+	zone := zones.Result[0]
+	c.rememberZone(account, zone.Name, zone.ID)
+	return ZoneDetail{
+		ID:          zone.ID,
+		Name:        zone.Name,
+		NameServers: zone.NameServers,
+		Status:      zone.Status,
+		Paused:      zone.Paused,
+	}, nil
+}
+
+func (c *apiClient) rememberZone(account config.CF, name, id string) {
+	ttl := c.ZoneTTL
+	if ttl <= 0 {
+		ttl = DefaultZoneCacheTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.zones == nil {
+		c.zones = make(map[string]zoneRef)
+	}
+	c.zones[zoneKey(account, name)] = zoneRef{ID: id, Name: name, expires: c.now().Add(ttl)}
+}
+
+// forgetZone 删除缓存，用于 Zone 被删除或使用缓存的 ID 调用失败时。
+func (c *apiClient) forgetZone(account config.CF, domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.zones, zoneKey(account, domain))
+}
+
+func (c *apiClient) now() time.Time {
+	if c.clock != nil {
+		return c.clock()
+	}
+	return time.Now()
+}
+
+func zoneKey(account config.CF, domain string) string {
+	return account.Label + "|" + strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
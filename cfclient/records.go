@@ -0,0 +1,378 @@
+package cfclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"DomainC/config"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+// ErrAmbiguousRecord 在同名同类型的记录有多条、无法确定要更新哪一条时返回，此时应按记录 ID 操作。
+var ErrAmbiguousRecord = errors.New("multiple records match")
+
+// DNSRecordFilter 为筛选解析记录的条件，为空的字段不筛选。
+// Name 可以是完整域名、相对 Zone 的前缀或 @。
+type DNSRecordFilter struct {
+	Type string
+	Name string
+}
+
+// SearchDNSRecords 按类型与名称返回解析记录。
+func (c *apiClient) SearchDNSRecords(ctx context.Context, account config.CF, domain string, filter DNSRecordFilter) ([]cloudflare.DNSRecord, error) {
+	ctx, cancel := ensureTimeout(ctx)
+	defer cancel()
+
+	api, err := c.api(account)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := c.zone(ctx, api, account, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	params := cloudflare.ListDNSRecordsParams{Type: strings.ToUpper(filter.Type)}
+	if filter.Name != "" {
+		params.Name = RecordFQDN(filter.Name, zone.Name)
+	}
+	records, _, err := api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zone.ID), params)
+	if err != nil {
+		c.forgetZone(account, domain)
+		return nil, fmt.Errorf("获取 DNS 记录失败: %v", err)
+	}
+	return records, nil
+}
+
+// GetDNSRecord 按 ID 返回解析记录。
+func (c *apiClient) GetDNSRecord(ctx context.Context, account config.CF, domain, id string) (cloudflare.DNSRecord, error) {
+	ctx, cancel := ensureTimeout(ctx)
+	defer cancel()
+
+	api, err := c.api(account)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+
+	zone, err := c.zone(ctx, api, account, domain)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+
+	record, err := api.GetDNSRecord(ctx, cloudflare.ZoneIdentifier(zone.ID), id)
+	if err != nil {
+		return cloudflare.DNSRecord{}, fmt.Errorf("获取解析记录失败: %v", err)
+	}
+	return record, nil
+}
+
+// CreateDNSRecord 新增一条解析记录，同名记录已存在时也不覆盖，用于轮询 A 记录、多条 TXT 等场景。
+func (c *apiClient) CreateDNSRecord(ctx context.Context, account config.CF, domain string, params DNSRecordParams) (cloudflare.DNSRecord, error) {
+	if err := params.Validate(); err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	ctx, cancel := ensureTimeout(ctx)
+	defer cancel()
+
+	api, err := c.api(account)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+
+	zone, err := c.zone(ctx, api, account, domain)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	return c.createRecord(ctx, api, zone, params)
+}
+
+// UpdateDNSRecord 按 ID 更新解析记录，params 为记录更新后的完整内容。
+func (c *apiClient) UpdateDNSRecord(ctx context.Context, account config.CF, domain, id string, params DNSRecordParams) (cloudflare.DNSRecord, error) {
+	if err := params.Validate(); err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	ctx, cancel := ensureTimeout(ctx)
+	defer cancel()
+
+	api, err := c.api(account)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+
+	zone, err := c.zone(ctx, api, account, domain)
+	if err != nil {
+		return cloudflare.DNSRecord{}, err
+	}
+	return c.updateRecord(ctx, api, zone, id, params)
+}
+
+// DeleteDNSRecord 按 ID 删除解析记录。
+func (c *apiClient) DeleteDNSRecord(ctx context.Context, account config.CF, domain, id string) error {
+	ctx, cancel := ensureTimeout(ctx)
+	defer cancel()
+
+	api, err := c.api(account)
+	if err != nil {
+		return err
+	}
+
+	zone, err := c.zone(ctx, api, account, domain)
+	if err != nil {
+		return err
+	}
+
+	if err := api.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zone.ID), id); err != nil {
+		return fmt.Errorf("删除解析记录失败: %v", err)
+	}
+	return nil
+}
+
+func (c *apiClient) createRecord(ctx context.Context, api *cloudflare.API, zone zoneRef, params DNSRecordParams) (cloudflare.DNSRecord, error) {
+	body := params.record(zone.Name)
+	record, err := api.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zone.ID), cloudflare.CreateDNSRecordParams{
+		Type:     body.Type,
+		Name:     body.Name,
+		Content:  body.Content,
+		Data:     body.Data,
+		Priority: body.Priority,
+		TTL:      body.TTL,
+		Proxied:  body.Proxied,
+		Comment:  body.Comment,
+		Tags:     body.Tags,
+	})
+	if err != nil {
+		return cloudflare.DNSRecord{}, fmt.Errorf("创建解析记录失败: %v", err)
+	}
+	return record, nil
+}
+
+func (c *apiClient) updateRecord(ctx context.Context, api *cloudflare.API, zone zoneRef, id string, params DNSRecordParams) (cloudflare.DNSRecord, error) {
+	body := params.record(zone.Name)
+	update := cloudflare.UpdateDNSRecordParams{
+		ID:       id,
+		Type:     body.Type,
+		Name:     body.Name,
+		Content:  body.Content,
+		Data:     body.Data,
+		Priority: body.Priority,
+		TTL:      body.TTL,
+		Proxied:  body.Proxied,
+		Tags:     body.Tags,
+	}
+	if body.Comment != "" {
+		update.Comment = &body.Comment
+	}
+	record, err := api.UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(zone.ID), update)
+	if err != nil {
+		return cloudflare.DNSRecord{}, fmt.Errorf("更新解析记录失败: %v", err)
+	}
+	return record, nil
+}
+
+// record 将参数转为 API 请求内容：SRV 与 CAA 通过 data 提交，MX 的优先级为单独字段。
+func (p DNSRecordParams) record(zone string) cloudflare.DNSRecord {
+	proxied := p.Proxied
+	r := cloudflare.DNSRecord{
+		Type:    strings.ToUpper(p.Type),
+		Name:    RecordFQDN(p.Name, zone),
+		Content: p.Content,
+		TTL:     recordTTL(p.TTL),
+		Proxied: &proxied,
+		Comment: p.Comment,
+		Tags:    p.Tags,
+	}
+	switch r.Type {
+	case "MX":
+		r.Priority = p.Priority
+	case "SRV":
+		r.Content = ""
+		r.Data = map[string]interface{}{
+			"priority": *p.Priority,
+			"weight":   p.Weight,
+			"port":     p.Port,
+			"target":   strings.TrimSuffix(p.Content, "."),
+		}
+	case "CAA":
+		r.Content = ""
+		r.Data = map[string]interface{}{
+			"flags": p.Flags,
+			"tag":   strings.ToLower(p.Tag),
+			"value": p.Content,
+		}
+	}
+	return r
+}
+
+// ParamsFromRecord 由已有记录得到参数，用于只修改其中部分字段。
+func ParamsFromRecord(r cloudflare.DNSRecord) DNSRecordParams {
+	p := DNSRecordParams{
+		Type:     r.Type,
+		Name:     r.Name,
+		Content:  r.Content,
+		Proxied:  r.Proxied != nil && *r.Proxied,
+		TTL:      r.TTL,
+		Priority: r.Priority,
+		Comment:  r.Comment,
+		Tags:     r.Tags,
+	}
+	data, _ := r.Data.(map[string]interface{})
+	num := func(key string) uint16 {
+		v, _ := data[key].(float64)
+		return uint16(v)
+	}
+	switch r.Type {
+	case "SRV":
+		priority := num("priority")
+		p.Priority = &priority
+		p.Weight = num("weight")
+		p.Port = num("port")
+		if target, ok := data["target"].(string); ok {
+			p.Content = target
+		}
+	case "CAA":
+		p.Flags = uint8(num("flags"))
+		p.Tag, _ = data["tag"].(string)
+		if value, ok := data["value"].(string); ok {
+			p.Content = value
+		}
+	}
+	return p
+}
+
+// Validate 按记录类型检查参数，避免把明显错误的记录提交给 Cloudflare。
+func (p DNSRecordParams) Validate() error {
+	typ := strings.ToUpper(p.Type)
+	content := strings.TrimSpace(p.Content)
+	if content == "" {
+		return fmt.Errorf("%s 记录内容不能为空", typ)
+	}
+
+	switch typ {
+	case "A":
+		if ip := net.ParseIP(content); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("A 记录需要 IPv4 地址: %s", content)
+		}
+	case "AAAA":
+		if ip := net.ParseIP(content); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("AAAA 记录需要 IPv6 地址: %s", content)
+		}
+	case "CNAME", "NS":
+		if !isHostname(content) {
+			return fmt.Errorf("%s 记录需要主机名: %s", typ, content)
+		}
+	case "MX":
+		if p.Priority == nil {
+			return errors.New("MX 记录需要指定优先级，例如 priority=10")
+		}
+		if content != "." && !isHostname(content) {
+			return fmt.Errorf("MX 记录需要邮件服务器主机名: %s", content)
+		}
+	case "SRV":
+		labels := strings.Split(strings.TrimSpace(p.Name), ".")
+		if len(labels) < 2 || !strings.HasPrefix(labels[0], "_") || !strings.HasPrefix(labels[1], "_") {
+			return fmt.Errorf("SRV 记录名需以 _服务._协议 开头，例如 _sip._tcp: %s", p.Name)
+		}
+		if p.Priority == nil {
+			return errors.New("SRV 记录需要指定优先级，例如 priority=10")
+		}
+		if p.Port == 0 {
+			return errors.New("SRV 记录需要指定端口，例如 port=443")
+		}
+		if content != "." && !isHostname(content) {
+			return fmt.Errorf("SRV 记录需要目标主机名: %s", content)
+		}
+	case "CAA":
+		switch strings.ToLower(p.Tag) {
+		case "issue", "issuewild":
+		case "iodef":
+			if !strings.HasPrefix(content, "mailto:") && !strings.HasPrefix(content, "https://") && !strings.HasPrefix(content, "http://") {
+				return fmt.Errorf("CAA iodef 的值需为 mailto: 或 http(s):// 地址: %s", content)
+			}
+		default:
+			return fmt.Errorf("CAA 记录需要 tag=issue、issuewild 或 iodef，当前为 %q", p.Tag)
+		}
+		if p.Flags != 0 && p.Flags != 128 {
+			return fmt.Errorf("CAA flags 只能为 0 或 128: %d", p.Flags)
+		}
+	case "TXT":
+		if len(content) > 2048 {
+			return fmt.Errorf("TXT 记录内容不能超过 2048 个字符，当前 %d", len(content))
+		}
+	default:
+		return fmt.Errorf("不支持的记录类型: %s", p.Type)
+	}
+
+	if p.Priority != nil && typ != "MX" && typ != "SRV" {
+		return fmt.Errorf("priority 只适用于 MX、SRV 记录")
+	}
+	if (p.Weight != 0 || p.Port != 0) && typ != "SRV" {
+		return fmt.Errorf("weight、port 只适用于 SRV 记录")
+	}
+	if (p.Flags != 0 || p.Tag != "") && typ != "CAA" {
+		return fmt.Errorf("flags、tag 只适用于 CAA 记录")
+	}
+	if p.Proxied {
+		if typ != "A" && typ != "AAAA" && typ != "CNAME" {
+			return fmt.Errorf("只有 A、AAAA、CNAME 记录可以开启代理")
+		}
+		if p.TTL > 1 {
+			return fmt.Errorf("开启代理的记录 TTL 只能为自动")
+		}
+	}
+	if p.TTL > 1 && (p.TTL < 60 || p.TTL > 86400) {
+		return fmt.Errorf("TTL 需为 60 到 86400 秒，或 1 表示自动: %d", p.TTL)
+	}
+	if p.TTL < 0 {
+		return fmt.Errorf("TTL 不能为负数: %d", p.TTL)
+	}
+	if len([]rune(p.Comment)) > 100 {
+		return fmt.Errorf("备注不能超过 100 个字符")
+	}
+	return nil
+}
+
+// isHostname 检查主机名格式，允许下划线（例如 _acme-challenge）与末尾的点。
+func isHostname(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// RecordFQDN 将记录名转为完整域名：空或 @ 为 Zone 本身，不以 Zone 结尾的名称视为前缀。
+func RecordFQDN(name, zone string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	switch {
+	case name == "" || name == "@":
+		return zone
+	case name == zone || strings.HasSuffix(name, "."+zone):
+		return name
+	default:
+		return name + "." + zone
+	}
+}
+
+// recordTTL 返回记录的 TTL，为 0 时使用 1（自动）。
+func recordTTL(ttl int) int {
+	if ttl == 0 {
+		return 1
+	}
+	return ttl
+}
@@ -0,0 +1,83 @@
+package cfclient
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+func TestDNSRecordParamsValidate(t *testing.T) {
+	priority := uint16(10)
+	cases := []struct {
+		name   string
+		params DNSRecordParams
+		errHas string
+	}{
+		{"ipv4", DNSRecordParams{Type: "a", Name: "www", Content: "192.0.2.1", Proxied: true}, ""},
+		{"a with ipv6", DNSRecordParams{Type: "A", Content: "2001:db8::1"}, "IPv4"},
+		{"ipv6", DNSRecordParams{Type: "AAAA", Content: "2001:db8::1"}, ""},
+		{"aaaa with ipv4", DNSRecordParams{Type: "AAAA", Content: "192.0.2.1"}, "IPv6"},
+		{"cname", DNSRecordParams{Type: "CNAME", Content: "_acme.target.example.net."}, ""},
+		{"cname with url", DNSRecordParams{Type: "CNAME", Content: "https://example.net"}, "主机名"},
+		{"mx", DNSRecordParams{Type: "MX", Content: "mail.example.com", Priority: &priority, TTL: 3600}, ""},
+		{"mx without priority", DNSRecordParams{Type: "MX", Content: "mail.example.com"}, "优先级"},
+		{"mx proxied", DNSRecordParams{Type: "MX", Content: "mail.example.com", Priority: &priority, Proxied: true}, "代理"},
+		{"srv", DNSRecordParams{Type: "SRV", Name: "_sip._tcp", Content: "sip.example.com", Priority: &priority, Weight: 5, Port: 5060}, ""},
+		{"srv bad name", DNSRecordParams{Type: "SRV", Name: "sip", Content: "sip.example.com", Priority: &priority, Port: 5060}, "_服务._协议"},
+		{"srv without port", DNSRecordParams{Type: "SRV", Name: "_sip._tcp", Content: "sip.example.com", Priority: &priority}, "端口"},
+		{"caa", DNSRecordParams{Type: "CAA", Content: "letsencrypt.org", Tag: "issue"}, ""},
+		{"caa bad tag", DNSRecordParams{Type: "CAA", Content: "letsencrypt.org", Tag: "foo"}, "tag="},
+		{"caa bad flags", DNSRecordParams{Type: "CAA", Content: "letsencrypt.org", Tag: "issue", Flags: 1}, "flags"},
+		{"caa iodef", DNSRecordParams{Type: "CAA", Content: "example.com", Tag: "iodef"}, "iodef"},
+		{"txt", DNSRecordParams{Type: "TXT", Content: "v=spf1 include:_spf.example.com ~all", Comment: "SPF"}, ""},
+		{"txt too long", DNSRecordParams{Type: "TXT", Content: strings.Repeat("a", 2049)}, "2048"},
+		{"priority on a", DNSRecordParams{Type: "A", Content: "192.0.2.1", Priority: &priority}, "priority"},
+		{"bad ttl", DNSRecordParams{Type: "A", Content: "192.0.2.1", TTL: 30}, "TTL"},
+		{"proxied ttl", DNSRecordParams{Type: "A", Content: "192.0.2.1", Proxied: true, TTL: 300}, "TTL"},
+		{"empty", DNSRecordParams{Type: "A"}, "不能为空"},
+		{"unsupported", DNSRecordParams{Type: "LOC", Content: "x"}, "不支持"},
+	}
+	for _, c := range cases {
+		err := c.params.Validate()
+		switch {
+		case c.errHas == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		case c.errHas != "" && (err == nil || !strings.Contains(err.Error(), c.errHas)):
+			t.Errorf("%s: expected error containing %q, got %v", c.name, c.errHas, err)
+		}
+	}
+}
+
+func TestParamsRoundTripThroughRecordData(t *testing.T) {
+	priority := uint16(10)
+	cases := []DNSRecordParams{
+		{Type: "SRV", Name: "_sip._tcp.example.com", Content: "sip.example.com", Priority: &priority, Weight: 5, Port: 5060, TTL: 300},
+		{Type: "CAA", Name: "example.com", Content: "letsencrypt.org", Flags: 128, Tag: "issuewild", Comment: "证书"},
+		{Type: "MX", Name: "example.com", Content: "mail.example.com", Priority: &priority},
+	}
+	for _, want := range cases {
+		// 经 JSON 往返，模拟 API 返回的 data 字段
+		raw, err := json.Marshal(want.record("example.com"))
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var r cloudflare.DNSRecord
+		if err := json.Unmarshal(raw, &r); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		got := ParamsFromRecord(r)
+		if got.Type != want.Type || got.Name != want.Name || got.Content != want.Content ||
+			got.Weight != want.Weight || got.Port != want.Port || got.Flags != want.Flags ||
+			got.Tag != want.Tag || got.Comment != want.Comment || got.TTL != recordTTL(want.TTL) {
+			t.Errorf("%s: got %+v, want %+v", want.Type, got, want)
+		}
+		if want.Priority != nil && (got.Priority == nil || *got.Priority != *want.Priority) {
+			t.Errorf("%s: priority %v, want %d", want.Type, got.Priority, *want.Priority)
+		}
+		if err := got.Validate(); err != nil {
+			t.Errorf("%s: round-tripped params invalid: %v", want.Type, err)
+		}
+	}
+}
@@ -3,15 +3,29 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	AlertDays          int      `yaml:"alertDays"`
-	Telegram           Telegram `yaml:"telegram"`
-	CloudflareAccounts []CF     `yaml:"cloudflareAccounts"`
-	DomainFiles        []string `yaml:"domainFiles"`
+	AlertDays int `yaml:"alertDays"`
+	// Timezone 为计算剩余天数与展示到期时间的时区，例如 Asia/Shanghai，默认为系统时区
+	Timezone           string       `yaml:"timezone"`
+	Telegram           Telegram     `yaml:"telegram"`
+	CloudflareAccounts []CF         `yaml:"cloudflareAccounts"`
+	DomainFiles        []string     `yaml:"domainFiles"`
+	Lookup             Lookup       `yaml:"lookup"`
+	RDAP               RDAP         `yaml:"rdap"`
+	Whois              Whois        `yaml:"whois"`
+	Alerts             Alerts       `yaml:"alerts"`
+	NameServers        NameServers  `yaml:"nameServers"`
+	DNSSEC             DNSSEC       `yaml:"dnssec"`
+	Certificates       Certificates `yaml:"certificates"`
+	HTTPProbes         HTTPProbes   `yaml:"httpProbes"`
+	Resolution         Resolution   `yaml:"resolution"`
+	// ZoneIndexRefresh 为跨账号 Zone 索引的刷新间隔，默认 30m
+	ZoneIndexRefresh time.Duration `yaml:"zoneIndexRefresh"`
 }
 
 type Telegram struct {
@@ -19,6 +33,176 @@ type Telegram struct {
 	ChatID   int64  `yaml:"chatID"`
 }
 
+// Lookup 控制 WHOIS/RDAP 查询的并发与限流。
+type Lookup struct {
+	Workers      int           `yaml:"workers"`
+	RateLimit    time.Duration `yaml:"rateLimit"`
+	QueryTimeout time.Duration `yaml:"queryTimeout"`
+	// RateLimits 按公共后缀覆盖限流间隔，例如 ph: 5s（同时作用于 com.ph）
+	RateLimits map[string]time.Duration `yaml:"rateLimits"`
+	// Retries 为网络错误、限流等临时失败的重试次数，默认 2，设为 0 不重试；RetryBackoff 为首次重试等待时间
+	Retries      *int          `yaml:"retries"`
+	RetryBackoff time.Duration `yaml:"retryBackoff"`
+	// Recheck 覆盖默认的复查频率，例如 - {beyond: 8760h, every: 720h}
+	Recheck []RecheckTier `yaml:"recheck"`
+	// StatusRecheck 为不论到期远近的最长复查间隔，用于发现 EPP 状态变化，默认 24h，即每次检测都查询状态
+	StatusRecheck time.Duration `yaml:"statusRecheck"`
+	// MinConfidence 为 WHOIS 到期时间的最低置信度（0~1），未设置（0）时默认 0.5，设为负数关闭检查
+	MinConfidence float64 `yaml:"minConfidence"`
+	// Backends 为默认查询顺序，可选 rdap、whois、manual 及 registrarAPIs 中的名称，默认 [rdap, whois]
+	Backends []string `yaml:"backends"`
+	// Strategies 按来源（CF 账号 label 或域名文件来源）或后缀覆盖查询顺序，来源优先
+	Strategies []LookupStrategy `yaml:"strategies"`
+	// RegistrarAPIs 为返回 JSON 的注册商接口
+	RegistrarAPIs []RegistrarAPI `yaml:"registrarAPIs"`
+	// Manual 为手工维护的到期时间，例如 example.ph: 2027-01-31
+	Manual map[string]string `yaml:"manual"`
+}
+
+// LookupStrategy 例如 {suffix: de, backends: [whois]} 或 {source: acc1, backends: [namecheap, rdap]}。
+type LookupStrategy struct {
+	Suffix   string   `yaml:"suffix"`
+	Source   string   `yaml:"source"`
+	Backends []string `yaml:"backends"`
+}
+
+// RegistrarAPI 描述一个按域名查询的注册商 HTTP 接口，字段用点号路径表示，例如 data.expires_at。
+type RegistrarAPI struct {
+	Name string `yaml:"name"`
+	// URL 为 fmt 格式，%s 替换为域名
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	// Registrar 为接口所属注册商名称，写入结果
+	Registrar       string        `yaml:"registrar"`
+	ExpiryField     string        `yaml:"expiryField"`
+	StatusField     string        `yaml:"statusField"`
+	NameServerField string        `yaml:"nameServerField"`
+	DateLayouts     []string      `yaml:"dateLayouts"`
+	Timeout         time.Duration `yaml:"timeout"`
+}
+
+// RecheckTier 表示距离到期超过 Beyond 的域名每隔 Every 重新查询一次。
+type RecheckTier struct {
+	Beyond time.Duration `yaml:"beyond"`
+	Every  time.Duration `yaml:"every"`
+}
+
+// RDAP 配置 bootstrap 数据来源，以及按 TLD/后缀覆盖的 RDAP 服务地址。
+type RDAP struct {
+	// BootstrapFile 为本地 dns.json，设置后不再在线刷新。
+	BootstrapFile   string        `yaml:"bootstrapFile"`
+	CacheDir        string        `yaml:"cacheDir"`
+	BootstrapURL    string        `yaml:"bootstrapURL"`
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
+	// Servers 例如 ph: https://rdap.example.ph/
+	Servers map[string]string `yaml:"servers"`
+}
+
+// Whois 按 TLD/后缀配置 WHOIS 服务器、查询格式与字段解析方式，未匹配的后缀使用通用规则。
+type Whois struct {
+	Rules []WhoisRule `yaml:"rules"`
+}
+
+type WhoisRule struct {
+	// Suffix 例如 de、us.com
+	Suffix string `yaml:"suffix"`
+	Server string `yaml:"server"`
+	// Query 为 fmt 格式，例如 "-T dn,ace %s"
+	Query            string   `yaml:"query"`
+	ExpiryFields     []string `yaml:"expiryFields"`
+	RegistrarFields  []string `yaml:"registrarFields"`
+	CreatedFields    []string `yaml:"createdFields"`
+	StatusFields     []string `yaml:"statusFields"`
+	NameServerFields []string `yaml:"nameServerFields"`
+	// DateLayouts 为 Go 时间格式，例如 02.01.2006
+	DateLayouts []string `yaml:"dateLayouts"`
+	// NotFound 为注册局"域名不存在"应答关键字，例如 "status: free"
+	NotFound []string `yaml:"notFound"`
+}
+
+// Alerts 配置逐级到期提醒，例如 thresholds: [60, 30, 14, 7, 3, 1]、dailyWithin: 3。
+// 未配置 thresholds 时使用默认阈值并以 alertDays 为上限。
+type Alerts struct {
+	AlertSchedule `yaml:",inline"`
+	// Overrides 按 CF 账号 label 或域名文件来源覆盖，未填写的字段沿用默认值
+	Overrides map[string]AlertSchedule `yaml:"overrides"`
+}
+
+type AlertSchedule struct {
+	Thresholds []int `yaml:"thresholds"`
+	// DailyWithin 为剩余天数不超过该值时每天提醒，0 表示不做每日提醒
+	DailyWithin *int `yaml:"dailyWithin"`
+}
+
+// NameServers 配置 CF 域名的 NS 委派检查。
+type NameServers struct {
+	Disabled bool `yaml:"disabled"`
+	// Source 为 registry（默认，取 RDAP/WHOIS 中的 NS）或 dns（向 Resolver 实时查询 NS）
+	Source string `yaml:"source"`
+	// Resolver 例如 1.1.1.1:53，registry 模式下用于补全没有 NS 信息的域名
+	Resolver string        `yaml:"resolver"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// DNSSEC 配置 CF 域名的 DNSSEC 检查。
+type DNSSEC struct {
+	Disabled bool `yaml:"disabled"`
+	// Source 为 registry（默认，取 RDAP/WHOIS 结果，未签名时再查 DS 确认）或 dns（总是向 Resolver 查询 DS）
+	Source   string        `yaml:"source"`
+	Resolver string        `yaml:"resolver"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// Certificates 配置 TLS 证书到期检查，主机名取自各 Zone 未代理的 A/AAAA/CNAME 记录与 ExtraHosts。
+type Certificates struct {
+	Disabled bool `yaml:"disabled"`
+	// AlertDays 为证书剩余天数不超过该值时提醒，默认 14
+	AlertDays int `yaml:"alertDays"`
+	// IncludeProxied 为 true 时同时检查已代理主机的源站证书（按记录内容连接源站，SNI 为主机名）
+	IncludeProxied bool          `yaml:"includeProxied"`
+	Timeout        time.Duration `yaml:"timeout"`
+	// ExtraHosts 为额外检查的主机，例如源站 {host: origin.example.com, address: 10.0.0.5:443}
+	ExtraHosts []CertHost `yaml:"extraHosts"`
+	// EdgeDisabled 为 true 时不检查 CF 证书包与自定义主机名证书，与 Disabled 互不影响
+	EdgeDisabled bool `yaml:"edgeDisabled"`
+	// PendingAfter 为证书包停留在 pending_validation 等状态多久后提醒，默认 24h
+	PendingAfter time.Duration `yaml:"pendingAfter"`
+}
+
+// CertHost 为一个证书检查目标，Host 用作 SNI，Address 为空时连接 Host:443。
+type CertHost struct {
+	Host    string `yaml:"host"`
+	Address string `yaml:"address"`
+}
+
+// Resolution 配置每日解析健康检查：向各解析器查询域名根与关键主机名，并与 CF 中的记录对比。
+type Resolution struct {
+	Disabled bool `yaml:"disabled"`
+	// Resolvers 例如 [1.1.1.1:53, 8.8.8.8:53]，默认 [1.1.1.1:53, 8.8.8.8:53]
+	Resolvers []string `yaml:"resolvers"`
+	// Hosts 为各域名下需要检查的主机名前缀，@ 表示域名根，默认 [www]；域名根总是检查
+	Hosts   []string      `yaml:"hosts"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// HTTPProbes 配置 HTTP 访问检查；域名文件第四列也可以为单个域名配置检查。
+type HTTPProbes struct {
+	Disabled bool          `yaml:"disabled"`
+	Timeout  time.Duration `yaml:"timeout"`
+	// Checks 例如 {domain: example.com, url: https://example.com/health, status: 200, contains: ok, maxLatency: 2s}
+	Checks []HTTPProbe `yaml:"checks"`
+}
+
+// HTTPProbe 为一条 HTTP 检查，Domain 为通知中显示的域名，为空时取 URL 的主机名。
+type HTTPProbe struct {
+	Domain     string        `yaml:"domain"`
+	URL        string        `yaml:"url"`
+	Status     int           `yaml:"status"`
+	Redirect   string        `yaml:"redirect"`
+	Contains   string        `yaml:"contains"`
+	MaxLatency time.Duration `yaml:"maxLatency"`
+}
+
 type CF struct {
 	Label     string `yaml:"label"`
 	Email     string `yaml:"email"`
@@ -28,6 +212,18 @@ type CF struct {
 
 var Cfg Config
 
+// Location 返回 Timezone 对应的时区，未配置时为系统时区。
+func (c Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区 %s: %w", c.Timezone, err)
+	}
+	return loc, nil
+}
+
 func Load(path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -0,0 +1,298 @@
+// Package dnsquery 向指定的 DNS 服务器发送查询，返回应答码与原始记录，
+// 用于对比注册局委派、DNSSEC 与解析健康状况。
+package dnsquery
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DefaultServer 为未配置解析服务器时使用的公共解析器。
+const DefaultServer = "1.1.1.1:53"
+
+// TypeDS 为 DS 记录类型，dnsmessage 未内置。
+const TypeDS dnsmessage.Type = 43
+
+// DSRecord 为父区中的 DS 记录，Digest 为大写十六进制。
+type DSRecord struct {
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     string
+}
+
+// ErrNXDomain 表示服务器应答域名不存在。
+var ErrNXDomain = errors.New("NXDOMAIN")
+
+// RCodeError 表示服务器返回了 NOERROR、NXDOMAIN 以外的应答码，例如 SERVFAIL、REFUSED。
+type RCodeError struct {
+	Server string
+	RCode  dnsmessage.RCode
+}
+
+func (e *RCodeError) Error() string {
+	return fmt.Sprintf("%s 应答 %s", e.Server, RCodeName(e.RCode))
+}
+
+// Client 通过 UDP 查询单个 DNS 服务器，应答被截断时改用 TCP 重试。
+type Client struct {
+	// Server 为 host 或 host:port，默认端口 53。
+	Server  string
+	Timeout time.Duration
+}
+
+// NewClient 返回查询 server 的客户端，server 为空时使用 DefaultServer。
+func NewClient(server string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Client{Server: server, Timeout: timeout}
+}
+
+func (c *Client) addr() string {
+	server := strings.TrimSpace(c.Server)
+	if server == "" {
+		return DefaultServer
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return server
+}
+
+// Query 查询 name 的 qtype 记录并返回完整应答，不检查应答码。
+// dnssecOK 为 true 时设置 EDNS DO 位，请求服务器返回 RRSIG 等 DNSSEC 记录。
+func (c *Client) Query(ctx context.Context, name string, qtype dnsmessage.Type, dnssecOK bool) (*dnsmessage.Message, error) {
+	fqdn, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, fmt.Errorf("无效的域名 %s: %w", name, err)
+	}
+	id := uint16(rand.Intn(1 << 16))
+	query, err := buildQuery(id, fqdn, qtype, dnssecOK)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := c.exchange(ctx, "udp", id, query)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Truncated {
+		resp, err = c.exchange(ctx, "tcp", id, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Lookup 查询并检查应答码：NXDOMAIN 返回 ErrNXDomain，其他错误码返回 *RCodeError。
+func (c *Client) Lookup(ctx context.Context, name string, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	resp, err := c.Query(ctx, name, qtype, false)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.RCode {
+	case dnsmessage.RCodeSuccess:
+		return resp, nil
+	case dnsmessage.RCodeNameError:
+		return resp, fmt.Errorf("%s %w", name, ErrNXDomain)
+	default:
+		return resp, &RCodeError{Server: c.addr(), RCode: resp.RCode}
+	}
+}
+
+// LookupNS 返回 name 的 NS 记录（小写、去掉末尾的点）。
+func (c *Client) LookupNS(ctx context.Context, name string) ([]string, error) {
+	resp, err := c.Lookup(ctx, name, dnsmessage.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, rr := range resp.Answers {
+		if ns, ok := rr.Body.(*dnsmessage.NSResource); ok {
+			out = append(out, NormalizeName(ns.NS.String()))
+		}
+	}
+	return out, nil
+}
+
+// LookupDS 返回 name 在父区的 DS 记录，没有 DS（未签名委派）时返回空列表。
+func (c *Client) LookupDS(ctx context.Context, name string) ([]DSRecord, error) {
+	resp, err := c.Lookup(ctx, name, TypeDS)
+	if err != nil {
+		return nil, err
+	}
+	var out []DSRecord
+	for _, rr := range resp.Answers {
+		body, ok := rr.Body.(*dnsmessage.UnknownResource)
+		if !ok || rr.Header.Type != TypeDS || len(body.Data) < 4 {
+			continue
+		}
+		out = append(out, DSRecord{
+			KeyTag:     binary.BigEndian.Uint16(body.Data[:2]),
+			Algorithm:  body.Data[2],
+			DigestType: body.Data[3],
+			Digest:     strings.ToUpper(hex.EncodeToString(body.Data[4:])),
+		})
+	}
+	return out, nil
+}
+
+// LookupRecords 返回 name 的 A/AAAA/CNAME/MX 记录值：IP 地址、CNAME 目标或 MX 主机名（小写、去掉末尾的点）。
+// 只返回属于 name 本身的记录，CNAME 链上其他名称的记录不计入。
+func (c *Client) LookupRecords(ctx context.Context, name string, qtype dnsmessage.Type) ([]string, error) {
+	resp, err := c.Lookup(ctx, name, qtype)
+	if err != nil {
+		return nil, err
+	}
+	owner := NormalizeName(name)
+	var out []string
+	for _, rr := range resp.Answers {
+		if rr.Header.Type != qtype || NormalizeName(rr.Header.Name.String()) != owner {
+			continue
+		}
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			out = append(out, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			out = append(out, net.IP(body.AAAA[:]).String())
+		case *dnsmessage.CNAMEResource:
+			out = append(out, NormalizeName(body.CNAME.String()))
+		case *dnsmessage.MXResource:
+			out = append(out, NormalizeName(body.MX.String()))
+		}
+	}
+	return out, nil
+}
+
+// Addr 返回实际查询的服务器地址。
+func (c *Client) Addr() string {
+	return c.addr()
+}
+
+func (c *Client) exchange(ctx context.Context, network string, id uint16, query []byte) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, c.addr())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if network == "tcp" {
+		return exchangeTCP(conn, query)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var msg dnsmessage.Message
+		if err := msg.Unpack(buf[:n]); err != nil || msg.ID != id || !msg.Response {
+			// 忽略无法解析或不属于本次查询的数据包
+			continue
+		}
+		return &msg, nil
+	}
+}
+
+func exchangeTCP(conn net.Conn, query []byte) (*dnsmessage.Message, error) {
+	framed := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(framed, uint16(len(query)))
+	copy(framed[2:], query)
+	if _, err := conn.Write(framed); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(buf); err != nil {
+		return nil, fmt.Errorf("解析 DNS 应答失败: %w", err)
+	}
+	return &msg, nil
+}
+
+func buildQuery(id uint16, name dnsmessage.Name, qtype dnsmessage.Type, dnssecOK bool) ([]byte, error) {
+	b := dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{ID: id, RecursionDesired: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: name, Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(1232, dnsmessage.RCodeSuccess, dnssecOK); err != nil {
+		return nil, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+func fqdn(name string) string {
+	name = strings.TrimSpace(name)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
+
+// NormalizeName 将记录中的域名转为小写并去掉末尾的点，便于比较。
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// RCodeName 返回应答码的常用名称，例如 SERVFAIL。
+func RCodeName(rcode dnsmessage.RCode) string {
+	switch rcode {
+	case dnsmessage.RCodeSuccess:
+		return "NOERROR"
+	case dnsmessage.RCodeFormatError:
+		return "FORMERR"
+	case dnsmessage.RCodeServerFailure:
+		return "SERVFAIL"
+	case dnsmessage.RCodeNameError:
+		return "NXDOMAIN"
+	case dnsmessage.RCodeNotImplemented:
+		return "NOTIMP"
+	case dnsmessage.RCodeRefused:
+		return "REFUSED"
+	default:
+		return fmt.Sprintf("RCODE%d", rcode)
+	}
+}
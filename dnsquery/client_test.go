@@ -0,0 +1,97 @@
+package dnsquery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/dnsquery/dnstest"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestClientLookupNSAndRCodes(t *testing.T) {
+	srv, err := dnstest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	srv.SetNS("example.com", "Ada.NS.Cloudflare.com", "bob.ns.cloudflare.com.")
+	srv.SetRCode("broken.com", dnsmessage.TypeNS, dnsmessage.RCodeServerFailure)
+
+	client := NewClient(srv.Addr, time.Second)
+	ns, err := client.LookupNS(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("LookupNS: %v", err)
+	}
+	if len(ns) != 2 || ns[0] != "ada.ns.cloudflare.com" || ns[1] != "bob.ns.cloudflare.com" {
+		t.Fatalf("unexpected NS %v", ns)
+	}
+
+	if _, err := client.LookupNS(context.Background(), "missing.com"); !errors.Is(err, ErrNXDomain) {
+		t.Fatalf("expected NXDOMAIN, got %v", err)
+	}
+	var rcodeErr *RCodeError
+	if _, err := client.LookupNS(context.Background(), "broken.com"); !errors.As(err, &rcodeErr) || rcodeErr.RCode != dnsmessage.RCodeServerFailure {
+		t.Fatalf("expected SERVFAIL, got %v", err)
+	}
+}
+
+func TestClientLookupDS(t *testing.T) {
+	srv, err := dnstest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	srv.SetDS("signed.com", 2371, 13, 2, "c988ec423e3880eb8dd8a46fe06ca230ee23f35b578d64e2f8c4b6b8b4c9d2a1")
+	srv.Set("unsigned.com", TypeDS, dnstest.Answer{})
+
+	client := NewClient(srv.Addr, time.Second)
+	ds, err := client.LookupDS(context.Background(), "signed.com")
+	if err != nil {
+		t.Fatalf("LookupDS: %v", err)
+	}
+	if len(ds) != 1 || ds[0].KeyTag != 2371 || ds[0].Algorithm != 13 || ds[0].DigestType != 2 || ds[0].Digest[:6] != "C988EC" {
+		t.Fatalf("unexpected DS %+v", ds)
+	}
+
+	ds, err = client.LookupDS(context.Background(), "unsigned.com")
+	if err != nil || len(ds) != 0 {
+		t.Fatalf("expected no DS for unsigned delegation, got %v, %v", ds, err)
+	}
+}
+
+func TestClientLookupRecords(t *testing.T) {
+	srv, err := dnstest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	srv.SetA("example.com", "192.0.2.1", "192.0.2.2")
+	srv.SetAAAA("example.com", "2001:db8::1")
+	srv.SetCNAME("www.example.com", "Example.com.")
+	srv.SetMX("example.com", "mx1.example.net", "MX2.example.net.")
+
+	client := NewClient(srv.Addr, time.Second)
+	cases := []struct {
+		name  string
+		qtype dnsmessage.Type
+		want  string
+	}{
+		{"example.com", dnsmessage.TypeA, "192.0.2.1,192.0.2.2"},
+		{"example.com", dnsmessage.TypeAAAA, "2001:db8::1"},
+		{"www.example.com", dnsmessage.TypeCNAME, "example.com"},
+		{"example.com", dnsmessage.TypeMX, "mx1.example.net,mx2.example.net"},
+	}
+	for _, tc := range cases {
+		got, err := client.LookupRecords(context.Background(), tc.name, tc.qtype)
+		if err != nil {
+			t.Fatalf("LookupRecords(%s, %v): %v", tc.name, tc.qtype, err)
+		}
+		if strings.Join(got, ",") != tc.want {
+			t.Fatalf("LookupRecords(%s, %v) = %v, want %s", tc.name, tc.qtype, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package dnstest 提供本地 UDP DNS 服务器，供测试解析相关的检查使用。
+package dnstest
+
+import (
+	"encoding/hex"
+	"net"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dsType 为 DS 记录类型，dnsmessage 未内置。
+const dsType dnsmessage.Type = 43
+
+// Answer 为某个名称和类型的固定应答，RCode 非零时不返回记录。
+type Answer struct {
+	RCode     dnsmessage.RCode
+	Resources []dnsmessage.Resource
+}
+
+// Server 按 (名称, 类型) 返回预设应答，未设置的查询应答 NXDOMAIN。
+type Server struct {
+	Addr string
+
+	conn    net.PacketConn
+	mu      sync.Mutex
+	answers map[string]Answer
+}
+
+// NewServer 在 127.0.0.1 的随机端口启动服务器。
+func NewServer() (*Server, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{Addr: conn.LocalAddr().String(), conn: conn, answers: make(map[string]Answer)}
+	go s.serve()
+	return s, nil
+}
+
+// Close 停止服务器。
+func (s *Server) Close() error {
+	return s.conn.Close()
+}
+
+// Set 设置 name 的 qtype 查询应答。
+func (s *Server) Set(name string, qtype dnsmessage.Type, answer Answer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.answers[key(name, qtype)] = answer
+}
+
+// SetNS 设置 NS 记录应答。
+func (s *Server) SetNS(name string, nameservers ...string) {
+	var rrs []dnsmessage.Resource
+	for _, ns := range nameservers {
+		rrs = append(rrs, dnsmessage.Resource{
+			Header: header(name, dnsmessage.TypeNS),
+			Body:   &dnsmessage.NSResource{NS: dnsmessage.MustNewName(fqdn(ns))},
+		})
+	}
+	s.Set(name, dnsmessage.TypeNS, Answer{Resources: rrs})
+}
+
+// SetA 设置 A 记录应答。
+func (s *Server) SetA(name string, ips ...string) {
+	var rrs []dnsmessage.Resource
+	for _, ip := range ips {
+		var a [4]byte
+		copy(a[:], net.ParseIP(ip).To4())
+		rrs = append(rrs, dnsmessage.Resource{Header: header(name, dnsmessage.TypeA), Body: &dnsmessage.AResource{A: a}})
+	}
+	s.Set(name, dnsmessage.TypeA, Answer{Resources: rrs})
+}
+
+// SetAAAA 设置 AAAA 记录应答。
+func (s *Server) SetAAAA(name string, ips ...string) {
+	var rrs []dnsmessage.Resource
+	for _, ip := range ips {
+		var a [16]byte
+		copy(a[:], net.ParseIP(ip).To16())
+		rrs = append(rrs, dnsmessage.Resource{Header: header(name, dnsmessage.TypeAAAA), Body: &dnsmessage.AAAAResource{AAAA: a}})
+	}
+	s.Set(name, dnsmessage.TypeAAAA, Answer{Resources: rrs})
+}
+
+// SetCNAME 设置 CNAME 记录应答。
+func (s *Server) SetCNAME(name, target string) {
+	s.Set(name, dnsmessage.TypeCNAME, Answer{Resources: []dnsmessage.Resource{{
+		Header: header(name, dnsmessage.TypeCNAME),
+		Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(fqdn(target))},
+	}}})
+}
+
+// SetMX 设置 MX 记录应答，优先级按参数顺序从 10 开始递增。
+func (s *Server) SetMX(name string, hosts ...string) {
+	var rrs []dnsmessage.Resource
+	for i, host := range hosts {
+		rrs = append(rrs, dnsmessage.Resource{
+			Header: header(name, dnsmessage.TypeMX),
+			Body:   &dnsmessage.MXResource{Pref: uint16(10 * (i + 1)), MX: dnsmessage.MustNewName(fqdn(host))},
+		})
+	}
+	s.Set(name, dnsmessage.TypeMX, Answer{Resources: rrs})
+}
+
+// SetDS 设置一条 DS 记录应答，digest 为十六进制。
+func (s *Server) SetDS(name string, keyTag uint16, algorithm, digestType uint8, digest string) {
+	raw, _ := hex.DecodeString(digest)
+	data := append([]byte{byte(keyTag >> 8), byte(keyTag), algorithm, digestType}, raw...)
+	s.Set(name, dsType, Answer{Resources: []dnsmessage.Resource{{
+		Header: header(name, dsType),
+		Body:   &dnsmessage.UnknownResource{Type: dsType, Data: data},
+	}}})
+}
+
+// SetRCode 让 name 的 qtype 查询返回指定应答码，例如 SERVFAIL。
+func (s *Server) SetRCode(name string, qtype dnsmessage.Type, rcode dnsmessage.RCode) {
+	s.Set(name, qtype, Answer{RCode: rcode})
+}
+
+func (s *Server) serve() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var req dnsmessage.Message
+		if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) == 0 {
+			continue
+		}
+		q := req.Questions[0]
+
+		s.mu.Lock()
+		answer, ok := s.answers[key(q.Name.String(), q.Type)]
+		s.mu.Unlock()
+		if !ok {
+			answer = Answer{RCode: dnsmessage.RCodeNameError}
+		}
+
+		resp := dnsmessage.Message{
+			Header: dnsmessage.Header{
+				ID:                 req.ID,
+				Response:           true,
+				RecursionDesired:   req.RecursionDesired,
+				RecursionAvailable: true,
+				RCode:              answer.RCode,
+			},
+			Questions: req.Questions,
+		}
+		if answer.RCode == dnsmessage.RCodeSuccess {
+			resp.Answers = answer.Resources
+		}
+		packed, err := resp.Pack()
+		if err != nil {
+			continue
+		}
+		s.conn.WriteTo(packed, addr)
+	}
+}
+
+func header(name string, qtype dnsmessage.Type) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(fqdn(name)), Type: qtype, Class: dnsmessage.ClassINET, TTL: 300}
+}
+
+func key(name string, qtype dnsmessage.Type) string {
+	return strings.ToLower(fqdn(name)) + "|" + qtype.String()
+}
+
+func fqdn(name string) string {
+	if !strings.HasSuffix(name, ".") {
+		return name + "."
+	}
+	return name
+}
@@ -0,0 +1,24 @@
+package domain
+
+// DNSSECCheck 为注册局 DS 委派与 Cloudflare DNSSEC 状态的一次对比结果。
+type DNSSECCheck struct {
+	Domain string
+	// RegistrySigned 表示注册局存在 DS 记录，RegistryFrom 为信息来源（RDAP、WHOIS 或 DNS）。
+	RegistrySigned  bool
+	RegistryFrom    string
+	RegistryKeyTags []int
+	// CFStatus 为 active、pending、disabled、pending-disabled 或 error。
+	CFStatus string
+	CFKeyTag int
+	// CFDS 为 CF 要求在注册商处添加的 DS 记录。
+	CFDS string
+	// Problem 为不一致的说明，为空表示两边一致。
+	Problem string
+	// Urgent 表示不一致会导致开启校验的解析器无法解析该域名。
+	Urgent bool
+}
+
+// Consistent 表示两边状态一致。
+func (c DNSSECCheck) Consistent() bool {
+	return c.Problem == ""
+}
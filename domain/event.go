@@ -0,0 +1,85 @@
+package domain
+
+import "time"
+
+// EventKind 区分到期提醒与查询失败之外需要单独通知的域名事件。
+type EventKind string
+
+const (
+	// EventDropped 表示注册局应答域名不存在，即自有域名已被删除释放。
+	EventDropped EventKind = "dropped"
+	// EventRenewed 表示到期时间较上次查询延后，即域名已续费。
+	EventRenewed EventKind = "renewed"
+	// EventExpiryMovedBack 表示到期时间较上次查询提前，属于异常情况。
+	EventExpiryMovedBack EventKind = "expiry_moved_back"
+	// EventStatusChanged 表示 EPP 状态码出现或消失。
+	EventStatusChanged EventKind = "status_changed"
+	// EventNameServerDrift 表示注册局委派的 NS 与 Cloudflare 分配的 NS 不一致。
+	EventNameServerDrift EventKind = "ns_drift"
+	// EventRegistrarChanged 表示注册商（名称或 IANA ID）发生变化。
+	EventRegistrarChanged EventKind = "registrar_changed"
+	// EventTransferLockRemoved 表示 clientTransferProhibited/serverTransferProhibited 被解除。
+	EventTransferLockRemoved EventKind = "transfer_lock_removed"
+	// EventTransferPending 表示出现 pendingTransfer，域名正在被转出。
+	EventTransferPending EventKind = "transfer_pending"
+	// EventDNSSECMismatch 表示注册局的 DS 委派与 Cloudflare 的 DNSSEC 状态不一致。
+	EventDNSSECMismatch EventKind = "dnssec_mismatch"
+	// EventCertExpiring 表示 TLS 证书即将到期或已经过期。
+	EventCertExpiring EventKind = "cert_expiring"
+	// EventCertInvalid 表示 TLS 证书无法通过校验，例如证书链不受信任或未覆盖该主机名。
+	EventCertInvalid EventKind = "cert_invalid"
+	// EventEdgeCertPending 表示 Cloudflare 证书包或自定义主机名的证书长时间未完成验证或签发失败。
+	EventEdgeCertPending EventKind = "edge_cert_pending"
+	// EventHTTPCheckFailed 表示 HTTP 访问检查未通过，例如无法访问、状态码或跳转目标不符。
+	EventHTTPCheckFailed EventKind = "http_check_failed"
+	// EventResolutionFailed 表示解析器返回 SERVFAIL/NXDOMAIN，或应答与 Cloudflare 中的记录不一致。
+	EventResolutionFailed EventKind = "resolution_failed"
+)
+
+// Label 返回用于通知的中文名称。
+func (k EventKind) Label() string {
+	switch k {
+	case EventDropped:
+		return "域名已不再注册"
+	case EventRenewed:
+		return "域名已续费"
+	case EventExpiryMovedBack:
+		return "到期时间提前"
+	case EventStatusChanged:
+		return "域名状态变化"
+	case EventNameServerDrift:
+		return "NS 委派不一致"
+	case EventRegistrarChanged:
+		return "注册商变更"
+	case EventTransferLockRemoved:
+		return "转移锁已解除"
+	case EventTransferPending:
+		return "域名转移中"
+	case EventDNSSECMismatch:
+		return "DNSSEC 配置不一致"
+	case EventCertExpiring:
+		return "证书即将到期"
+	case EventCertInvalid:
+		return "证书校验失败"
+	case EventEdgeCertPending:
+		return "边缘证书未签发"
+	case EventHTTPCheckFailed:
+		return "HTTP 检查失败"
+	case EventResolutionFailed:
+		return "解析异常"
+	default:
+		return "域名事件"
+	}
+}
+
+// Event 为一次检测中发现的域名事件，Detail 为注册局原文等补充说明。
+type Event struct {
+	Kind   EventKind
+	Domain string
+	Source string
+	IsCF   bool
+	Detail string
+	// Urgent 表示事件会导致解析中断或失去域名，需要立即处理。
+	Urgent bool
+	At     time.Time
+}
@@ -1,7 +1,66 @@
 package domain
 
+// FailureKind 对查询失败进行分类，便于重试与汇总。
+type FailureKind string
+
+const (
+	// FailureNetwork 为超时、连接失败等临时性网络错误。
+	FailureNetwork FailureKind = "network"
+	// FailureThrottled 表示被 WHOIS/RDAP 服务限流。
+	FailureThrottled FailureKind = "throttled"
+	// FailureNotRegistered 表示注册局返回域名不存在。
+	FailureNotRegistered FailureKind = "not_registered"
+	// FailureUnparseable 表示拿到了响应但无法解析出到期时间。
+	FailureUnparseable FailureKind = "unparseable"
+	// FailureLowConfidence 表示解析出的到期时间置信度过低，例如原文中有多个相互矛盾的日期。
+	FailureLowConfidence FailureKind = "low_confidence"
+	// FailureUnsupported 表示后缀没有可用的 RDAP/WHOIS 服务或无法识别。
+	FailureUnsupported FailureKind = "unsupported_tld"
+	// FailureUnknown 为无法归类的错误，例如响应格式错误，不重试。
+	FailureUnknown FailureKind = "unknown"
+)
+
+// FailureKinds 为汇总展示时的固定顺序。
+var FailureKinds = []FailureKind{
+	FailureNotRegistered,
+	FailureUnparseable,
+	FailureLowConfidence,
+	FailureUnsupported,
+	FailureUnknown,
+	FailureThrottled,
+	FailureNetwork,
+}
+
+// Transient 表示该类失败可以通过重试恢复。
+func (k FailureKind) Transient() bool {
+	return k == FailureNetwork || k == FailureThrottled
+}
+
+// Label 返回用于通知的中文名称。
+func (k FailureKind) Label() string {
+	switch k {
+	case FailureNetwork:
+		return "网络错误"
+	case FailureThrottled:
+		return "被限流"
+	case FailureNotRegistered:
+		return "域名未注册"
+	case FailureUnparseable:
+		return "无法解析"
+	case FailureLowConfidence:
+		return "到期时间存疑"
+	case FailureUnsupported:
+		return "不支持的后缀"
+	case FailureUnknown:
+		return "未知错误"
+	default:
+		return "未分类"
+	}
+}
+
 type FailureRecord struct {
 	Domain string
 	Source string
+	Kind   FailureKind
 	Reason string
 }
@@ -3,8 +3,13 @@ package domain
 import (
 	"bufio"
 	"fmt"
+	"log"
 	"os"
 	"strings"
+	"time"
+
+	"DomainC/lookup"
+	"DomainC/tools"
 )
 
 // FileRepository 基于文件的域名仓库实现。
@@ -12,15 +17,19 @@ type FileRepository struct {
 	sourcesPaths   []string
 	expiringTarget string
 	failureTarget  string
+	// Location 为域名文件中只写日期时所在的时区，为空时按 UTC。
+	Location *time.Location
 }
 
 func NewFileRepository(sources []string, expiringPath, failurePath string) *FileRepository {
 	return &FileRepository{sourcesPaths: sources, expiringTarget: expiringPath, failureTarget: failurePath}
 }
 
-// LoadSources 读取配置的源文件，每行一个域名，忽略空行和注释。
+// LoadSources 读取配置的源文件，每行一个域名，格式为 域名|来源|到期时间|HTTP 检查，后三列可省略，忽略空行和注释。
+// 误填的子域名会按 Public Suffix List 归一化为可注册域名，同一来源下重复的域名只保留第一条。
 func (r *FileRepository) LoadSources() ([]DomainSource, error) {
 	var out []DomainSource
+	seen := make(map[string]bool)
 	for _, path := range r.sourcesPaths {
 		file, err := os.Open(path)
 		if err != nil {
@@ -34,22 +43,44 @@ func (r *FileRepository) LoadSources() ([]DomainSource, error) {
 				continue
 			}
 			parts := strings.Split(line, "|")
-			domain := strings.TrimSpace(parts[0])
-			if domain == "" {
+			domain := tools.NormalizeDomain(parts[0])
+			if domain == "" || strings.HasPrefix(domain, "#") {
 				continue
 			}
+			if registrable, err := tools.RegistrableDomain(domain); err == nil && registrable != domain {
+				log.Printf("域名文件 %s 中的 %s 是子域名，已按 %s 处理", path, domain, registrable)
+				domain = registrable
+			}
 
 			source := strings.TrimSpace(path)
 			if len(parts) >= 2 && strings.TrimSpace(parts[1]) != "" {
 				source = strings.TrimSpace(parts[1])
 			}
 
-			expiry := ""
-			if len(parts) >= 3 {
-				expiry = strings.TrimSpace(parts[2])
+			var expiry time.Time
+			if len(parts) >= 3 && strings.TrimSpace(parts[2]) != "" {
+				t, ok := r.parseExpiry(parts[2])
+				if !ok {
+					log.Printf("域名文件 %s 中 %s 的到期时间无法解析，改为在线查询: %s", path, domain, parts[2])
+				}
+				expiry = t
 			}
 
-			out = append(out, DomainSource{Domain: domain, Source: source, Expiry: expiry})
+			var checks []HTTPCheck
+			if len(parts) >= 4 && strings.TrimSpace(parts[3]) != "" {
+				parsed, err := ParseHTTPChecks(parts[3])
+				if err != nil {
+					log.Printf("域名文件 %s 中 %s 的 HTTP 检查配置无效，已忽略: %v", path, domain, err)
+				}
+				checks = parsed
+			}
+
+			key := domain + "|" + source
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, DomainSource{Domain: domain, Source: source, Expiry: expiry, HTTPChecks: checks})
 
 		}
 
@@ -62,7 +93,20 @@ func (r *FileRepository) LoadSources() ([]DomainSource, error) {
 	return out, nil
 }
 
-// SaveExpiring 将即将到期的域名写入指定文件，使用统一的分隔符和格式。
+// parseExpiry 解析域名文件中的到期时间：只写日期时按 Location 当天零点处理，其余格式同 lookup.ParseDate。
+func (r *FileRepository) parseExpiry(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	loc := r.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true
+	}
+	return lookup.ParseDate(value)
+}
+
+// SaveExpiring 将即将到期的域名写入指定文件，使用统一的分隔符和格式，到期时间为 RFC3339。
 func (r *FileRepository) SaveExpiring(domains []DomainSource) error {
 	file, err := os.Create(r.expiringTarget)
 	if err != nil {
@@ -73,7 +117,7 @@ func (r *FileRepository) SaveExpiring(domains []DomainSource) error {
 	writer := bufio.NewWriter(file)
 	for _, ds := range domains {
 		if _, err := writer.WriteString(
-			fmt.Sprintf("%s|%s|%s\n", strings.TrimSpace(ds.Domain), strings.TrimSpace(ds.Source), strings.TrimSpace(ds.Expiry)),
+			fmt.Sprintf("%s|%s|%s\n", strings.TrimSpace(ds.Domain), strings.TrimSpace(ds.Source), formatExpiry(ds.Expiry)),
 		); err != nil {
 			return fmt.Errorf("写入到期缓存失败: %w", err)
 		}
@@ -84,6 +128,8 @@ func (r *FileRepository) SaveExpiring(domains []DomainSource) error {
 	}
 	return nil
 }
+
+// SaveFailures 按 域名|来源|分类|原因 的格式写入失败记录。
 func (r *FileRepository) SaveFailures(failures []FailureRecord) error {
 	file, err := os.Create(r.failureTarget)
 	if err != nil {
@@ -94,7 +140,7 @@ func (r *FileRepository) SaveFailures(failures []FailureRecord) error {
 	writer := bufio.NewWriter(file)
 	for _, f := range failures {
 		if _, err := writer.WriteString(
-			fmt.Sprintf("%s|%s|%s\n", strings.TrimSpace(f.Domain), strings.TrimSpace(f.Source), strings.TrimSpace(f.Reason)),
+			fmt.Sprintf("%s|%s|%s|%s\n", strings.TrimSpace(f.Domain), strings.TrimSpace(f.Source), f.Kind, strings.TrimSpace(f.Reason)),
 		); err != nil {
 			return fmt.Errorf("写入失败记录失败: %w", err)
 		}
@@ -105,3 +151,10 @@ func (r *FileRepository) SaveFailures(failures []FailureRecord) error {
 	}
 	return nil
 }
+
+func formatExpiry(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadSourcesParsesMetadata(t *testing.T) {
@@ -31,7 +32,7 @@ func TestLoadSourcesParsesMetadata(t *testing.T) {
 	if first.Source != "yuang6496" {
 		t.Errorf("expected source to use second column, got %s", first.Source)
 	}
-	if first.Expiry != "2026-01-03" {
+	if want := time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC); !first.Expiry.Equal(want) {
 		t.Errorf("unexpected expiry: %s", first.Expiry)
 	}
 
@@ -42,7 +43,97 @@ func TestLoadSourcesParsesMetadata(t *testing.T) {
 	if second.Source != filePath {
 		t.Errorf("expected source to fall back to path, got %s", second.Source)
 	}
-	if second.Expiry != "" {
+	if !second.Expiry.IsZero() {
 		t.Errorf("expected empty expiry, got %s", second.Expiry)
 	}
 }
+
+func TestLoadSourcesNormalizesSubdomains(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "domains.txt")
+	data := "WWW.Example.com|acc\nexample.com|acc\nshop.example.com.cn\n"
+	if err := os.WriteFile(filePath, []byte(data), 0o644); err != nil {
+		t.Fatalf("failed to write temp domain file: %v", err)
+	}
+
+	sources, err := NewFileRepository([]string{filePath}, "", "").LoadSources()
+	if err != nil {
+		t.Fatalf("LoadSources returned error: %v", err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("expected duplicates to collapse into 2 sources, got %d: %+v", len(sources), sources)
+	}
+	if sources[0].Domain != "example.com" || sources[0].Source != "acc" {
+		t.Errorf("unexpected first source: %+v", sources[0])
+	}
+	if sources[1].Domain != "example.com.cn" {
+		t.Errorf("expected example.com.cn, got %s", sources[1].Domain)
+	}
+}
+
+func TestFileRepositoryKeepsFullExpiryTime(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "domains.txt")
+	data := "a.com|acc|2026-01-03\nb.com|acc|2026-01-03T15:04:05+08:00\nc.com|acc|not-a-date\n"
+	if err := os.WriteFile(sourcePath, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	shanghai := time.FixedZone("CST", 8*3600)
+	expiringPath := filepath.Join(dir, "expiring.txt")
+	repo := NewFileRepository([]string{sourcePath}, expiringPath, "")
+	repo.Location = shanghai
+
+	sources, err := repo.LoadSources()
+	if err != nil {
+		t.Fatalf("LoadSources: %v", err)
+	}
+	if !sources[0].Expiry.Equal(time.Date(2026, 1, 3, 0, 0, 0, 0, shanghai)) {
+		t.Fatalf("date-only expiry should use repository location, got %v", sources[0].Expiry)
+	}
+	if !sources[1].Expiry.Equal(time.Date(2026, 1, 3, 7, 4, 5, 0, time.UTC)) {
+		t.Fatalf("unexpected timestamp expiry %v", sources[1].Expiry)
+	}
+	if !sources[2].Expiry.IsZero() {
+		t.Fatalf("unparseable expiry should fall back to lookup, got %v", sources[2].Expiry)
+	}
+
+	if err := repo.SaveExpiring(sources[1:2]); err != nil {
+		t.Fatalf("SaveExpiring: %v", err)
+	}
+	saved, err := NewFileRepository([]string{expiringPath}, "", "").LoadSources()
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(saved) != 1 || !saved[0].Expiry.Equal(sources[1].Expiry) {
+		t.Fatalf("expected round-trip of full timestamp, got %+v", saved)
+	}
+}
+
+func TestLoadSourcesParsesHTTPChecks(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "domains.txt")
+	data := "a.com|acc||https://a.com/health status=200 contains=Hello%20World latency=2s; a.com redirect=https://www.a.com/\n" +
+		"b.com|acc||https://b.com status=abc\n"
+	if err := os.WriteFile(filePath, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	sources, err := NewFileRepository([]string{filePath}, "", "").LoadSources()
+	if err != nil {
+		t.Fatalf("LoadSources: %v", err)
+	}
+	checks := sources[0].HTTPChecks
+	if len(checks) != 2 {
+		t.Fatalf("expected 2 checks, got %+v", checks)
+	}
+	want := HTTPCheck{URL: "https://a.com/health", Status: 200, Contains: "Hello World", MaxLatency: 2 * time.Second}
+	if checks[0] != want {
+		t.Fatalf("unexpected first check %+v", checks[0])
+	}
+	if checks[1].URL != "https://a.com" || checks[1].Redirect != "https://www.a.com/" {
+		t.Fatalf("unexpected second check %+v", checks[1])
+	}
+	if len(sources[1].HTTPChecks) != 0 {
+		t.Fatalf("invalid check spec should be ignored, got %+v", sources[1].HTTPChecks)
+	}
+}
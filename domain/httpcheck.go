@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPCheck 描述一次 HTTP 访问检查，未设置的条件不检查。
+type HTTPCheck struct {
+	URL string
+	// Status 为期望的状态码，为 0 时要求小于 400。
+	Status int
+	// Redirect 为期望的跳转目标，设置后不跟随跳转，直接比较 Location。
+	Redirect string
+	// Contains 为响应内容中应包含的文本。
+	Contains   string
+	MaxLatency time.Duration
+}
+
+// Host 返回 URL 中的主机名（小写，不含端口）。
+func (c HTTPCheck) Host() string {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// DefaultHTTPCheck 返回主机的默认检查：访问 https://host/，状态码小于 400 即可。
+func DefaultHTTPCheck(host string) HTTPCheck {
+	return HTTPCheck{URL: "https://" + strings.TrimSuffix(strings.ToLower(host), ".") + "/"}
+}
+
+// ParseHTTPChecks 解析域名文件第四列的检查配置，多条以分号分隔，每条为 URL 加可选的 key=value，例如
+//
+//	https://example.com/health status=200 contains=ok latency=2s; http://example.com redirect=https://example.com/
+//
+// 值中的空格等字符需按 URL 编码书写，例如 contains=Hello%20World；URL 未写协议时按 https 处理。
+func ParseHTTPChecks(spec string) ([]HTTPCheck, error) {
+	var out []HTTPCheck
+	for _, part := range strings.Split(spec, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		check := HTTPCheck{URL: NormalizeCheckURL(fields[0])}
+		for _, f := range fields[1:] {
+			key, raw, ok := strings.Cut(f, "=")
+			if !ok {
+				return nil, fmt.Errorf("无法识别的检查条件: %s", f)
+			}
+			value, err := url.QueryUnescape(raw)
+			if err != nil {
+				return nil, fmt.Errorf("检查条件 %s 的值无效: %w", key, err)
+			}
+			switch strings.ToLower(key) {
+			case "status":
+				code, err := strconv.Atoi(value)
+				if err != nil || code < 100 || code > 599 {
+					return nil, fmt.Errorf("无效的状态码: %s", value)
+				}
+				check.Status = code
+			case "redirect":
+				check.Redirect = value
+			case "contains":
+				check.Contains = value
+			case "latency":
+				d, err := time.ParseDuration(value)
+				if err != nil {
+					return nil, fmt.Errorf("无效的延迟上限: %s", value)
+				}
+				check.MaxLatency = d
+			default:
+				return nil, fmt.Errorf("未知的检查条件: %s", key)
+			}
+		}
+		out = append(out, check)
+	}
+	return out, nil
+}
+
+// NormalizeCheckURL 为未写协议的地址补上 https://。
+func NormalizeCheckURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		return "https://" + raw
+	}
+	return raw
+}
+
+// HTTPCheckResult 为一次 HTTP 检查的结果；Err 为请求失败原因，Problems 为未满足的条件。
+type HTTPCheckResult struct {
+	Check      HTTPCheck
+	StatusCode int
+	// Location 为跳转目标，或跟随跳转后的最终地址。
+	Location string
+	Latency  time.Duration
+	Err      string
+	Problems []string
+}
+
+func (r HTTPCheckResult) OK() bool {
+	return r.Err == "" && len(r.Problems) == 0
+}
+
+// Summary 返回一行结果说明，例如 "✅ https://example.com/ → 200 (123ms)"。
+func (r HTTPCheckResult) Summary() string {
+	if r.Err != "" {
+		return fmt.Sprintf("❌ %s: %s", r.Check.URL, r.Err)
+	}
+	line := fmt.Sprintf("%s → %d", r.Check.URL, r.StatusCode)
+	if r.Location != "" && r.Location != r.Check.URL {
+		line += " → " + r.Location
+	}
+	line += fmt.Sprintf(" (%dms)", r.Latency.Milliseconds())
+	if r.OK() {
+		return "✅ " + line
+	}
+	return "❌ " + line + ": " + strings.Join(r.Problems, "; ")
+}
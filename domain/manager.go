@@ -4,30 +4,36 @@ import (
 	"context"
 	"log"
 	"strings"
+	"time"
 
 	"DomainC/cfclient"
 	"DomainC/config"
+	"DomainC/lookup"
 	"DomainC/tools"
 )
 
 type DomainSource struct {
 	Domain string
 	Source string
-	Expiry string
+	// Expiry 为完整的到期时间，未知时为零值。
+	Expiry time.Time
 	IsCF   bool
 	Status string
 	Paused bool
+	// Whois 为本次检测得到的注册信息，未查询时为空。
+	Whois *lookup.Result
+	// NameServers 为 Cloudflare 分配的 NS，仅 CF 域名有值。
+	NameServers []string
+	// HTTPChecks 为域名文件中配置的 HTTP 访问检查。
+	HTTPChecks []HTTPCheck
 }
 
-func DaysUntil(expiry string) (int, error) {
-	return tools.DaysUntilExpiry(expiry)
+// DaysUntil 返回 loc 时区内距离到期的日历天数。
+func DaysUntil(expiry time.Time, loc *time.Location) int {
+	return tools.CountdownTo(expiry, time.Now(), loc).Days
 }
 
-func CheckWhois(domain string) string {
-	return tools.CheckWhois(domain)
-}
-
-func ParseExpiry(whois string) (string, bool) {
+func ParseExpiry(whois string) (time.Time, bool) {
 	return tools.ExtractExpiry(whois)
 }
 
@@ -53,11 +59,12 @@ func (s *Service) collectCF(accounts []config.CF) ([]DomainSource, error) {
 		}
 		for _, d := range doms {
 			out = append(out, DomainSource{
-				Domain: d.Domain,
-				Source: d.Source,
-				IsCF:   d.IsCF,
-				Status: d.Status,
-				Paused: d.Paused,
+				Domain:      d.Domain,
+				Source:      d.Source,
+				IsCF:        d.IsCF,
+				Status:      d.Status,
+				Paused:      d.Paused,
+				NameServers: d.NameServers,
 			})
 		}
 	}
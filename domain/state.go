@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/lookup"
+)
+
+// DomainState 记录单个域名跨运行保留的状态。
+type DomainState struct {
+	Domain string `json:"domain"`
+	// Source 与 IsCF 为最近一次查询时域名的来源，手动刷新时沿用其查询策略与事件来源。
+	Source string `json:"source,omitempty"`
+	IsCF   bool   `json:"isCF,omitempty"`
+	// Lookup 为最近一次成功查询的结果（不含原文）。
+	Lookup    *lookup.Result `json:"lookup,omitempty"`
+	CheckedAt time.Time      `json:"checkedAt,omitempty"`
+	// RenewedAt 为最近一次发现到期时间延后的时间。
+	RenewedAt time.Time `json:"renewedAt,omitempty"`
+	// Alert 记录针对当前到期时间已经发送过的提醒。
+	Alert *AlertRecord `json:"alert,omitempty"`
+	// History 为到期时间的变化记录，按时间先后排列，最多保留 MaxExpiryHistory 条。
+	History []ExpiryChange `json:"history,omitempty"`
+	// Registrars 为注册商与转移锁的变化记录，按时间先后排列，最多保留 MaxRegistrarHistory 条。
+	Registrars []RegistrarRecord `json:"registrars,omitempty"`
+	// EdgePending 为 CF 边缘证书首次观察到待处理状态的时间，键为证书 ID 与状态，签发完成后清除。
+	EdgePending map[string]time.Time `json:"edgePending,omitempty"`
+}
+
+// MaxRegistrarHistory 为每个域名保留的注册商变化记录条数。
+const MaxRegistrarHistory = 50
+
+// RegistrarRecord 记录某一时刻的注册商及转移锁（clientTransferProhibited 等）。
+type RegistrarRecord struct {
+	At        time.Time `json:"at"`
+	Registrar string    `json:"registrar,omitempty"`
+	IANAID    string    `json:"ianaID,omitempty"`
+	Locks     []string  `json:"locks,omitempty"`
+}
+
+// LastRegistrar 返回最近一条注册商记录。
+func (s DomainState) LastRegistrar() (RegistrarRecord, bool) {
+	if len(s.Registrars) == 0 {
+		return RegistrarRecord{}, false
+	}
+	return s.Registrars[len(s.Registrars)-1], true
+}
+
+// AddRegistrar 追加一条注册商记录，超出 MaxRegistrarHistory 时丢弃最早的记录。
+func (s *DomainState) AddRegistrar(record RegistrarRecord) {
+	s.Registrars = append(s.Registrars, record)
+	if n := len(s.Registrars); n > MaxRegistrarHistory {
+		s.Registrars = append([]RegistrarRecord(nil), s.Registrars[n-MaxRegistrarHistory:]...)
+	}
+}
+
+// MaxExpiryHistory 为每个域名保留的到期时间变化记录条数。
+const MaxExpiryHistory = 50
+
+// ExpiryChange 记录一次到期时间变化，首次查询到的到期时间 From 为零值。
+type ExpiryChange struct {
+	At   time.Time `json:"at"`
+	From time.Time `json:"from,omitempty"`
+	To   time.Time `json:"to"`
+}
+
+// Renewed 表示到期时间延后，即域名已续费。
+func (c ExpiryChange) Renewed() bool {
+	return !c.From.IsZero() && c.To.After(c.From)
+}
+
+// MovedBackwards 表示到期时间被提前，通常意味着注册局数据异常或域名被转移、删除。
+func (c ExpiryChange) MovedBackwards() bool {
+	return !c.From.IsZero() && c.To.Before(c.From)
+}
+
+// AddHistory 追加一条到期时间变化，超出 MaxExpiryHistory 时丢弃最早的记录。
+func (s *DomainState) AddHistory(change ExpiryChange) {
+	s.History = append(s.History, change)
+	if n := len(s.History); n > MaxExpiryHistory {
+		s.History = append([]ExpiryChange(nil), s.History[n-MaxExpiryHistory:]...)
+	}
+}
+
+// AlertRecord 记录某个到期时间下已提醒过的阈值天数和最近一次提醒时间。
+type AlertRecord struct {
+	Expiry     time.Time `json:"expiry"`
+	Thresholds []int     `json:"thresholds,omitempty"`
+	LastSent   time.Time `json:"lastSent,omitempty"`
+}
+
+// Announced 判断阈值是否已经提醒过。
+func (r AlertRecord) Announced(threshold int) bool {
+	for _, t := range r.Thresholds {
+		if t == threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// StateStore 保存域名状态，Put/Update 只修改内存，Save 时统一落盘。
+// 并发修改同一域名时应使用 Update，Get 后再 Put 会互相覆盖。
+type StateStore interface {
+	Get(domain string) (DomainState, bool)
+	Put(state DomainState)
+	Update(domain string, fn func(*DomainState))
+	All() []DomainState
+	Save() error
+}
+
+// FileStateStore 将域名状态保存为 JSON 文件。
+type FileStateStore struct {
+	path   string
+	mu     sync.RWMutex
+	states map[string]DomainState
+}
+
+// NewFileStateStore 读取已有状态文件，文件不存在时返回空仓库。
+func NewFileStateStore(path string) (*FileStateStore, error) {
+	s := &FileStateStore{path: path, states: make(map[string]DomainState)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取状态文件失败: %w", err)
+	}
+
+	var list []DomainState
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("解析状态文件失败: %w", err)
+	}
+	for _, st := range list {
+		s.states[stateKey(st.Domain)] = st
+	}
+	return s, nil
+}
+
+func (s *FileStateStore) Get(domain string) (DomainState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.states[stateKey(domain)]
+	return st, ok
+}
+
+func (s *FileStateStore) Put(state DomainState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[stateKey(state.Domain)] = state
+}
+
+// Update 在同一把锁内读取、修改并写回域名状态。状态不存在时 fn 收到只设置了 Domain 的空状态。
+func (s *FileStateStore) Update(domain string, fn func(*DomainState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stateKey(domain)
+	st := s.states[key]
+	if st.Domain == "" {
+		st.Domain = domain
+	}
+	fn(&st)
+	s.states[key] = st
+}
+
+// All 返回按域名排序的全部状态。
+func (s *FileStateStore) All() []DomainState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]DomainState, 0, len(s.states))
+	for _, st := range s.states {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
+	return out
+}
+
+// Save 先写临时文件再重命名，避免中断时损坏状态文件。
+func (s *FileStateStore) Save() error {
+	data, err := json.MarshalIndent(s.All(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化状态失败: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("创建状态临时文件失败: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入状态文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入状态文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("替换状态文件失败: %w", err)
+	}
+	return nil
+}
+
+func stateKey(domain string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/config"
+	"DomainC/domain"
+)
+
+// DefaultAlertThresholds 为默认的逐级提醒天数。
+var DefaultAlertThresholds = []int{60, 30, 14, 7, 3, 1}
+
+// defaultDailyWithin 为默认的每日提醒天数。
+const defaultDailyWithin = 3
+
+// AlertSchedule 描述逐级提醒：剩余天数首次降到某个阈值时提醒一次，
+// 剩余天数不超过 DailyWithin 时每天提醒。
+type AlertSchedule struct {
+	// Thresholds 按从大到小排序的提醒天数。
+	Thresholds  []int
+	DailyWithin int
+}
+
+// AlertPolicy 为默认提醒计划，Overrides 按来源（CF 账号 label 或域名文件来源）覆盖。
+type AlertPolicy struct {
+	Default   AlertSchedule
+	Overrides map[string]AlertSchedule
+}
+
+// NewAlertSchedule 对阈值去重并从大到小排序，忽略非正数。
+func NewAlertSchedule(thresholds []int, dailyWithin int) AlertSchedule {
+	seen := make(map[int]bool)
+	var out []int
+	for _, t := range thresholds {
+		if t > 0 && !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(out)))
+	if dailyWithin < 0 {
+		dailyWithin = 0
+	}
+	return AlertSchedule{Thresholds: out, DailyWithin: dailyWithin}
+}
+
+// AlertPolicyFromConfig 根据 alerts 配置构造提醒计划。未配置阈值时使用默认阈值，
+// 并以旧的 alertDays 作为上限，保持原有的提醒窗口。
+func AlertPolicyFromConfig(cfg config.Alerts, alertDays int) AlertPolicy {
+	thresholds := cfg.Thresholds
+	if len(thresholds) == 0 {
+		for _, t := range DefaultAlertThresholds {
+			if alertDays <= 0 || t <= alertDays {
+				thresholds = append(thresholds, t)
+			}
+		}
+		if len(thresholds) == 0 {
+			thresholds = []int{alertDays}
+		}
+	}
+	daily := defaultDailyWithin
+	if cfg.DailyWithin != nil {
+		daily = *cfg.DailyWithin
+	}
+
+	policy := AlertPolicy{Default: NewAlertSchedule(thresholds, daily)}
+	for source, o := range cfg.Overrides {
+		schedule := policy.Default
+		if len(o.Thresholds) > 0 {
+			schedule = NewAlertSchedule(o.Thresholds, schedule.DailyWithin)
+		}
+		if o.DailyWithin != nil {
+			schedule.DailyWithin = *o.DailyWithin
+		}
+		if policy.Overrides == nil {
+			policy.Overrides = make(map[string]AlertSchedule)
+		}
+		policy.Overrides[strings.TrimSpace(source)] = schedule
+	}
+	return policy
+}
+
+// For 返回来源对应的提醒计划。
+func (p AlertPolicy) For(source string) AlertSchedule {
+	if s, ok := p.Overrides[strings.TrimSpace(source)]; ok {
+		return s
+	}
+	return p.Default
+}
+
+// Window 返回全部提醒计划中最大的提醒窗口，用于决定哪些域名需要每天复查。
+func (p AlertPolicy) Window() time.Duration {
+	window := p.Default.Window()
+	for _, s := range p.Overrides {
+		if w := s.Window(); w > window {
+			window = w
+		}
+	}
+	return window
+}
+
+// Window 返回剩余天数不超过最大阈值（或每日提醒天数）的时间窗口。
+func (s AlertSchedule) Window() time.Duration {
+	days := s.DailyWithin
+	if len(s.Thresholds) > 0 && s.Thresholds[0] > days {
+		days = s.Thresholds[0]
+	}
+	if days <= 0 {
+		return 0
+	}
+	// 剩余天数向下取整，窗口取到 days+1 天之前
+	return time.Duration(days+1) * 24 * time.Hour
+}
+
+// Due 判断剩余 days 天的域名本次是否需要提醒，并返回更新后的提醒记录。
+// 到期时间变化（续费或注册局修正）后重新开始计算阈值；首次进入时跳过已越过的更大阈值，
+// 避免一次性补发多条提醒。
+func (s AlertSchedule) Due(rec *domain.AlertRecord, expiry time.Time, days int, now time.Time) (bool, domain.AlertRecord) {
+	next := domain.AlertRecord{Expiry: expiry}
+	if rec != nil && rec.Expiry.Equal(expiry) {
+		next = *rec
+		next.Thresholds = append([]int(nil), rec.Thresholds...)
+	}
+
+	current := 0
+	for _, t := range s.Thresholds {
+		if days <= t {
+			current = t
+		}
+	}
+
+	due := false
+	if current > 0 && !next.Announced(current) {
+		due = true
+	} else if days <= s.DailyWithin && !sameDay(next.LastSent, now) {
+		due = true
+	}
+	if !due {
+		return false, next
+	}
+
+	for _, t := range s.Thresholds {
+		if days <= t && !next.Announced(t) {
+			next.Thresholds = append(next.Thresholds, t)
+		}
+	}
+	next.LastSent = now
+	return true, next
+}
+
+func sameDay(a, b time.Time) bool {
+	if a.IsZero() {
+		return false
+	}
+	ay, am, ad := a.In(b.Location()).Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
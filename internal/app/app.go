@@ -13,13 +13,40 @@ type DomainCollector interface {
 	Collect(ctx context.Context) ([]domain.DomainSource, error)
 }
 
+// CheckReport 为一次检测的结果：即将到期的域名、查询失败，以及需要单独通知的事件。
+type CheckReport struct {
+	// Expiring 为本次需要提醒的域名，Window 为提醒窗口内的全部域名（含按提醒计划本次不提醒的）。
+	Expiring []domain.DomainSource
+	Window   []domain.DomainSource
+	Failures []domain.FailureRecord
+	Events   []domain.Event
+	// Alerts 为 Expiring 对应的提醒记录，提醒发送成功后通过 RecordAlerts 写入。
+	Alerts []PendingAlert
+}
+
+// PendingAlert 为尚未确认送达的提醒记录，Domain 为可注册域名。
+type PendingAlert struct {
+	Domain string
+	Record domain.AlertRecord
+}
+
 type ExpiryChecker interface {
-	Check(ctx context.Context, domains []domain.DomainSource) ([]domain.DomainSource, []domain.FailureRecord, error)
+	Check(ctx context.Context, domains []domain.DomainSource) (CheckReport, error)
+	RecordAlerts(alerts []PendingAlert) error
 }
 
 type Notifier interface {
 	Notify(ctx context.Context, domains []domain.DomainSource) error
+	// AutoDelete 处理提醒窗口内的全部域名，不受提醒去重影响。
+	AutoDelete(ctx context.Context, domains []domain.DomainSource) error
 	NotifyFailures(ctx context.Context, failures []domain.FailureRecord) error
+	NotifyEvents(ctx context.Context, events []domain.Event) error
+}
+
+// Probe 为到期检测之外的每日检查，例如 NS 委派、DNSSEC、证书，发现的问题以事件返回。
+type Probe interface {
+	Name() string
+	Run(ctx context.Context, domains []domain.DomainSource) ([]domain.Event, error)
 }
 
 type Scheduler interface {
@@ -31,6 +58,8 @@ type App struct {
 	Checker   ExpiryChecker
 	Notifier  Notifier
 	Scheduler Scheduler
+	// Probes 在每次到期检测之后依次执行。
+	Probes    []Probe
 	AlertHour int
 	AlertMin  int
 }
@@ -47,22 +76,50 @@ func (a *App) Run(ctx context.Context) error {
 			return
 		}
 
-		expiring, failures, err := a.Checker.Check(ctx, domains)
+		report, err := a.Checker.Check(ctx, domains)
 		if err != nil {
 			log.Printf("检测到期失败: %v", err)
 		}
 
-		if len(expiring) > 0 {
-			if err := a.Notifier.Notify(ctx, expiring); err != nil {
+		// 域名已被删除等事件最紧急，先于到期提醒发送
+		if len(report.Events) > 0 {
+			if err := a.Notifier.NotifyEvents(ctx, report.Events); err != nil {
+				log.Printf("发送事件通知失败: %v", err)
+			}
+		}
+
+		if len(report.Expiring) > 0 {
+			// 提醒送达后才记录已提醒的阈值，发送失败时下次运行重新提醒
+			if err := a.Notifier.Notify(ctx, report.Expiring); err != nil {
 				log.Printf("发送通知失败: %v", err)
+			} else if err := a.Checker.RecordAlerts(report.Alerts); err != nil {
+				log.Printf("记录提醒状态失败: %v", err)
+			}
+		}
+
+		if len(report.Window) > 0 {
+			if err := a.Notifier.AutoDelete(ctx, report.Window); err != nil {
+				log.Printf("自动删除失败: %v", err)
 			}
 		}
 
-		if len(failures) > 0 {
-			if err := a.Notifier.NotifyFailures(ctx, failures); err != nil {
+		if len(report.Failures) > 0 {
+			if err := a.Notifier.NotifyFailures(ctx, report.Failures); err != nil {
 				log.Printf("发送失败通知失败: %v", err)
 			}
 		}
+
+		for _, probe := range a.Probes {
+			events, err := probe.Run(ctx, domains)
+			if err != nil {
+				log.Printf("%s失败: %v", probe.Name(), err)
+			}
+			if len(events) > 0 {
+				if err := a.Notifier.NotifyEvents(ctx, events); err != nil {
+					log.Printf("发送%s通知失败: %v", probe.Name(), err)
+				}
+			}
+		}
 	}
 
 	run()
@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/dnsquery"
+	"DomainC/domain"
+	"DomainC/tools"
+)
+
+// certUrgentWithin 内到期或已过期的证书按紧急事件通知。
+const certUrgentWithin = 3 * 24 * time.Hour
+
+// CertTarget 为一次证书检查的目标，Host 用作 SNI，Addr 为实际连接的地址。
+type CertTarget struct {
+	Host   string
+	Addr   string
+	Zone   string
+	Source string
+}
+
+// CertInfo 为握手得到的叶子证书信息。
+type CertInfo struct {
+	Target   CertTarget
+	NotAfter time.Time
+	Issuer   string
+	DNSNames []string
+	// Covered 表示证书的 SAN 覆盖了 Target.Host。
+	Covered bool
+	// VerifyErr 为证书链校验失败的原因，为空表示受信任。
+	VerifyErr string
+}
+
+// CertProbe 对 CF Zone 中未代理的主机名及额外配置的主机做 TLS 握手，检查证书到期时间、签发者与 SAN。
+// 已代理的主机名按主机名连接得到的是 CF 边缘证书，IncludeProxied 为 true 时改为按记录内容直连源站、
+// 以主机名为 SNI 检查源站证书；边缘证书由 EdgeCertProbe 检查。
+type CertProbe struct {
+	CF             cfclient.Client
+	Extra          []CertTarget
+	Within         time.Duration
+	IncludeProxied bool
+	Timeout        time.Duration
+	// RootCAs 为校验证书链使用的根证书，为空时使用系统根证书。
+	RootCAs *x509.CertPool
+	// Location 为展示到期时间的时区，为空时使用系统时区。
+	Location *time.Location
+}
+
+func (p *CertProbe) Name() string { return "证书检查" }
+
+func (p *CertProbe) Run(ctx context.Context, domains []domain.DomainSource) ([]domain.Event, error) {
+	var events []domain.Event
+	for _, target := range p.targets(ctx, domains) {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		info, err := p.Inspect(ctx, target)
+		if err != nil {
+			// 连接失败多为主机未提供 HTTPS，可达性由其他检查负责
+			log.Printf("TLS 握手失败 (%s → %s): %v", target.Host, target.Addr, err)
+			continue
+		}
+		if ev, ok := p.evaluate(info, time.Now()); ok {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+// targets 汇总需要检查的主机：各 CF Zone 的 A/AAAA/CNAME 记录与 Extra，按主机名去重。
+func (p *CertProbe) targets(ctx context.Context, domains []domain.DomainSource) []CertTarget {
+	seen := make(map[string]bool)
+	var out []CertTarget
+	add := func(t CertTarget) {
+		t.Host = dnsquery.NormalizeName(t.Host)
+		if t.Host == "" || strings.HasPrefix(t.Host, "*") {
+			return
+		}
+		if t.Addr == "" {
+			t.Addr = net.JoinHostPort(t.Host, "443")
+		}
+		key := t.Host + "|" + t.Addr
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		out = append(out, t)
+	}
+
+	for _, ds := range domains {
+		if !ds.IsCF || p.CF == nil {
+			continue
+		}
+		account := cfclient.GetAccountByLabel(ds.Source)
+		if account == nil {
+			log.Printf("未找到账号: %s", ds.Source)
+			continue
+		}
+		records, err := p.CF.ListDNSRecords(ctx, *account, ds.Domain)
+		if err != nil {
+			log.Printf("获取 %s 解析失败: %v", ds.Domain, err)
+			continue
+		}
+		for _, r := range records {
+			switch r.Type {
+			case "A", "AAAA", "CNAME":
+			default:
+				continue
+			}
+			target := CertTarget{Host: r.Name, Zone: ds.Domain, Source: ds.Source}
+			if r.Proxied != nil && *r.Proxied {
+				if !p.IncludeProxied || r.Content == "" {
+					continue
+				}
+				target.Addr = net.JoinHostPort(dnsquery.NormalizeName(r.Content), "443")
+			}
+			add(target)
+		}
+	}
+	for _, t := range p.Extra {
+		if t.Source == "" {
+			t.Source = "certificates"
+		}
+		add(t)
+	}
+	return out
+}
+
+// Inspect 以 Host 为 SNI 连接 Addr，返回叶子证书信息。证书不受信任时仍返回信息，并在 VerifyErr 中说明。
+func (p *CertProbe) Inspect(ctx context.Context, target CertTarget) (CertInfo, error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		// 先取回证书再单独校验，过期或不受信任的证书也要能报告到期时间
+		Config: &tls.Config{ServerName: target.Host, InsecureSkipVerify: true},
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := dialer.DialContext(dialCtx, "tcp", target.Addr)
+	if err != nil {
+		return CertInfo{}, err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return CertInfo{}, errors.New("服务器未返回证书")
+	}
+	leaf := state.PeerCertificates[0]
+	info := CertInfo{
+		Target:   target,
+		NotAfter: leaf.NotAfter,
+		Issuer:   certIssuer(leaf),
+		DNSNames: leaf.DNSNames,
+		Covered:  leaf.VerifyHostname(target.Host) == nil,
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range state.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         p.RootCAs,
+		Intermediates: intermediates,
+		// 到期与主机名单独判断，这里只校验证书链
+		CurrentTime: leaf.NotBefore.Add(time.Second),
+	})
+	if err != nil {
+		info.VerifyErr = err.Error()
+	}
+	return info, nil
+}
+
+// evaluate 将证书信息转换为事件：证书链或主机名校验失败时为 EventCertInvalid，进入提醒窗口时为 EventCertExpiring。
+func (p *CertProbe) evaluate(info CertInfo, now time.Time) (domain.Event, bool) {
+	ev := domain.Event{Domain: info.Target.Host, Source: info.Target.Source, At: now}
+	summary := fmt.Sprintf("%s\n签发者: %s\n到期时间: %s (%s)\nSAN: %s",
+		describeTarget(info.Target), orDash(info.Issuer),
+		tools.FormatExpiry(info.NotAfter, p.Location), tools.CountdownTo(info.NotAfter, now, p.Location),
+		orDash(strings.Join(info.DNSNames, ", ")))
+
+	remaining := info.NotAfter.Sub(now)
+	switch {
+	case !info.Covered:
+		ev.Kind = domain.EventCertInvalid
+		ev.Urgent = true
+		ev.Detail = fmt.Sprintf("证书未覆盖主机名 %s\n%s", info.Target.Host, summary)
+	case info.VerifyErr != "":
+		ev.Kind = domain.EventCertInvalid
+		ev.Urgent = true
+		ev.Detail = fmt.Sprintf("证书链校验失败: %s\n%s", info.VerifyErr, summary)
+	case remaining <= p.Within:
+		ev.Kind = domain.EventCertExpiring
+		ev.Urgent = remaining <= certUrgentWithin
+		ev.Detail = summary
+	default:
+		return domain.Event{}, false
+	}
+	return ev, true
+}
+
+func describeTarget(t CertTarget) string {
+	var parts []string
+	if t.Zone != "" {
+		parts = append(parts, "Zone: "+t.Zone)
+	}
+	parts = append(parts, "地址: "+t.Addr)
+	return strings.Join(parts, "\n")
+}
+
+func certIssuer(c *x509.Certificate) string {
+	if c.Issuer.CommonName != "" {
+		if len(c.Issuer.Organization) > 0 {
+			return fmt.Sprintf("%s (%s)", c.Issuer.CommonName, c.Issuer.Organization[0])
+		}
+		return c.Issuer.CommonName
+	}
+	return c.Issuer.String()
+}
+
+// CertTargetsFromConfig 将配置中的额外主机转换为检查目标，Address 未写端口时补 443。
+func CertTargetsFromConfig(hosts []config.CertHost) []CertTarget {
+	out := make([]CertTarget, 0, len(hosts))
+	for _, h := range hosts {
+		addr := strings.TrimSpace(h.Address)
+		if addr != "" {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				addr = net.JoinHostPort(strings.Trim(addr, "[]"), "443")
+			}
+		}
+		out = append(out, CertTarget{Host: h.Host, Addr: addr})
+	}
+	return out
+}
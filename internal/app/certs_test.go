@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/config"
+	"DomainC/domain"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+)
+
+// testCA 签发测试证书并提供对应的根证书池。
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root", Organization: []string{"DomainC"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(5, 0, 0),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// serve 启动使用 dnsNames、在 notAfter 到期的证书的本地 TLS 服务，返回监听地址。
+func (ca *testCA) serve(t *testing.T, notAfter time.Time, dnsNames ...string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				c.(*tls.Conn).Handshake()
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+type recordsCF struct {
+	fakeCF
+	records map[string][]cloudflare.DNSRecord
+}
+
+func (f *recordsCF) ListDNSRecords(ctx context.Context, account config.CF, zone string) ([]cloudflare.DNSRecord, error) {
+	return f.records[zone], nil
+}
+
+func TestCertProbeReportsExpiringAndInvalidCerts(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+	healthy := ca.serve(t, time.Now().AddDate(0, 6, 0), "ok.example.com")
+	expiring := ca.serve(t, time.Now().Add(5*24*time.Hour), "soon.example.com", "www.soon.example.com")
+	mismatch := ca.serve(t, time.Now().AddDate(1, 0, 0), "other.example.com")
+	untrusted := other.serve(t, time.Now().AddDate(1, 0, 0), "self.example.com")
+
+	probe := &CertProbe{
+		Extra: []CertTarget{
+			{Host: "ok.example.com", Addr: healthy},
+			{Host: "www.soon.example.com", Addr: expiring},
+			{Host: "wrong.example.com", Addr: mismatch},
+			{Host: "self.example.com", Addr: untrusted},
+			{Host: "down.example.com", Addr: "127.0.0.1:1"},
+		},
+		Within:  14 * 24 * time.Hour,
+		Timeout: time.Second,
+		RootCAs: ca.pool,
+	}
+	events, err := probe.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if ev := events[0]; ev.Kind != domain.EventCertExpiring || ev.Domain != "www.soon.example.com" || ev.Urgent ||
+		!strings.Contains(ev.Detail, "Test Root (DomainC)") || !strings.Contains(ev.Detail, "剩余 4 天") && !strings.Contains(ev.Detail, "剩余 5 天") {
+		t.Fatalf("unexpected expiring event %+v", ev)
+	}
+	if ev := events[1]; ev.Kind != domain.EventCertInvalid || !ev.Urgent || !strings.Contains(ev.Detail, "未覆盖主机名 wrong.example.com") {
+		t.Fatalf("unexpected SAN event %+v", ev)
+	}
+	if ev := events[2]; ev.Kind != domain.EventCertInvalid || !strings.Contains(ev.Detail, "证书链校验失败") {
+		t.Fatalf("unexpected trust event %+v", ev)
+	}
+}
+
+func TestCertProbeEnumeratesUnproxiedHosts(t *testing.T) {
+	on, off := true, false
+	cf := &recordsCF{records: map[string][]cloudflare.DNSRecord{
+		"example.com": {
+			{Type: "A", Name: "example.com", Proxied: &on},
+			{Type: "A", Name: "origin.example.com", Proxied: &off},
+			{Type: "CNAME", Name: "Mail.Example.com.", Proxied: &off},
+			{Type: "A", Name: "*.example.com", Proxied: &off},
+			{Type: "TXT", Name: "example.com"},
+		},
+	}}
+	config.Cfg.CloudflareAccounts = []config.CF{{Label: "acc"}}
+	probe := &CertProbe{CF: cf, Extra: CertTargetsFromConfig([]config.CertHost{{Host: "origin.example.com", Address: "10.0.0.5"}})}
+
+	targets := probe.targets(context.Background(), []domain.DomainSource{{Domain: "example.com", Source: "acc", IsCF: true}})
+	var got []string
+	for _, tg := range targets {
+		got = append(got, tg.Host+"@"+tg.Addr)
+	}
+	want := "origin.example.com@origin.example.com:443,mail.example.com@mail.example.com:443,origin.example.com@10.0.0.5:443"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected targets %v", got)
+	}
+
+	// 已代理的记录直连源站，SNI 仍为主机名
+	cf.records["example.com"] = []cloudflare.DNSRecord{
+		{Type: "A", Name: "example.com", Content: "192.0.2.10", Proxied: &on},
+		{Type: "AAAA", Name: "www.example.com", Content: "2001:db8::10", Proxied: &on},
+	}
+	probe = &CertProbe{CF: cf, IncludeProxied: true}
+	got = nil
+	for _, tg := range probe.targets(context.Background(), []domain.DomainSource{{Domain: "example.com", Source: "acc", IsCF: true}}) {
+		got = append(got, tg.Host+"@"+tg.Addr)
+	}
+	if want := "example.com@192.0.2.10:443,www.example.com@[2001:db8::10]:443"; strings.Join(got, ",") != want {
+		t.Fatalf("expected proxied records to dial the origin, got %v", got)
+	}
+}
@@ -2,105 +2,582 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"DomainC/domain"
+	"DomainC/lookup"
 	"DomainC/tools"
 )
 
+// WhoisClient 查询域名注册信息并返回结构化结果。
 type WhoisClient interface {
-	Query(ctx context.Context, domain string) (string, error)
+	Lookup(ctx context.Context, domain string) (lookup.Result, error)
+}
+
+// SourceLookup 由可以按来源选择查询策略的 WhoisClient 实现，例如 lookup.Chain。
+type SourceLookup interface {
+	LookupSource(ctx context.Context, domain, source string) (lookup.Result, error)
 }
 
 type ExpiryCheckerService struct {
-	Whois        WhoisClient
-	Repo         domain.Repository
-	AlertWithin  time.Duration
-	RateLimit    time.Duration
+	Whois       WhoisClient
+	Repo        domain.Repository
+	AlertWithin time.Duration
+	// RateLimit 为同一限流键两次查询之间的最小间隔。
+	RateLimit time.Duration
+	// RateLimits 按限流键（默认为公共后缀）覆盖 RateLimit，例如 {"ph": 5s}，按最长后缀匹配。
+	RateLimits map[string]time.Duration
+	// RateKey 按域名与来源计算限流键（例如该来源的查询策略第一个访问的 WHOIS/RDAP 服务器），
+	// 为空或返回空字符串时按公共后缀分组。间隔仍按域名的公共后缀从 RateLimits 中选取。
+	RateKey func(domain, source string) string
+	// Workers 为并发查询的协程数，小于 1 时按 1 处理。
+	Workers      int
 	QueryTimeout time.Duration
+	// State 保存最近一次成功查询的结果，配合 Recheck 决定是否需要重新查询；为空时每次都查询。
+	State   domain.StateStore
+	Recheck RecheckPolicy
+	// ForceRefresh 为 true 时忽略缓存，全部重新查询。
+	ForceRefresh bool
+	// Retries 为网络错误、限流等临时性失败在本次运行内的重试次数。
+	Retries int
+	// RetryBackoff 为第一次重试前的等待时间，之后逐次翻倍，限流时再翻倍。
+	RetryBackoff time.Duration
+	// MinConfidence 为到期时间的最低置信度（0~1）。低于该值的结果仍按解析出的时间提醒，
+	// 但不写入缓存、不与历史比较，并记为"到期时间存疑"失败以便人工核对；小于等于 0 表示不检查。
+	// 配置中未设置时由 main 默认为 0.5，设为负数关闭。
+	MinConfidence float64
+	// Location 为计算剩余天数与判断"同一天"的时区，为空时使用系统时区。
+	Location *time.Location
+	// Alerts 为逐级提醒计划，配合 State 让每个阈值只提醒一次；为空时窗口内的域名每次都提醒。
+	Alerts *AlertPolicy
+
+	// limiter 在多次检测与手动刷新之间共用，避免两者同时访问同一服务器。
+	limiterOnce sync.Once
+	limiter     *keyedLimiter
+}
+
+// checkOutcome 记录单个域名的检测结果，按输入顺序汇总以保证输出稳定。
+type checkOutcome struct {
+	expiring *domain.DomainSource
+	// announce 表示本次需要发送到期提醒，未到新阈值的域名只写入到期列表。
+	announce bool
+	// alert 为需要提醒时待写入的提醒记录。
+	alert   *PendingAlert
+	failure *domain.FailureRecord
+	events  []domain.Event
 }
 
-func (c *ExpiryCheckerService) Check(ctx context.Context, domains []domain.DomainSource) ([]domain.DomainSource, []domain.FailureRecord, error) {
+func (c *ExpiryCheckerService) Check(ctx context.Context, domains []domain.DomainSource) (CheckReport, error) {
 	if c.Whois == nil {
-		return nil, nil, ErrMissingDependencies
+		return CheckReport{}, ErrMissingDependencies
 	}
 	if c.AlertWithin == 0 {
 		c.AlertWithin = 24 * time.Hour
 	}
-	var ticker *time.Ticker
-	if c.RateLimit > 0 {
-		ticker = time.NewTicker(c.RateLimit)
-		defer ticker.Stop()
+	workers := c.Workers
+	if workers < 1 {
+		workers = 1
 	}
+	limiter := c.rateLimiter()
 
+	outcomes := make([]checkOutcome, len(domains))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// 第一次查询的时间片已由 dispatch 占用，重试时再重新排队
+				dispatched := true
+				wait := func(name string) error {
+					if dispatched {
+						dispatched = false
+						return nil
+					}
+					return limiter.WaitFor(ctx, c.rateKey(name, domains[i].Source), limiter.intervalFor(suffixKey(name)))
+				}
+				outcomes[i] = c.checkOne(ctx, domains[i], wait)
+			}
+		}()
+	}
+	c.dispatch(ctx, domains, limiter, jobs)
+	close(jobs)
+	wg.Wait()
+
+	var report CheckReport
 	var expiring []domain.DomainSource
-	var failures []domain.FailureRecord
-	for i, ds := range domains {
-		if expiryStr := strings.TrimSpace(ds.Expiry); expiryStr != "" {
-			expiryTime, err := time.Parse("2006-01-02", expiryStr)
-			if err != nil {
-				failures = append(failures, domain.FailureRecord{Domain: ds.Domain, Source: ds.Source, Reason: fmt.Sprintf("解析失败: %v", err)})
-				continue
+	for _, out := range outcomes {
+		if out.expiring != nil {
+			expiring = append(expiring, *out.expiring)
+			if out.announce {
+				report.Expiring = append(report.Expiring, *out.expiring)
 			}
+		}
+		if out.alert != nil {
+			report.Alerts = append(report.Alerts, *out.alert)
+		}
+		if out.failure != nil {
+			report.Failures = append(report.Failures, *out.failure)
+		}
+		report.Events = append(report.Events, out.events...)
+	}
+	report.Window = expiring
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
 
-			if time.Until(expiryTime) <= c.AlertWithin {
-				ds.Expiry = expiryTime.Format("2006-01-02")
-				expiring = append(expiring, ds)
+	if c.State != nil {
+		if err := c.State.Save(); err != nil {
+			return report, err
+		}
+	}
+	if c.Repo != nil {
+		if err := c.Repo.SaveExpiring(expiring); err != nil {
+			return report, err
+		}
+		if err := c.Repo.SaveFailures(report.Failures); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// dispatch 按限流键排队派发任务：每轮只派发时间片已到的键，其余键继续排队，
+// 慢后缀（例如 .ph）的等待不会占住协程、拖慢其他后缀。不需要查询的域名（已有到期时间或缓存仍有效）直接派发。
+func (c *ExpiryCheckerService) dispatch(ctx context.Context, domains []domain.DomainSource, limiter *keyedLimiter, jobs chan<- int) {
+	send := func(i int) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case jobs <- i:
+			return true
+		}
+	}
+
+	type queue struct {
+		key      string
+		interval time.Duration
+		items    []int
+	}
+	var queues []*queue
+	byKey := make(map[string]*queue)
+	pending := 0
+	for i, ds := range domains {
+		name, ok := c.lookupName(ds)
+		if !ok {
+			if !send(i) {
+				return
 			}
 			continue
 		}
+		key := c.rateKey(name, ds.Source)
+		q := byKey[key]
+		if q == nil {
+			q = &queue{key: key}
+			byKey[key] = q
+			queues = append(queues, q)
+		}
+		// 同一服务器下的后缀间隔不同时取最长的
+		if d := limiter.intervalFor(suffixKey(name)); d > q.interval {
+			q.interval = d
+		}
+		q.items = append(q.items, i)
+		pending++
+	}
 
-		if i > 0 && ticker != nil {
-			select {
-			case <-ctx.Done():
-				return expiring, failures, ctx.Err()
-			case <-ticker.C:
+	for pending > 0 {
+		now := time.Now()
+		var wake time.Time
+		sent := false
+		for _, q := range queues {
+			if len(q.items) == 0 {
+				continue
+			}
+			if ready := limiter.readyAt(q.key); ready.After(now) {
+				if wake.IsZero() || ready.Before(wake) {
+					wake = ready
+				}
+				continue
+			}
+			if !send(q.items[0]) {
+				return
 			}
+			now = time.Now()
+			limiter.take(q.key, q.interval, now)
+			q.items = q.items[1:]
+			pending--
+			sent = true
 		}
+		if !sent && !wake.IsZero() {
+			if err := sleepContext(ctx, time.Until(wake)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// lookupName 返回需要联网查询时使用的可注册域名；已有到期时间、缓存仍有效或无法识别的域名返回 false。
+func (c *ExpiryCheckerService) lookupName(ds domain.DomainSource) (string, bool) {
+	if !ds.Expiry.IsZero() {
+		return "", false
+	}
+	name, err := tools.RegistrableDomain(ds.Domain)
+	if err != nil {
+		return "", false
+	}
+	if _, ok := c.cached(name); ok {
+		return "", false
+	}
+	return name, true
+}
 
-		lookupCtx := ctx
-		cancel := func() {}
-		if c.QueryTimeout > 0 {
-			lookupCtx, cancel = context.WithTimeout(ctx, c.QueryTimeout)
+// rateKey 返回按 source 的策略查询域名时的限流键，RateKey 未设置或返回空字符串时按公共后缀分组。
+func (c *ExpiryCheckerService) rateKey(name, source string) string {
+	if c.RateKey != nil {
+		if key := c.RateKey(name, source); key != "" {
+			return key
 		}
-		result, err := c.Whois.Query(lookupCtx, ds.Domain)
-		cancel()
-		if err != nil {
-			log.Printf("WHOIS 查询失败 (%s): %v", ds.Domain, err)
-			failures = append(failures, domain.FailureRecord{Domain: ds.Domain, Source: ds.Source, Reason: err.Error()})
-			continue
+	}
+	return suffixKey(name)
+}
+
+// cached 返回仍在复查周期内的缓存结果。
+func (c *ExpiryCheckerService) cached(name string) (lookup.Result, bool) {
+	if c.State == nil || c.ForceRefresh {
+		return lookup.Result{}, false
+	}
+	st, ok := c.State.Get(name)
+	if !ok || !c.Recheck.Fresh(st, c.AlertWithin, time.Now()) {
+		return lookup.Result{}, false
+	}
+	return *st.Lookup, true
+}
+
+// checkOne 检测单个域名，wait 在真正发起查询前调用以完成限流。
+// ctx 取消时返回空结果。
+func (c *ExpiryCheckerService) checkOne(ctx context.Context, ds domain.DomainSource, wait func(name string) error) checkOutcome {
+	if !ds.Expiry.IsZero() {
+		return c.expiringOutcome(tools.NormalizeDomain(ds.Domain), ds, ds.Expiry)
+	}
+
+	name, err := tools.RegistrableDomain(ds.Domain)
+	if err != nil {
+		return checkOutcome{failure: &domain.FailureRecord{Domain: ds.Domain, Source: ds.Source, Kind: domain.FailureUnsupported, Reason: err.Error()}}
+	}
+	if name != tools.NormalizeDomain(ds.Domain) {
+		log.Printf("%s 为子域名，按可注册域名 %s 查询", ds.Domain, name)
+	}
+
+	result, events, err := c.resolve(ctx, name, ds, wait)
+	if ctx.Err() != nil {
+		return checkOutcome{}
+	}
+	if err != nil {
+		var failure *lookupFailure
+		if !errors.As(err, &failure) {
+			failure = &lookupFailure{Kind: classifyError(err), Reason: err.Error()}
 		}
+		// 自有域名查询到"不存在"说明已被删除释放，作为紧急事件单独通知，不计入查询失败
+		if failure.Kind == domain.FailureNotRegistered {
+			log.Printf("域名已不再注册 (%s): %s", ds.Domain, firstLine(failure.Reason))
+			return checkOutcome{events: []domain.Event{{
+				Kind:   domain.EventDropped,
+				Domain: ds.Domain,
+				Source: ds.Source,
+				IsCF:   ds.IsCF,
+				Detail: failure.Reason,
+				At:     time.Now(),
+			}}}
+		}
+		log.Printf("WHOIS 查询失败 (%s) [%s]: %s", ds.Domain, failure.Kind, firstLine(failure.Reason))
+		return checkOutcome{failure: &domain.FailureRecord{Domain: ds.Domain, Source: ds.Source, Kind: failure.Kind, Reason: failure.Reason}}
+	}
 
-		expiry, ok := tools.ExtractExpiry(result)
-		if !ok {
-			failures = append(failures, domain.FailureRecord{Domain: ds.Domain, Source: ds.Source, Reason: truncateReason("未找到到期时间字段: " + result)})
-			continue
+	ds.Expiry = result.Expiry()
+	ds.Whois = &result
+	out := c.expiringOutcome(name, ds, result.Expiry())
+	out.events = events
+	if c.lowConfidence(result) {
+		log.Printf("到期时间置信度低 (%s): %.2f", ds.Domain, result.ExpiryConfidence)
+		out.failure = &domain.FailureRecord{Domain: ds.Domain, Source: ds.Source, Kind: domain.FailureLowConfidence, Reason: describeConfidence(result)}
+	}
+	return out
+}
+
+func (c *ExpiryCheckerService) location() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+	return c.Location
+}
+
+// lowConfidence 判断结果的到期时间是否低于 MinConfidence，未评分的结果视为可信。
+func (c *ExpiryCheckerService) lowConfidence(result lookup.Result) bool {
+	return c.MinConfidence > 0 && result.ExpiryConfidence > 0 && result.ExpiryConfidence < c.MinConfidence
+}
+
+// describeConfidence 列出选中的到期时间与其他候选值，便于人工核对。
+func describeConfidence(result lookup.Result) string {
+	reason := fmt.Sprintf("置信度 %.2f，采用 %s", result.ExpiryConfidence, result.Expiry().Format("2006-01-02"))
+	extraction, ok := lookup.ExtractExpiry(result.Raw)
+	if !ok || len(extraction.Candidates) < 2 {
+		return reason
+	}
+	var others []string
+	for _, cand := range extraction.Candidates[1:] {
+		others = append(others, fmt.Sprintf("%s=%s", cand.Field, cand.Time.Format("2006-01-02")))
+	}
+	return truncateReason(reason + "；其他候选: " + strings.Join(others, ", "))
+}
+
+// expiringOutcome 判断域名是否处于提醒窗口内，并按提醒计划决定本次是否需要提醒。
+// 需要提醒时提醒记录随结果返回，待发送成功后由 RecordAlerts 写入，避免发送失败后漏掉该阈值。
+func (c *ExpiryCheckerService) expiringOutcome(name string, ds domain.DomainSource, expiry time.Time) checkOutcome {
+	now := time.Now().In(c.location())
+	remaining := expiry.Sub(now)
+	if c.Alerts == nil {
+		if remaining > c.AlertWithin {
+			return checkOutcome{}
 		}
-		expiryTime, err := time.Parse("2006-01-02", expiry)
-		if err != nil {
-			log.Printf("解析到期时间失败 [%s]: %v", ds.Domain, err)
-			failures = append(failures, domain.FailureRecord{Domain: ds.Domain, Source: ds.Source, Reason: fmt.Sprintf("解析失败: %v", err)})
-			continue
+		return checkOutcome{expiring: &ds, announce: true}
+	}
+
+	schedule := c.Alerts.For(ds.Source)
+	if remaining >= schedule.Window() {
+		return checkOutcome{}
+	}
+	if c.State == nil {
+		return checkOutcome{expiring: &ds, announce: true}
+	}
+	var (
+		due bool
+		rec domain.AlertRecord
+	)
+	c.State.Update(name, func(st *domain.DomainState) {
+		due, rec = schedule.Due(st.Alert, expiry, tools.CountdownTo(expiry, now, c.location()).Days, now)
+		if !due {
+			st.Alert = &rec
 		}
+	})
+	if due {
+		return checkOutcome{expiring: &ds, announce: true, alert: &PendingAlert{Domain: name, Record: rec}}
+	}
+	return checkOutcome{expiring: &ds}
+}
 
-		if time.Until(expiryTime) <= c.AlertWithin {
-			ds.Expiry = expiry
-			expiring = append(expiring, ds)
+// RecordAlerts 在到期提醒发送成功后写入提醒记录。
+func (c *ExpiryCheckerService) RecordAlerts(alerts []PendingAlert) error {
+	if c.State == nil || len(alerts) == 0 {
+		return nil
+	}
+	for _, a := range alerts {
+		rec := a.Record
+		c.State.Update(a.Domain, func(st *domain.DomainState) {
+			st.Alert = &rec
+		})
+	}
+	return c.State.Save()
+}
+
+// resolve 返回带到期时间的注册信息，缓存仍在复查周期内时直接使用缓存。
+// 临时性失败按 Retries/RetryBackoff 重试，最终失败时返回 *lookupFailure。
+// 重新查询时一并返回与上次结果相比发现的事件。
+func (c *ExpiryCheckerService) resolve(ctx context.Context, name string, ds domain.DomainSource, wait func(name string) error) (lookup.Result, []domain.Event, error) {
+	if result, ok := c.cached(name); ok {
+		return result, nil, nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := wait(name); err != nil {
+			return lookup.Result{}, nil, err
+		}
+		result, err := c.query(ctx, name, ds.Source)
+		failure := classifyLookup(result, err)
+		if failure == nil {
+			if c.lowConfidence(result) {
+				// 存疑的时间不缓存，避免误判续费或在之后的运行中沿用
+				return result, nil, nil
+			}
+			events := c.remember(name, ds, result, time.Now())
+			return result, events, nil
+		}
+		if ctx.Err() != nil {
+			return lookup.Result{}, nil, ctx.Err()
+		}
+		if !failure.Kind.Transient() || attempt >= c.Retries {
+			return result, nil, failure
+		}
+
+		backoff := c.RetryBackoff << attempt
+		if failure.Kind == domain.FailureThrottled {
+			backoff *= 2
+		}
+		log.Printf("查询 %s 失败 [%s]，%v 后第 %d 次重试", name, failure.Kind, backoff, attempt+1)
+		if err := sleepContext(ctx, backoff); err != nil {
+			return lookup.Result{}, nil, err
 		}
 	}
+}
 
-	if c.Repo != nil {
-		if err := c.Repo.SaveExpiring(expiring); err != nil {
-			return expiring, failures, err
+// query 发起一次查询，WhoisClient 支持 SourceLookup 时按来源选择查询策略。
+func (c *ExpiryCheckerService) query(ctx context.Context, name, source string) (lookup.Result, error) {
+	lookupCtx := ctx
+	cancel := func() {}
+	if c.QueryTimeout > 0 {
+		lookupCtx, cancel = context.WithTimeout(ctx, c.QueryTimeout)
+	}
+	defer cancel()
+	if sl, ok := c.Whois.(SourceLookup); ok && source != "" {
+		return sl.LookupSource(lookupCtx, name, source)
+	}
+	return c.Whois.Lookup(lookupCtx, name)
+}
+
+// remember 将带到期时间的查询结果写入缓存，并与上次结果比较：
+// 到期时间变化时记入历史并返回续费或提前事件，延后时记录续费时间；EPP 状态变化时返回状态事件；
+// 注册商或转移锁变化时记入注册商历史并返回转移相关事件。事件的来源取自 ds。
+func (c *ExpiryCheckerService) remember(name string, ds domain.DomainSource, result lookup.Result, now time.Time) []domain.Event {
+	if c.State == nil || !result.HasExpiry() {
+		return nil
+	}
+	// 同一域名可能由多个来源同时检测，比较与写入在同一次 Update 中完成，避免重复事件或互相覆盖
+	var events []domain.Event
+	c.State.Update(name, func(st *domain.DomainState) {
+		events = c.compare(st, name, ds, result, now)
+	})
+	return events
+}
+
+// compare 将查询结果合并进 st 并返回发现的事件，由 remember 在 Update 中调用。
+func (c *ExpiryCheckerService) compare(st *domain.DomainState, name string, ds domain.DomainSource, result lookup.Result, now time.Time) []domain.Event {
+	st.Source = ds.Source
+	st.IsCF = ds.IsCF
+
+	var events []domain.Event
+	var previous time.Time
+	if st.Lookup != nil && st.Lookup.HasExpiry() {
+		previous = st.Lookup.Expiry()
+	}
+	if change, ok := expiryChange(previous, result.Expiry(), now); ok {
+		st.AddHistory(change)
+		switch {
+		case change.Renewed():
+			st.RenewedAt = now
+			events = append(events, domain.Event{Kind: domain.EventRenewed, Domain: name, Detail: describeExpiryChange(change), At: now})
+		case change.MovedBackwards():
+			events = append(events, domain.Event{Kind: domain.EventExpiryMovedBack, Domain: name, Detail: describeExpiryChange(change), At: now})
 		}
-		if err := c.Repo.SaveFailures(failures); err != nil {
-			return expiring, failures, err
+	}
+
+	if detail, urgent, ok := statusChange(st.Lookup, result); ok {
+		events = append(events, domain.Event{Kind: domain.EventStatusChanged, Domain: name, Detail: detail, Urgent: urgent, At: now})
+	}
+	events = append(events, transferEvents(st, st.Lookup, result, now)...)
+	for i := range events {
+		events[i].Source = ds.Source
+		events[i].IsCF = ds.IsCF
+	}
+
+	cached := result
+	cached.Raw = ""
+	st.Lookup = &cached
+	st.CheckedAt = now
+	return events
+}
+
+// Refresh 跳过缓存立即查询域名并更新缓存，供手动强制刷新使用。
+// 沿用上次检测记录的来源选择查询策略，与定时检测共用限流；与上次结果相比发现的变化以事件返回。
+func (c *ExpiryCheckerService) Refresh(ctx context.Context, name string) (lookup.Result, []domain.Event, error) {
+	if c.Whois == nil {
+		return lookup.Result{}, nil, ErrMissingDependencies
+	}
+	name, err := tools.RegistrableDomain(name)
+	if err != nil {
+		return lookup.Result{}, nil, err
+	}
+	ds := domain.DomainSource{Domain: name}
+	if c.State != nil {
+		if st, ok := c.State.Get(name); ok {
+			ds.Source, ds.IsCF = st.Source, st.IsCF
+		}
+	}
+	limiter := c.rateLimiter()
+	if err := limiter.WaitFor(ctx, c.rateKey(name, ds.Source), limiter.intervalFor(suffixKey(name))); err != nil {
+		return lookup.Result{}, nil, err
+	}
+	result, err := c.query(ctx, name, ds.Source)
+	if err != nil {
+		return lookup.Result{}, nil, err
+	}
+	events := c.remember(name, ds, result, time.Now())
+	if c.State != nil {
+		if err := c.State.Save(); err != nil {
+			return result, events, err
 		}
 	}
-	return expiring, failures, nil
+	return result, events, nil
+}
+
+// rateLimiter 返回共用的限流器，首次使用时按 RateLimit/RateLimits 创建。
+func (c *ExpiryCheckerService) rateLimiter() *keyedLimiter {
+	c.limiterOnce.Do(func() {
+		c.limiter = newKeyedLimiter(c.RateLimit, c.RateLimits)
+	})
+	return c.limiter
+}
+
+// History 返回域名的到期时间与注册商变化记录，供 /history 命令查询。
+func (c *ExpiryCheckerService) History(name string) (domain.DomainState, error) {
+	if c.State == nil {
+		return domain.DomainState{}, ErrMissingDependencies
+	}
+	name, err := tools.RegistrableDomain(name)
+	if err != nil {
+		return domain.DomainState{}, err
+	}
+	st, _ := c.State.Get(name)
+	st.Domain = name
+	return st, nil
+}
+
+// InvalidateCache 让全部缓存失效，下一次运行时重新查询所有域名。
+func (c *ExpiryCheckerService) InvalidateCache() error {
+	if c.State == nil {
+		return nil
+	}
+	for _, st := range c.State.All() {
+		c.State.Update(st.Domain, func(st *domain.DomainState) {
+			st.CheckedAt = time.Time{}
+		})
+	}
+	return c.State.Save()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
 }
 
 func truncateReason(reason string) string {
@@ -2,23 +2,36 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
+	"DomainC/config"
 	"DomainC/domain"
+	"DomainC/lookup"
+
+	"github.com/openrdap/rdap"
 )
 
 type fakeWhois struct{ result string }
 
-func (f fakeWhois) Query(ctx context.Context, domain string) (string, error) {
-	return f.result, nil
+func (f fakeWhois) Lookup(ctx context.Context, domain string) (lookup.Result, error) {
+	return lookup.ParseWhois(domain, f.result), nil
 }
 
 type countingWhois struct{ calls int }
 
-func (c *countingWhois) Query(ctx context.Context, domain string) (string, error) {
+func (c *countingWhois) Lookup(ctx context.Context, domain string) (lookup.Result, error) {
 	c.calls++
-	return "", nil
+	return lookup.Result{}, nil
 }
 
 type fakeRepo struct{ saved []domain.DomainSource }
@@ -41,7 +54,8 @@ func TestExpiryCheckerFiltersByAlertWindow(t *testing.T) {
 	}
 
 	domains := []domain.DomainSource{{Domain: "example.com", Source: "test"}}
-	got, failures, err := checker.Check(context.Background(), domains)
+	report, err := checker.Check(context.Background(), domains)
+	got, failures := report.Expiring, report.Failures
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -53,7 +67,7 @@ func TestExpiryCheckerFiltersByAlertWindow(t *testing.T) {
 	if len(got) != 1 {
 		t.Fatalf("expected 1 domain, got %d", len(got))
 	}
-	if got[0].Expiry != expiry {
+	if got[0].Expiry.Format("2006-01-02") != expiry {
 		t.Fatalf("unexpected expiry %s", got[0].Expiry)
 	}
 }
@@ -66,7 +80,8 @@ func TestExpiryCheckerReturnsFailures(t *testing.T) {
 	}
 
 	domains := []domain.DomainSource{{Domain: "nodate.com", Source: "test"}}
-	expiring, failures, err := checker.Check(context.Background(), domains)
+	report, err := checker.Check(context.Background(), domains)
+	expiring, failures := report.Expiring, report.Failures
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -81,7 +96,7 @@ func TestExpiryCheckerReturnsFailures(t *testing.T) {
 	}
 }
 func TestExpiryCheckerUsesProvidedExpiry(t *testing.T) {
-	expiry := time.Now().Add(24 * time.Hour).Format("2006-01-02")
+	expiry := time.Now().Add(24 * time.Hour)
 	repo := &fakeRepo{}
 	whois := &countingWhois{}
 	checker := &ExpiryCheckerService{
@@ -91,7 +106,8 @@ func TestExpiryCheckerUsesProvidedExpiry(t *testing.T) {
 	}
 
 	domains := []domain.DomainSource{{Domain: "prefilled.com", Source: "file", Expiry: expiry}}
-	got, failures, err := checker.Check(context.Background(), domains)
+	report, err := checker.Check(context.Background(), domains)
+	got, failures := report.Expiring, report.Failures
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -108,3 +124,775 @@ func TestExpiryCheckerUsesProvidedExpiry(t *testing.T) {
 		t.Fatalf("expected whois not to be called when expiry is provided")
 	}
 }
+
+type slowWhois struct{ expiry string }
+
+func (s slowWhois) Lookup(ctx context.Context, domain string) (lookup.Result, error) {
+	if strings.HasSuffix(domain, ".ph") {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return lookup.ParseWhois(domain, "Expiration Date: "+s.expiry), nil
+}
+
+func TestExpiryCheckerConcurrentKeepsOrder(t *testing.T) {
+	expiry := time.Now().Add(24 * time.Hour).Format("2006-01-02")
+	checker := &ExpiryCheckerService{
+		Whois:       slowWhois{expiry: expiry},
+		Repo:        &fakeRepo{},
+		AlertWithin: 48 * time.Hour,
+		Workers:     4,
+		RateLimit:   time.Millisecond,
+		RateLimits:  map[string]time.Duration{"ph": 30 * time.Millisecond},
+	}
+
+	names := []string{"a.ph", "a.com", "b.ph", "b.com", "c.com", "c.ph"}
+	var domains []domain.DomainSource
+	for _, n := range names {
+		domains = append(domains, domain.DomainSource{Domain: n, Source: "test"})
+	}
+
+	report, err := checker.Check(context.Background(), domains)
+	got, failures := report.Expiring, report.Failures
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failures) != 0 {
+		t.Fatalf("expected no failures, got %d", len(failures))
+	}
+	if len(got) != len(names) {
+		t.Fatalf("expected %d domains, got %d", len(names), len(got))
+	}
+	for i, n := range names {
+		if got[i].Domain != n {
+			t.Fatalf("expected order %v, got %s at %d", names, got[i].Domain, i)
+		}
+	}
+}
+
+func TestKeyedLimiterSeparatesKeys(t *testing.T) {
+	limiter := newKeyedLimiter(time.Hour, map[string]time.Duration{"com": 0})
+	if d := limiter.intervalFor("us.com"); d != 0 {
+		t.Fatalf("expected us.com to inherit com override, got %v", d)
+	}
+	ctx := context.Background()
+
+	if err := limiter.Wait(ctx, "ph"); err != nil {
+		t.Fatalf("first wait should not block: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, "com"); err != nil {
+			t.Fatalf("unlimited key should not block: %v", err)
+		}
+	}
+
+	cancelled, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(cancelled, "ph"); err == nil {
+		t.Fatalf("expected second wait on same key to be rate limited")
+	}
+}
+
+type expiryWhois struct {
+	mu     sync.Mutex
+	expiry time.Time
+	calls  int
+}
+
+func (e *expiryWhois) Lookup(ctx context.Context, name string) (lookup.Result, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	return lookup.Result{Domain: name, RegistryExpiry: e.expiry, Protocol: lookup.ProtocolRDAP, Raw: "raw"}, nil
+}
+
+func TestExpiryCheckerCachesFarExpiry(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	store, err := domain.NewFileStateStore(statePath)
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	whois := &expiryWhois{expiry: time.Now().Add(2 * 365 * 24 * time.Hour)}
+	checker := &ExpiryCheckerService{
+		Whois:       whois,
+		AlertWithin: 30 * 24 * time.Hour,
+		State:       store,
+		Recheck:     DefaultRecheckPolicy(),
+	}
+	domains := []domain.DomainSource{{Domain: "far.com", Source: "test"}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := checker.Check(context.Background(), domains); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if whois.calls != 1 {
+		t.Fatalf("expected cached result to be reused, got %d lookups", whois.calls)
+	}
+
+	reloaded, err := domain.NewFileStateStore(statePath)
+	if err != nil {
+		t.Fatalf("reload state: %v", err)
+	}
+	st, ok := reloaded.Get("far.com")
+	if !ok || st.Lookup == nil || st.Lookup.Raw != "" || !st.Lookup.Expiry().Equal(whois.expiry) {
+		t.Fatalf("expected persisted lookup without raw body, got %+v", st)
+	}
+	if st.Source != "test" {
+		t.Fatalf("expected source to be remembered for manual refresh, got %q", st.Source)
+	}
+
+	if _, _, err := checker.Refresh(context.Background(), "www.far.com"); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if whois.calls != 2 {
+		t.Fatalf("expected manual refresh to bypass cache, got %d lookups", whois.calls)
+	}
+}
+
+func TestExpiryCheckerRefreshReturnsEvents(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	whois := &expiryWhois{expiry: time.Now().Add(200 * 24 * time.Hour)}
+	checker := &ExpiryCheckerService{Whois: whois, AlertWithin: 30 * 24 * time.Hour, State: store, Recheck: DefaultRecheckPolicy()}
+	if _, err := checker.Check(context.Background(), []domain.DomainSource{{Domain: "renew.com", Source: "acc", IsCF: true}}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+
+	// 手动刷新发现续费时返回事件，来源沿用上次检测
+	whois.expiry = whois.expiry.AddDate(1, 0, 0)
+	_, events, err := checker.Refresh(context.Background(), "renew.com")
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if len(events) != 1 || events[0].Kind != domain.EventRenewed || events[0].Source != "acc" || !events[0].IsCF {
+		t.Fatalf("expected renewal event from refresh, got %+v", events)
+	}
+}
+
+func TestRecheckPolicyIntervals(t *testing.T) {
+	now := time.Now()
+	policy := DefaultRecheckPolicy()
+	day := 24 * time.Hour
+	stateFor := func(remaining time.Duration) domain.DomainState {
+		return domain.DomainState{Lookup: &lookup.Result{RegistryExpiry: now.Add(remaining)}, CheckedAt: now.Add(-2 * day)}
+	}
+
+	if got := policy.Interval(stateFor(400*day), 30*day, now); got != 30*day {
+		t.Errorf("expected monthly recheck for far expiry, got %v", got)
+	}
+	if got := policy.Interval(stateFor(100*day), 30*day, now); got != 7*day {
+		t.Errorf("expected weekly recheck, got %v", got)
+	}
+	if got := policy.Interval(stateFor(10*day), 30*day, now); got != 0 {
+		t.Errorf("expected every run inside alert window, got %v", got)
+	}
+
+	renewed := stateFor(400 * day)
+	renewed.RenewedAt = now.Add(-day)
+	if policy.Fresh(renewed, 30*day, now) {
+		t.Errorf("expected recently renewed domain to be rechecked")
+	}
+	if !policy.Fresh(stateFor(400*day), 30*day, now) {
+		t.Errorf("expected far expiry checked two days ago to be fresh")
+	}
+
+	unlocked := stateFor(400 * day)
+	unlocked.Lookup.Statuses = []string{"ok"}
+	unlocked.Registrars = []domain.RegistrarRecord{{Registrar: "Example", Locks: []string{"clientTransferProhibited"}}, {Registrar: "Example"}}
+	if got := policy.Interval(unlocked, 30*day, now); got != day {
+		t.Errorf("expected daily recheck after transfer lock removal, got %v", got)
+	}
+	pending := stateFor(400 * day)
+	pending.Lookup.Statuses = []string{"pendingTransfer"}
+	if got := policy.Interval(pending, 30*day, now); got != day {
+		t.Errorf("expected daily recheck while transfer is pending, got %v", got)
+	}
+}
+
+type flakyWhois struct {
+	failures int
+	calls    int
+	expiry   time.Time
+}
+
+func (f *flakyWhois) Lookup(ctx context.Context, name string) (lookup.Result, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return lookup.Result{}, fmt.Errorf("whois: connect to whois server failed: %w", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded})
+	}
+	return lookup.Result{Domain: name, RegistryExpiry: f.expiry}, nil
+}
+
+func TestExpiryCheckerRetriesTransientFailures(t *testing.T) {
+	whois := &flakyWhois{failures: 2, expiry: time.Now().Add(24 * time.Hour)}
+	checker := &ExpiryCheckerService{
+		Whois:        whois,
+		AlertWithin:  48 * time.Hour,
+		Retries:      2,
+		RetryBackoff: time.Millisecond,
+	}
+
+	report, err := checker.Check(context.Background(), []domain.DomainSource{{Domain: "flaky.com", Source: "test"}})
+	got, failures := report.Expiring, report.Failures
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failures) != 0 || len(got) != 1 {
+		t.Fatalf("expected success after retries, got %d expiring, %+v", len(got), failures)
+	}
+	if whois.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", whois.calls)
+	}
+}
+
+func TestExpiryCheckerReportsDroppedDomain(t *testing.T) {
+	checker := &ExpiryCheckerService{
+		Whois:       fakeWhois{result: "No match for \"GAMESTORE.US.COM\".\n>>> Last update of whois database: 2026-01-03T12:12:42Z <<<\n"},
+		Repo:        &fakeRepo{},
+		AlertWithin: 48 * time.Hour,
+		Retries:     3,
+	}
+
+	report, err := checker.Check(context.Background(), []domain.DomainSource{{Domain: "gamestore.us.com", Source: "test", IsCF: true}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Failures) != 0 || len(report.Expiring) != 0 {
+		t.Fatalf("expected dropped domain to be reported only as an event, got %+v", report)
+	}
+	if len(report.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(report.Events))
+	}
+	ev := report.Events[0]
+	if ev.Kind != domain.EventDropped || ev.Domain != "gamestore.us.com" || !ev.IsCF {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+	if ev.Detail != `No match for "GAMESTORE.US.COM".` {
+		t.Fatalf("expected detail to keep only the matching line, got %q", ev.Detail)
+	}
+
+	checker.Whois = errWhois{err: fmt.Errorf("WHOIS错误: %w", &lookup.NotRegisteredError{Domain: "gone.de", Line: "Status: free"})}
+	report, _ = checker.Check(context.Background(), []domain.DomainSource{{Domain: "gone.de", Source: "test"}})
+	if len(report.Events) != 1 || report.Events[0].Detail != "Status: free" {
+		t.Fatalf("expected not-registered error from lookup to become a dropped event, got %+v", report)
+	}
+}
+
+type errWhois struct{ err error }
+
+func (e errWhois) Lookup(ctx context.Context, name string) (lookup.Result, error) {
+	return lookup.Result{}, e.err
+}
+
+func TestExpiryCheckerGivesUpOnPersistentNetworkErrors(t *testing.T) {
+	flaky := &flakyWhois{failures: 10}
+	checker := &ExpiryCheckerService{Whois: flaky, Retries: 1, RetryBackoff: time.Millisecond}
+	report, _ := checker.Check(context.Background(), []domain.DomainSource{{Domain: "down.com", Source: "test"}})
+	failures := report.Failures
+	if len(failures) != 1 || failures[0].Kind != domain.FailureNetwork || flaky.calls != 2 {
+		t.Fatalf("expected network failure after 2 attempts, got %+v (%d calls)", failures, flaky.calls)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want domain.FailureKind
+	}{
+		{"dial timeout", fmt.Errorf("whois: connect to whois server failed: %w", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}), domain.FailureNetwork},
+		{"connection refused", fmt.Errorf("连接 WHOIS 服务器失败 [whois.nic.ph]: %w", syscall.ECONNREFUSED), domain.FailureNetwork},
+		{"connection reset", &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}, domain.FailureNetwork},
+		{"query timeout", fmt.Errorf("RDAP错误: %w", context.DeadlineExceeded), domain.FailureNetwork},
+		{"truncated response", fmt.Errorf("读取 WHOIS 响应失败: %w", io.ErrUnexpectedEOF), domain.FailureNetwork},
+		{"rdap no working servers", &rdap.ClientError{Type: rdap.NoWorkingServers}, domain.FailureNetwork},
+		{"rdap not found", &rdap.ClientError{Type: rdap.ObjectDoesNotExist}, domain.FailureNotRegistered},
+		{"no rdap server", fmt.Errorf("%w: example.zz", lookup.ErrNoRDAPServer), domain.FailureUnsupported},
+		{"throttled", errors.New("Query rate limit exceeded"), domain.FailureThrottled},
+		// 无法归类的错误不按网络错误重试
+		{"wrong response type", &rdap.ClientError{Type: rdap.WrongResponseType}, domain.FailureUnknown},
+		{"bad api response", errors.New("解析注册商 API 响应失败: invalid character"), domain.FailureUnknown},
+		{"no expiry", errors.New("no expiry found"), domain.FailureUnknown},
+	}
+	for _, tc := range cases {
+		if got := classifyError(tc.err); got != tc.want {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestExpiryCheckerDoesNotRetryUnknownErrors(t *testing.T) {
+	whois := &countingErrWhois{err: errors.New("no expiry found")}
+	checker := &ExpiryCheckerService{Whois: whois, Retries: 3, RetryBackoff: time.Millisecond}
+	report, _ := checker.Check(context.Background(), []domain.DomainSource{{Domain: "odd.com", Source: "test"}})
+	if len(report.Failures) != 1 || report.Failures[0].Kind != domain.FailureUnknown || whois.calls != 1 {
+		t.Fatalf("expected a single attempt for unknown errors, got %+v (%d calls)", report.Failures, whois.calls)
+	}
+}
+
+type countingErrWhois struct {
+	err   error
+	calls int
+}
+
+func (e *countingErrWhois) Lookup(ctx context.Context, name string) (lookup.Result, error) {
+	e.calls++
+	return lookup.Result{}, e.err
+}
+
+func TestAlertScheduleDue(t *testing.T) {
+	schedule := NewAlertSchedule([]int{7, 60, 30, 14, 3, 1}, 3)
+	now := time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC)
+	expiry := now.Add(20 * 24 * time.Hour)
+
+	// 首次进入窗口时只提醒一次，并把已越过的 60/30 一并记为已提醒
+	due, rec := schedule.Due(nil, expiry, 20, now)
+	if !due || !rec.Announced(60) || !rec.Announced(30) || rec.Announced(14) {
+		t.Fatalf("expected first alert to cover 60 and 30, got %v %+v", due, rec)
+	}
+	if due, _ := schedule.Due(&rec, expiry, 19, now.Add(24*time.Hour)); due {
+		t.Fatalf("expected no repeat before the next threshold")
+	}
+	due, rec = schedule.Due(&rec, expiry, 14, now.Add(6*24*time.Hour))
+	if !due || !rec.Announced(14) {
+		t.Fatalf("expected 14 day threshold to fire")
+	}
+
+	// 最后几天每天提醒一次
+	day := now.Add(17 * 24 * time.Hour)
+	due, rec = schedule.Due(&rec, expiry, 3, day)
+	if !due {
+		t.Fatalf("expected 3 day threshold to fire")
+	}
+	if due, _ := schedule.Due(&rec, expiry, 2, day.Add(time.Hour)); due {
+		t.Fatalf("expected only one reminder per day")
+	}
+	if due, _ := schedule.Due(&rec, expiry, 2, day.Add(24*time.Hour)); !due {
+		t.Fatalf("expected daily reminder in the final stretch")
+	}
+
+	// 续费后到期时间变化，重新开始计算
+	renewed := expiry.AddDate(1, 0, 0)
+	if due, rec := schedule.Due(&rec, renewed, 40, day); !due || rec.Announced(14) {
+		t.Fatalf("expected record to reset after renewal, got %+v", rec)
+	}
+}
+
+func TestAlertPolicyFromConfig(t *testing.T) {
+	one := 1
+	policy := AlertPolicyFromConfig(config.Alerts{
+		Overrides: map[string]config.AlertSchedule{
+			"acc": {Thresholds: []int{90, 30}},
+			"vip": {DailyWithin: &one},
+		},
+	}, 30)
+
+	if got := policy.Default.Thresholds; len(got) != 5 || got[0] != 30 {
+		t.Fatalf("expected defaults capped by alertDays, got %v", got)
+	}
+	if s := policy.For("acc"); len(s.Thresholds) != 2 || s.DailyWithin != defaultDailyWithin {
+		t.Fatalf("unexpected override for acc: %+v", s)
+	}
+	if s := policy.For("vip"); s.DailyWithin != 1 || len(s.Thresholds) != 5 {
+		t.Fatalf("unexpected override for vip: %+v", s)
+	}
+	if w := policy.Window(); w != 91*24*time.Hour {
+		t.Fatalf("expected window to cover the largest override, got %v", w)
+	}
+}
+
+func TestExpiryCheckerAnnouncesEachThresholdOnce(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	whois := &expiryWhois{expiry: time.Now().Add(20*24*time.Hour + time.Hour)}
+	policy := AlertPolicy{Default: NewAlertSchedule([]int{30, 7}, 0)}
+	repo := &fakeRepo{}
+	checker := &ExpiryCheckerService{
+		Whois:       whois,
+		Repo:        repo,
+		AlertWithin: policy.Window(),
+		Alerts:      &policy,
+		State:       store,
+		Recheck:     DefaultRecheckPolicy(),
+	}
+	domains := []domain.DomainSource{{Domain: "example.com", Source: "test"}}
+
+	report, err := checker.Check(context.Background(), domains)
+	if err != nil || len(report.Expiring) != 1 || len(report.Alerts) != 1 {
+		t.Fatalf("expected first run to announce, got %+v (%v)", report, err)
+	}
+	// 提醒未送达时不记录，下次运行重新提醒
+	report, err = checker.Check(context.Background(), domains)
+	if err != nil || len(report.Expiring) != 1 {
+		t.Fatalf("expected undelivered alert to be announced again, got %+v (%v)", report, err)
+	}
+	if err := checker.RecordAlerts(report.Alerts); err != nil {
+		t.Fatalf("RecordAlerts: %v", err)
+	}
+	report, err = checker.Check(context.Background(), domains)
+	if err != nil || len(report.Expiring) != 0 || len(report.Window) != 1 {
+		t.Fatalf("expected third run to stay quiet, got %+v (%v)", report, err)
+	}
+	// 不提醒时仍写入到期列表
+	if len(repo.saved) != 3 {
+		t.Fatalf("expected domain to be saved on every run, got %d", len(repo.saved))
+	}
+}
+
+// barrierWhois 等到 n 个查询同时到达后才一起返回，让同名域名的结果尽量同时写入状态。
+type barrierWhois struct {
+	expiry  time.Time
+	arrived sync.WaitGroup
+}
+
+func newBarrierWhois(expiry time.Time, n int) *barrierWhois {
+	w := &barrierWhois{expiry: expiry}
+	w.arrived.Add(n)
+	return w
+}
+
+func (w *barrierWhois) Lookup(ctx context.Context, name string) (lookup.Result, error) {
+	w.arrived.Done()
+	w.arrived.Wait()
+	return lookup.Result{Domain: name, RegistryExpiry: w.expiry, Protocol: lookup.ProtocolRDAP}, nil
+}
+
+// slowStateStore 在 Get 返回前稍作等待，放大 Get 与 Put 之间的并发窗口。
+type slowStateStore struct{ domain.StateStore }
+
+func (s slowStateStore) Get(name string) (domain.DomainState, bool) {
+	st, ok := s.StateStore.Get(name)
+	time.Sleep(10 * time.Millisecond)
+	return st, ok
+}
+
+func TestExpiryCheckerSharedNameKeepsState(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	now := time.Now()
+	renewed := now.Add(20*24*time.Hour + time.Hour).Truncate(time.Second)
+	old := now.Add(5 * 24 * time.Hour).Truncate(time.Second)
+	// 新到期时间的 30 天阈值已提醒过，本次不提醒但会写入提醒记录，同时续费写入历史
+	store.Put(domain.DomainState{
+		Domain: "example.com",
+		Lookup: &lookup.Result{Domain: "example.com", RegistryExpiry: old},
+		Alert:  &domain.AlertRecord{Expiry: renewed, Thresholds: []int{30}, LastSent: now.Add(-48 * time.Hour)},
+	})
+	policy := AlertPolicy{Default: NewAlertSchedule([]int{30, 7}, 0)}
+	checker := &ExpiryCheckerService{
+		Whois:       newBarrierWhois(renewed, 3),
+		AlertWithin: policy.Window(),
+		Alerts:      &policy,
+		State:       slowStateStore{store},
+		Recheck:     DefaultRecheckPolicy(),
+		Workers:     4,
+	}
+	// 同一可注册域名出现在多个来源中，由不同协程同时检测
+	domains := []domain.DomainSource{
+		{Domain: "example.com", Source: "a", IsCF: true},
+		{Domain: "www.example.com", Source: "b"},
+		{Domain: "example.com", Source: "c"},
+	}
+
+	report, err := checker.Check(context.Background(), domains)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	renewals := 0
+	for _, ev := range report.Events {
+		if ev.Kind == domain.EventRenewed {
+			renewals++
+		}
+	}
+	if renewals != 1 {
+		t.Fatalf("expected a single renewal event, got %+v", report.Events)
+	}
+	st, ok := store.Get("example.com")
+	if !ok || len(st.History) != 1 {
+		t.Fatalf("expected renewal history to survive, got %+v", st)
+	}
+	if st.Alert == nil || !st.Alert.Expiry.Equal(renewed) || !st.Alert.Announced(30) {
+		t.Fatalf("expected alert state to survive, got %+v", st.Alert)
+	}
+	if st.Lookup == nil || !st.Lookup.Expiry().Equal(renewed) || st.CheckedAt.IsZero() {
+		t.Fatalf("expected cached lookup to survive, got %+v", st)
+	}
+}
+
+func TestExpiryCheckerRecordsExpiryHistory(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	first := time.Now().Add(100 * 24 * time.Hour).Truncate(time.Second)
+	whois := &expiryWhois{expiry: first}
+	checker := &ExpiryCheckerService{Whois: whois, AlertWithin: time.Hour, State: store, ForceRefresh: true}
+	domains := []domain.DomainSource{{Domain: "example.com", Source: "acc", IsCF: true}}
+
+	report, _ := checker.Check(context.Background(), domains)
+	if len(report.Events) != 0 {
+		t.Fatalf("expected no events on first lookup, got %+v", report.Events)
+	}
+
+	// 同一天内的时间差异不算变化
+	whois.expiry = first.Add(time.Hour)
+	if report, _ = checker.Check(context.Background(), domains); len(report.Events) != 0 {
+		t.Fatalf("expected small drift to be ignored, got %+v", report.Events)
+	}
+
+	whois.expiry = first.AddDate(1, 0, 0)
+	report, _ = checker.Check(context.Background(), domains)
+	if len(report.Events) != 1 || report.Events[0].Kind != domain.EventRenewed {
+		t.Fatalf("expected renewed event, got %+v", report.Events)
+	}
+	if ev := report.Events[0]; ev.Source != "acc" || !ev.IsCF || !strings.Contains(ev.Detail, "+1 年") {
+		t.Fatalf("unexpected renewed event %+v", ev)
+	}
+
+	whois.expiry = first.AddDate(0, -1, 0)
+	report, _ = checker.Check(context.Background(), domains)
+	if len(report.Events) != 1 || report.Events[0].Kind != domain.EventExpiryMovedBack {
+		t.Fatalf("expected moved-back event, got %+v", report.Events)
+	}
+
+	st, err := checker.History("www.example.com")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	history := st.History
+	if len(history) != 3 || !history[0].From.IsZero() || !history[1].Renewed() || !history[2].MovedBackwards() {
+		t.Fatalf("unexpected history %+v", history)
+	}
+}
+
+type statusWhois struct {
+	expiry   time.Time
+	statuses []string
+}
+
+func (s *statusWhois) Lookup(ctx context.Context, name string) (lookup.Result, error) {
+	return lookup.Result{Domain: name, RegistryExpiry: s.expiry, Statuses: s.statuses}, nil
+}
+
+func TestExpiryCheckerReportsStatusChanges(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	whois := &statusWhois{expiry: time.Now().Add(200 * 24 * time.Hour), statuses: []string{"clientTransferProhibited"}}
+	checker := &ExpiryCheckerService{Whois: whois, AlertWithin: time.Hour, State: store, ForceRefresh: true}
+	domains := []domain.DomainSource{{Domain: "example.com", Source: "acc"}}
+
+	// 首次查询只报告严重状态
+	if report, _ := checker.Check(context.Background(), domains); len(report.Events) != 0 {
+		t.Fatalf("expected no events for benign statuses, got %+v", report.Events)
+	}
+
+	whois.statuses = []string{"clientTransferProhibited", "clientHold"}
+	report, _ := checker.Check(context.Background(), domains)
+	if len(report.Events) != 1 || report.Events[0].Kind != domain.EventStatusChanged || !report.Events[0].Urgent {
+		t.Fatalf("expected urgent status event, got %+v", report.Events)
+	}
+	if !strings.Contains(report.Events[0].Detail, "新增 clientHold") {
+		t.Fatalf("expected explanation of clientHold, got %q", report.Events[0].Detail)
+	}
+
+	// 严重状态存在时每天复查
+	st, _ := store.Get("example.com")
+	if got := DefaultRecheckPolicy().Interval(st, time.Hour, time.Now()); got != 24*time.Hour {
+		t.Fatalf("expected daily recheck while on hold, got %v", got)
+	}
+
+	whois.statuses = []string{"clientTransferProhibited"}
+	report, _ = checker.Check(context.Background(), domains)
+	if len(report.Events) != 1 || report.Events[0].Urgent || !strings.Contains(report.Events[0].Detail, "解除 clientHold") {
+		t.Fatalf("expected non-urgent event for lifted hold, got %+v", report.Events)
+	}
+}
+
+type registrarWhois struct {
+	expiry time.Time
+	result lookup.Result
+}
+
+func (r *registrarWhois) Lookup(ctx context.Context, name string) (lookup.Result, error) {
+	res := r.result
+	res.Domain = name
+	res.RegistryExpiry = r.expiry
+	return res, nil
+}
+
+func TestExpiryCheckerMonitorsTransfers(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	whois := &registrarWhois{
+		expiry: time.Now().Add(200 * 24 * time.Hour),
+		result: lookup.Result{Registrar: "Example Registrar", RegistrarIANAID: "292", Statuses: []string{"clientTransferProhibited", "clientDeleteProhibited"}},
+	}
+	checker := &ExpiryCheckerService{Whois: whois, AlertWithin: time.Hour, State: store, ForceRefresh: true}
+	domains := []domain.DomainSource{{Domain: "example.com", Source: "acc"}}
+	kinds := func(report CheckReport) []domain.EventKind {
+		var out []domain.EventKind
+		for _, ev := range report.Events {
+			out = append(out, ev.Kind)
+		}
+		return out
+	}
+
+	if report, _ := checker.Check(context.Background(), domains); len(report.Events) != 0 {
+		t.Fatalf("expected no events on first lookup, got %v", kinds(report))
+	}
+
+	// 名称写法不同但 IANA ID 相同，不算变更
+	whois.result.Registrar = "EXAMPLE REGISTRAR, INC."
+	if report, _ := checker.Check(context.Background(), domains); len(report.Events) != 0 {
+		t.Fatalf("expected same IANA ID to be treated as unchanged, got %v", kinds(report))
+	}
+
+	whois.result.Statuses = []string{"clientDeleteProhibited", "pendingTransfer"}
+	report, _ := checker.Check(context.Background(), domains)
+	if got := kinds(report); len(got) != 2 || got[0] != domain.EventTransferPending || got[1] != domain.EventTransferLockRemoved {
+		t.Fatalf("expected pending transfer and lock removed events only, got %v", got)
+	}
+
+	whois.result = lookup.Result{Registrar: "Other Registrar", RegistrarIANAID: "1068", Statuses: []string{"clientTransferProhibited", "clientDeleteProhibited"}}
+	report, _ = checker.Check(context.Background(), domains)
+	if got := kinds(report); len(got) != 1 || got[0] != domain.EventRegistrarChanged || !report.Events[0].Urgent {
+		t.Fatalf("expected registrar changed event, got %v", got)
+	}
+	if !strings.Contains(report.Events[0].Detail, "(IANA 292) → Other Registrar (IANA 1068)") {
+		t.Fatalf("unexpected detail %q", report.Events[0].Detail)
+	}
+
+	st, _ := checker.History("example.com")
+	if len(st.Registrars) != 3 {
+		t.Fatalf("expected 3 registrar records, got %+v", st.Registrars)
+	}
+}
+
+type sourceWhois struct{ sources map[string]string }
+
+func (s *sourceWhois) Lookup(ctx context.Context, domain string) (lookup.Result, error) {
+	return s.LookupSource(ctx, domain, "")
+}
+
+func (s *sourceWhois) LookupSource(ctx context.Context, domain, source string) (lookup.Result, error) {
+	s.sources[domain] = source
+	return lookup.Result{Domain: domain, RegistryExpiry: time.Now().AddDate(1, 0, 0)}, nil
+}
+
+func TestExpiryCheckerPassesSourceToLookup(t *testing.T) {
+	whois := &sourceWhois{sources: make(map[string]string)}
+	checker := &ExpiryCheckerService{Whois: whois, Repo: &fakeRepo{}, AlertWithin: 48 * time.Hour}
+
+	domains := []domain.DomainSource{
+		{Domain: "a.com", Source: "godaddy"},
+		{Domain: "b.com", Source: "cf-main", IsCF: true},
+	}
+	if _, err := checker.Check(context.Background(), domains); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if whois.sources["a.com"] != "godaddy" || whois.sources["b.com"] != "cf-main" {
+		t.Fatalf("unexpected sources %v", whois.sources)
+	}
+}
+
+func TestExpiryCheckerFlagsLowConfidenceExpiry(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	soon := time.Now().Add(5 * 24 * time.Hour).Format("2006-01-02")
+	later := time.Now().AddDate(2, 0, 0).Format("2006-01-02")
+	checker := &ExpiryCheckerService{
+		Whois:         fakeWhois{result: "Expiry date: " + soon + "\nExpiration Date: " + later},
+		Repo:          &fakeRepo{},
+		AlertWithin:   30 * 24 * time.Hour,
+		State:         store,
+		Recheck:       DefaultRecheckPolicy(),
+		MinConfidence: 0.5,
+	}
+
+	report, err := checker.Check(context.Background(), []domain.DomainSource{{Domain: "doubt.com", Source: "test"}})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(report.Expiring) != 1 || report.Expiring[0].Expiry.Format("2006-01-02") != soon {
+		t.Fatalf("expected alert on the best candidate, got %+v", report.Expiring)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].Kind != domain.FailureLowConfidence || !strings.Contains(report.Failures[0].Reason, later) {
+		t.Fatalf("expected low-confidence failure listing the other candidate, got %+v", report.Failures)
+	}
+	if st, ok := store.Get("doubt.com"); ok && st.Lookup != nil {
+		t.Fatalf("low-confidence result should not be cached: %+v", st.Lookup)
+	}
+}
+
+// timedWhois 记录每次查询的时间。
+type timedWhois struct {
+	mu    sync.Mutex
+	start time.Time
+	calls map[string]time.Duration
+}
+
+func (w *timedWhois) Lookup(ctx context.Context, name string) (lookup.Result, error) {
+	w.mu.Lock()
+	w.calls[name] = time.Since(w.start)
+	w.mu.Unlock()
+	return lookup.Result{Domain: name, RegistryExpiry: time.Now().Add(24 * time.Hour)}, nil
+}
+
+func TestExpiryCheckerSlowSuffixDoesNotBlockOthers(t *testing.T) {
+	whois := &timedWhois{start: time.Now(), calls: make(map[string]time.Duration)}
+	checker := &ExpiryCheckerService{
+		Whois:       whois,
+		AlertWithin: 48 * time.Hour,
+		Workers:     2,
+		RateLimit:   time.Millisecond,
+		RateLimits:  map[string]time.Duration{"ph": 100 * time.Millisecond},
+	}
+
+	names := []string{"a.ph", "b.ph", "c.ph", "d.ph", "a.com", "b.com", "c.com"}
+	var domains []domain.DomainSource
+	for _, n := range names {
+		domains = append(domains, domain.DomainSource{Domain: n, Source: "test"})
+	}
+	if _, err := checker.Check(context.Background(), domains); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, n := range []string{"a.com", "b.com", "c.com"} {
+		if d := whois.calls[n]; d > 80*time.Millisecond {
+			t.Fatalf("%s waited %v behind the .ph queue", n, d)
+		}
+	}
+	if d := whois.calls["d.ph"]; d < 250*time.Millisecond {
+		t.Fatalf("expected .ph lookups to stay rate limited, d.ph ran after %v", d)
+	}
+}
+
+func TestExpiryCheckerRateKeyGroupsByServer(t *testing.T) {
+	whois := &timedWhois{start: time.Now(), calls: make(map[string]time.Duration)}
+	checker := &ExpiryCheckerService{
+		Whois:       whois,
+		AlertWithin: 48 * time.Hour,
+		Workers:     2,
+		RateLimit:   100 * time.Millisecond,
+		RateKey:     func(string, string) string { return "whois:shared" },
+	}
+	domains := []domain.DomainSource{{Domain: "a.com", Source: "test"}, {Domain: "b.net", Source: "test"}}
+	if _, err := checker.Check(context.Background(), domains); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := whois.calls["b.net"]; d < 80*time.Millisecond {
+		t.Fatalf("expected domains on the same server to share a limit, b.net ran after %v", d)
+	}
+}
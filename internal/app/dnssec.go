@@ -0,0 +1,166 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/dnsquery"
+	"DomainC/domain"
+	"DomainC/tools"
+)
+
+// DSResolver 实时查询域名在父区的 DS 记录。
+type DSResolver interface {
+	LookupDS(ctx context.Context, name string) ([]dnsquery.DSRecord, error)
+}
+
+// DNSSECProbe 对比注册局的 DS 委派与 CF Zone 的 DNSSEC 状态。
+// 委派信息默认取自最近一次 RDAP/WHOIS 结果（State）；没有记录、记录显示未签名或 UseDNS 为 true 时
+// 通过 Resolver 查询 DS，避免漏掉注册局残留 DS 而 CF 已关闭 DNSSEC 这种会导致解析完全失败的情况。
+type DNSSECProbe struct {
+	CF       cfclient.Client
+	State    domain.StateStore
+	Resolver DSResolver
+	UseDNS   bool
+}
+
+func (p *DNSSECProbe) Name() string { return "DNSSEC 检查" }
+
+func (p *DNSSECProbe) Run(ctx context.Context, domains []domain.DomainSource) ([]domain.Event, error) {
+	var events []domain.Event
+	for _, ds := range domains {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		// 未激活的 Zone 不由 CF 应答，DS 与其签名状态无关
+		if !ds.IsCF || !strings.EqualFold(ds.Status, "active") {
+			continue
+		}
+		account := cfclient.GetAccountByLabel(ds.Source)
+		if account == nil {
+			log.Printf("未找到账号: %s", ds.Source)
+			continue
+		}
+
+		check, err := p.CheckDNSSEC(ctx, *account, ds.Domain)
+		if err != nil {
+			log.Printf("检查 %s 的 DNSSEC 失败: %v", ds.Domain, err)
+			continue
+		}
+		if check.Consistent() {
+			continue
+		}
+		events = append(events, domain.Event{
+			Kind:   domain.EventDNSSECMismatch,
+			Domain: ds.Domain,
+			Source: ds.Source,
+			IsCF:   true,
+			Detail: check.Problem,
+			Urgent: check.Urgent,
+			At:     time.Now(),
+		})
+	}
+	return events, nil
+}
+
+// CheckDNSSEC 查询单个 Zone 的两边状态并给出对比结果，供每日任务与 /dnssec 命令使用。
+func (p *DNSSECProbe) CheckDNSSEC(ctx context.Context, account config.CF, zone string) (domain.DNSSECCheck, error) {
+	if p.CF == nil {
+		return domain.DNSSECCheck{}, ErrMissingDependencies
+	}
+	status, err := p.CF.GetDNSSEC(ctx, account, zone)
+	if err != nil {
+		return domain.DNSSECCheck{}, err
+	}
+	check := domain.DNSSECCheck{Domain: zone, CFStatus: status.Status, CFKeyTag: status.KeyTag, CFDS: status.DS}
+
+	if err := p.registry(ctx, &check); err != nil {
+		return check, err
+	}
+	check.Problem, check.Urgent = compareDNSSEC(check)
+	return check, nil
+}
+
+// registry 填写注册局侧的 DS 委派信息。
+func (p *DNSSECProbe) registry(ctx context.Context, check *domain.DNSSECCheck) error {
+	if !p.UseDNS && p.State != nil {
+		name, err := tools.RegistrableDomain(check.Domain)
+		if err != nil {
+			return err
+		}
+		if st, ok := p.State.Get(name); ok && st.Lookup != nil {
+			check.RegistrySigned = st.Lookup.DNSSEC
+			check.RegistryKeyTags = st.Lookup.DSKeyTags
+			check.RegistryFrom = strings.ToUpper(string(st.Lookup.Protocol))
+			// WHOIS 中没有 DNSSEC 字段时与未签名无法区分，未签名时再用 DNS 确认
+			if check.RegistrySigned || p.Resolver == nil {
+				return nil
+			}
+		}
+	}
+	if p.Resolver == nil {
+		return nil
+	}
+	records, err := p.Resolver.LookupDS(ctx, check.Domain)
+	if err != nil {
+		return fmt.Errorf("查询 DS 记录失败: %w", err)
+	}
+	check.RegistrySigned = len(records) > 0
+	check.RegistryFrom = "DNS"
+	check.RegistryKeyTags = nil
+	for _, r := range records {
+		check.RegistryKeyTags = append(check.RegistryKeyTags, int(r.KeyTag))
+	}
+	return nil
+}
+
+// compareDNSSEC 返回不一致说明以及是否会导致解析中断。
+func compareDNSSEC(c domain.DNSSECCheck) (string, bool) {
+	cfSigning := c.CFStatus == "active" || c.CFStatus == "pending"
+	switch {
+	case c.RegistrySigned && c.CFStatus == "pending-disabled":
+		// CF 在注册局 DS 删除之前会继续签名，解析不受影响
+		return fmt.Sprintf(
+			"CF 正在关闭 DNSSEC，注册局仍有 DS 记录 (key tag %s，来源 %s)。\n请在注册商处删除 DS，删除生效后 CF 才会停止签名。",
+			joinKeyTags(c.RegistryKeyTags), c.RegistryFrom,
+		), false
+	case c.RegistrySigned && !cfSigning:
+		return fmt.Sprintf(
+			"注册局存在 DS 记录 (key tag %s，来源 %s)，但 CF 的 DNSSEC 状态为 %s。\n开启 DNSSEC 校验的解析器会对该域名返回 SERVFAIL，请在注册商处删除 DS 或在 CF 重新启用 DNSSEC。",
+			joinKeyTags(c.RegistryKeyTags), c.RegistryFrom, orDash(c.CFStatus),
+		), true
+	case c.RegistrySigned && c.CFKeyTag != 0 && len(c.RegistryKeyTags) > 0 && !containsKeyTag(c.RegistryKeyTags, c.CFKeyTag):
+		return fmt.Sprintf(
+			"注册局 DS 的 key tag (%s) 与 CF 的密钥 (%d) 不匹配，DNSSEC 校验将失败。\n请在注册商处将 DS 更新为: %s",
+			joinKeyTags(c.RegistryKeyTags), c.CFKeyTag, orDash(c.CFDS),
+		), true
+	case !c.RegistrySigned && c.CFStatus == "active":
+		return fmt.Sprintf("CF 已启用 DNSSEC，但注册局没有 DS 记录，DNSSEC 未生效。\n请在注册商处添加 DS: %s", orDash(c.CFDS)), false
+	}
+	return "", false
+}
+
+func containsKeyTag(tags []int, tag int) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func joinKeyTags(tags []int) string {
+	if len(tags) == 0 {
+		return "未知"
+	}
+	parts := make([]string, len(tags))
+	for i, t := range tags {
+		parts[i] = fmt.Sprint(t)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/dnsquery"
+	"DomainC/dnsquery/dnstest"
+	"DomainC/domain"
+	"DomainC/lookup"
+)
+
+type dnssecCF struct {
+	fakeCF
+	statuses map[string]cfclient.DNSSECStatus
+}
+
+func (f *dnssecCF) GetDNSSEC(ctx context.Context, account config.CF, zone string) (cfclient.DNSSECStatus, error) {
+	return f.statuses[zone], nil
+}
+
+func TestDNSSECProbeDetectsMismatch(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	store.Put(domain.DomainState{Domain: "stale.com", Lookup: &lookup.Result{Protocol: lookup.ProtocolRDAP, DNSSEC: true, DSKeyTags: []int{2371}}})
+	store.Put(domain.DomainState{Domain: "ok.com", Lookup: &lookup.Result{Protocol: lookup.ProtocolRDAP, DNSSEC: true, DSKeyTags: []int{2371}}})
+	store.Put(domain.DomainState{Domain: "rotated.com", Lookup: &lookup.Result{Protocol: lookup.ProtocolRDAP, DNSSEC: true, DSKeyTags: []int{1111}}})
+	store.Put(domain.DomainState{Domain: "whois.com", Lookup: &lookup.Result{Protocol: lookup.ProtocolWHOIS}})
+	store.Put(domain.DomainState{Domain: "disabling.com", Lookup: &lookup.Result{Protocol: lookup.ProtocolRDAP, DNSSEC: true, DSKeyTags: []int{2371}}})
+	store.Put(domain.DomainState{Domain: "pending.com", Lookup: &lookup.Result{Protocol: lookup.ProtocolRDAP, DNSSEC: true, DSKeyTags: []int{2371}}})
+
+	srv, err := dnstest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	// WHOIS 没有 DNSSEC 信息，DNS 中却有残留 DS
+	srv.SetDS("whois.com", 4242, 13, 2, "aabbcc")
+	srv.Set("missing-ds.com", dnsquery.TypeDS, dnstest.Answer{})
+
+	cf := &dnssecCF{statuses: map[string]cfclient.DNSSECStatus{
+		"stale.com":      {Status: "disabled"},
+		"ok.com":         {Status: "active", KeyTag: 2371},
+		"rotated.com":    {Status: "active", KeyTag: 2371, DS: "rotated.com. 3600 IN DS 2371 13 2 ABCD"},
+		"whois.com":      {Status: "disabled"},
+		"missing-ds.com": {Status: "active", KeyTag: 2371, DS: "missing-ds.com. 3600 IN DS 2371 13 2 ABCD"},
+		"disabling.com":  {Status: "pending-disabled", KeyTag: 2371},
+		"pending.com":    {Status: "disabled"},
+	}}
+	config.Cfg.CloudflareAccounts = []config.CF{{Label: "acc"}}
+
+	var domains []domain.DomainSource
+	for _, name := range []string{"stale.com", "ok.com", "rotated.com", "whois.com", "missing-ds.com", "disabling.com"} {
+		domains = append(domains, domain.DomainSource{Domain: name, Source: "acc", IsCF: true, Status: "active"})
+	}
+	// 未激活的 Zone 不检查
+	domains = append(domains, domain.DomainSource{Domain: "pending.com", Source: "acc", IsCF: true, Status: "pending"})
+	domains = append(domains, domain.DomainSource{Domain: "file.com", Source: "domains.txt"})
+
+	probe := &DNSSECProbe{CF: cf, State: store, Resolver: dnsquery.NewClient(srv.Addr, time.Second)}
+	events, err := probe.Run(context.Background(), domains)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := make(map[string]domain.Event)
+	for _, ev := range events {
+		if ev.Kind != domain.EventDNSSECMismatch {
+			t.Fatalf("unexpected event kind %+v", ev)
+		}
+		got[ev.Domain] = ev
+	}
+	if len(got) != 5 {
+		t.Fatalf("expected 5 mismatches, got %+v", events)
+	}
+	if ev := got["stale.com"]; !ev.Urgent || !strings.Contains(ev.Detail, "SERVFAIL") {
+		t.Fatalf("stale DS with DNSSEC disabled should be urgent, got %+v", ev)
+	}
+	if ev := got["rotated.com"]; !ev.Urgent || !strings.Contains(ev.Detail, "1111") {
+		t.Fatalf("key tag mismatch should be urgent, got %+v", ev)
+	}
+	if ev := got["whois.com"]; !ev.Urgent || !strings.Contains(ev.Detail, "来源 DNS") {
+		t.Fatalf("expected DNS confirmation of stale DS, got %+v", ev)
+	}
+	if ev := got["missing-ds.com"]; ev.Urgent || !strings.Contains(ev.Detail, "请在注册商处添加 DS") {
+		t.Fatalf("missing DS should be a non-urgent reminder, got %+v", ev)
+	}
+	if ev := got["disabling.com"]; ev.Urgent || !strings.Contains(ev.Detail, "请在注册商处删除 DS") {
+		t.Fatalf("pending-disabled should be a non-urgent removal reminder, got %+v", ev)
+	}
+}
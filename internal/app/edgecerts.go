@@ -0,0 +1,237 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/domain"
+	"DomainC/tools"
+)
+
+// defaultPendingAfter 为证书停留在待验证/待签发状态多久后提醒。
+const defaultPendingAfter = 24 * time.Hour
+
+// edgePendingStatuses 为签发流程中的正常中间状态，停留过久才算异常。
+var edgePendingStatuses = map[string]bool{
+	"initializing":       true,
+	"pending_validation": true,
+	"pending_issuance":   true,
+	"pending_deployment": true,
+}
+
+// EdgeCertProbe 检查已代理 Zone 的 CF 边缘证书：证书包与自定义主机名的证书。
+// 长时间停留在 pending_validation 等状态、签发超时，或临近到期仍未续期时返回事件。
+// CF 会在到期前约 30 天自动续期，Within 应小于该值，进入窗口即说明续期失败。
+// 首次观察到待处理状态的时间保存在 State 中，重启后继续计时。
+type EdgeCertProbe struct {
+	CF           cfclient.Client
+	State        domain.StateStore
+	Within       time.Duration
+	PendingAfter time.Duration
+	Location     *time.Location
+}
+
+// edgeCert 统一证书包与自定义主机名证书，便于按同一规则判断。
+type edgeCert struct {
+	Kind             string
+	ID               string
+	Hosts            []string
+	Status           string
+	Issuer           string
+	ExpiresOn        time.Time
+	Validation       []string
+	ValidationErrors []string
+}
+
+func (p *EdgeCertProbe) Name() string { return "边缘证书检查" }
+
+func (p *EdgeCertProbe) Run(ctx context.Context, domains []domain.DomainSource) ([]domain.Event, error) {
+	if p.CF == nil || p.State == nil {
+		return nil, ErrMissingDependencies
+	}
+	now := time.Now()
+	dirty := false
+	var events []domain.Event
+	for _, ds := range domains {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		// 未接入（NS 未切换）或已暂停的 Zone 证书不会签发，跳过以免每天重复提醒
+		if !ds.IsCF || !strings.EqualFold(ds.Status, "active") || ds.Paused {
+			continue
+		}
+		certs, err := p.zoneCerts(ctx, ds)
+		if err != nil {
+			// 查询失败时不知道证书的当前状态，保留已有的待处理记录，避免重新计时
+			log.Printf("边缘证书检查跳过 %s: %v", ds.Domain, err)
+			continue
+		}
+		st, _ := p.State.Get(ds.Domain)
+		// 只保留本次仍处于待处理状态的证书，签发完成后重新计时
+		pending := make(map[string]time.Time)
+		for _, cert := range certs {
+			if ev, ok := p.evaluate(ds, cert, now, st.EdgePending, pending); ok {
+				events = append(events, ev)
+			}
+		}
+		if pendingChanged(st.EdgePending, pending) {
+			p.State.Update(ds.Domain, func(st *domain.DomainState) {
+				st.EdgePending = nil
+				if len(pending) > 0 {
+					st.EdgePending = pending
+				}
+			})
+			dirty = true
+		}
+	}
+	if dirty {
+		if err := p.State.Save(); err != nil {
+			return events, err
+		}
+	}
+	return events, nil
+}
+
+// zoneCerts 获取 Zone 的证书包与自定义主机名，证书包获取失败时返回错误；
+// 未开通 SSL for SaaS 的 Zone 查询自定义主机名会失败，只记录日志。
+func (p *EdgeCertProbe) zoneCerts(ctx context.Context, ds domain.DomainSource) ([]edgeCert, error) {
+	account := cfclient.GetAccountByLabel(ds.Source)
+	if account == nil {
+		return nil, fmt.Errorf("未找到账号: %s", ds.Source)
+	}
+
+	var certs []edgeCert
+	packs, err := p.CF.ListCertificatePacks(ctx, *account, ds.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("获取证书包失败: %w", err)
+	}
+	for _, pack := range packs {
+		certs = append(certs, edgeCert{
+			Kind:             "证书包 (" + pack.Type + ")",
+			ID:               pack.ID,
+			Hosts:            pack.Hosts,
+			Status:           pack.Status,
+			Issuer:           pack.CertificateAuthority,
+			ExpiresOn:        pack.ExpiresOn,
+			Validation:       pack.Validation,
+			ValidationErrors: pack.ValidationErrors,
+		})
+	}
+
+	hostnames, err := p.CF.ListCustomHostnames(ctx, *account, ds.Domain)
+	if err != nil {
+		log.Printf("获取 %s 的自定义主机名失败: %v", ds.Domain, err)
+	}
+	for _, h := range hostnames {
+		certs = append(certs, edgeCert{
+			Kind:             "自定义主机名",
+			ID:               h.ID,
+			Hosts:            []string{h.Hostname},
+			Status:           h.SSLStatus,
+			Issuer:           h.Issuer,
+			ExpiresOn:        h.ExpiresOn,
+			Validation:       h.Validation,
+			ValidationErrors: h.ValidationErrors,
+		})
+	}
+	return certs, nil
+}
+
+// evaluate 判断单个证书是否需要提醒。previous 为上次保存的待处理开始时间，
+// 处于待处理状态的证书写入 pending。
+func (p *EdgeCertProbe) evaluate(ds domain.DomainSource, cert edgeCert, now time.Time, previous, pending map[string]time.Time) (domain.Event, bool) {
+	ev := domain.Event{Domain: ds.Domain, Source: ds.Source, IsCF: true, At: now}
+	summary := p.describe(cert, now)
+
+	switch {
+	case strings.HasSuffix(cert.Status, "_timed_out"):
+		ev.Kind = domain.EventEdgeCertPending
+		ev.Urgent = true
+		ev.Detail = fmt.Sprintf("证书签发超时 (%s)，需要重新验证\n%s", cert.Status, summary)
+		return ev, true
+	case edgePendingStatuses[cert.Status]:
+		key := cert.ID + "|" + cert.Status
+		since, ok := previous[key]
+		if !ok {
+			since = now
+		}
+		pending[key] = since
+		if len(cert.ValidationErrors) == 0 && now.Sub(since) < p.pendingAfter() {
+			return domain.Event{}, false
+		}
+		ev.Kind = domain.EventEdgeCertPending
+		ev.Detail = fmt.Sprintf("证书处于 %s 状态，已持续 %s\n%s", cert.Status, formatPending(now.Sub(since)), summary)
+		return ev, true
+	case cert.Status == "expired":
+		ev.Kind = domain.EventCertExpiring
+		ev.Urgent = true
+		ev.Detail = "证书已过期\n" + summary
+		return ev, true
+	}
+
+	if cert.ExpiresOn.IsZero() {
+		return domain.Event{}, false
+	}
+	remaining := cert.ExpiresOn.Sub(now)
+	if remaining > p.Within {
+		return domain.Event{}, false
+	}
+	ev.Kind = domain.EventCertExpiring
+	ev.Urgent = remaining <= certUrgentWithin
+	ev.Detail = "证书临近到期，CF 未自动续期\n" + summary
+	return ev, true
+}
+
+func (p *EdgeCertProbe) describe(cert edgeCert, now time.Time) string {
+	lines := []string{
+		"类型: " + cert.Kind,
+		"主机名: " + orDash(strings.Join(cert.Hosts, ", ")),
+		"状态: " + orDash(cert.Status),
+		"签发者: " + orDash(cert.Issuer),
+	}
+	if !cert.ExpiresOn.IsZero() {
+		lines = append(lines, fmt.Sprintf("到期时间: %s (%s)",
+			tools.FormatExpiry(cert.ExpiresOn, p.Location), tools.CountdownTo(cert.ExpiresOn, now, p.Location)))
+	}
+	for _, msg := range cert.ValidationErrors {
+		lines = append(lines, "验证错误: "+msg)
+	}
+	for _, rec := range cert.Validation {
+		lines = append(lines, "验证记录: "+rec)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pendingChanged 判断待处理记录是否需要重新保存。
+func pendingChanged(old, cur map[string]time.Time) bool {
+	if len(old) != len(cur) {
+		return true
+	}
+	for key, since := range cur {
+		if prev, ok := old[key]; !ok || !prev.Equal(since) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *EdgeCertProbe) pendingAfter() time.Duration {
+	if p.PendingAfter > 0 {
+		return p.PendingAfter
+	}
+	return defaultPendingAfter
+}
+
+func formatPending(d time.Duration) string {
+	if d < time.Hour {
+		return "不足 1 小时"
+	}
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%d 小时", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d 天", int(d.Hours()/24))
+}
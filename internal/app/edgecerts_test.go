@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/config"
+	"DomainC/domain"
+)
+
+type edgeCF struct {
+	fakeCF
+	packs     map[string][]cfclient.CertificatePack
+	hostnames map[string][]cfclient.CustomHostname
+	packErr   error
+}
+
+func (f *edgeCF) ListCertificatePacks(ctx context.Context, account config.CF, zone string) ([]cfclient.CertificatePack, error) {
+	if f.packErr != nil {
+		return nil, f.packErr
+	}
+	return f.packs[zone], nil
+}
+
+func (f *edgeCF) ListCustomHostnames(ctx context.Context, account config.CF, zone string) ([]cfclient.CustomHostname, error) {
+	return f.hostnames[zone], nil
+}
+
+func TestEdgeCertProbeReportsStuckAndUnrenewedCerts(t *testing.T) {
+	now := time.Now()
+	cf := &edgeCF{
+		packs: map[string][]cfclient.CertificatePack{
+			"example.com": {
+				{ID: "ok", Type: "universal", Hosts: []string{"example.com", "*.example.com"}, Status: "active", ExpiresOn: now.AddDate(0, 2, 0)},
+				{ID: "stale", Type: "advanced", Hosts: []string{"shop.example.com"}, Status: "active", ExpiresOn: now.Add(2 * 24 * time.Hour)},
+				{ID: "stuck", Type: "advanced", Hosts: []string{"api.example.com"}, Status: "pending_validation",
+					Validation: []string{"TXT _acme-challenge.api.example.com token"}},
+			},
+			// NS 尚未切换的 Zone 证书一直处于待验证，不提醒
+			"new.com": {{ID: "new", Type: "universal", Hosts: []string{"new.com"}, Status: "validation_timed_out"}},
+		},
+		hostnames: map[string][]cfclient.CustomHostname{
+			"example.com": {
+				{ID: "h1", Hostname: "app.customer.com", SSLStatus: "pending_validation", ValidationErrors: []string{"CAA record prevents issuance"}},
+				{ID: "h2", Hostname: "old.customer.com", SSLStatus: "validation_timed_out"},
+			},
+		},
+	}
+	config.Cfg.CloudflareAccounts = []config.CF{{Label: "acc"}}
+	domains := []domain.DomainSource{
+		{Domain: "example.com", Source: "acc", IsCF: true, Status: "active"},
+		{Domain: "new.com", Source: "acc", IsCF: true, Status: "pending"},
+		{Domain: "file.com", Source: "domains.txt"},
+	}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	store, err := domain.NewFileStateStore(statePath)
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	probe := &EdgeCertProbe{CF: cf, State: store, Within: 14 * 24 * time.Hour}
+
+	events, err := probe.Run(context.Background(), domains)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events on first run, got %+v", events)
+	}
+	if ev := events[0]; ev.Kind != domain.EventCertExpiring || !ev.Urgent || !strings.Contains(ev.Detail, "shop.example.com") {
+		t.Fatalf("unexpected unrenewed pack event %+v", ev)
+	}
+	if ev := events[1]; ev.Kind != domain.EventEdgeCertPending || !strings.Contains(ev.Detail, "CAA record prevents issuance") {
+		t.Fatalf("validation errors should be reported immediately, got %+v", ev)
+	}
+	if ev := events[2]; ev.Kind != domain.EventEdgeCertPending || !ev.Urgent || !strings.Contains(ev.Detail, "validation_timed_out") {
+		t.Fatalf("unexpected timeout event %+v", ev)
+	}
+
+	// 待验证状态持续超过 PendingAfter 后提醒，开始时间在重启后保留
+	st, _ := store.Get("example.com")
+	for key := range st.EdgePending {
+		st.EdgePending[key] = now.Add(-25 * time.Hour)
+	}
+	store.Put(st)
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if store, err = domain.NewFileStateStore(statePath); err != nil {
+		t.Fatalf("reload state: %v", err)
+	}
+	probe = &EdgeCertProbe{CF: cf, State: store, Within: 14 * 24 * time.Hour}
+	events, err = probe.Run(context.Background(), domains)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	var stuck *domain.Event
+	for i := range events {
+		if strings.Contains(events[i].Detail, "api.example.com") {
+			stuck = &events[i]
+		}
+	}
+	if stuck == nil || stuck.Kind != domain.EventEdgeCertPending || !strings.Contains(stuck.Detail, "已持续 25 小时") ||
+		!strings.Contains(stuck.Detail, "TXT _acme-challenge.api.example.com token") {
+		t.Fatalf("expected stuck pack to be reported, got %+v", events)
+	}
+
+	// 证书包获取失败时保留待处理记录，不重新计时
+	before, _ := store.Get("example.com")
+	cf.packErr = errors.New("api unavailable")
+	if events, err := probe.Run(context.Background(), domains); err != nil || len(events) != 0 {
+		t.Fatalf("expected failed zone to be skipped, got %+v (%v)", events, err)
+	}
+	cf.packErr = nil
+	if after, _ := store.Get("example.com"); len(after.EdgePending) == 0 || pendingChanged(before.EdgePending, after.EdgePending) {
+		t.Fatalf("expected pending records to survive a failed listing, got %v", after.EdgePending)
+	}
+
+	// 签发完成后不再计时
+	cf.packs["example.com"][2].Status = "active"
+	if _, err := probe.Run(context.Background(), domains); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	st, _ = store.Get("example.com")
+	for key := range st.EdgePending {
+		if strings.Contains(key, "stuck") {
+			t.Fatalf("expected pending record to be cleared, got %v", st.EdgePending)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"DomainC/domain"
+	"DomainC/lookup"
+
+	"github.com/likexian/whois"
+	"github.com/openrdap/rdap"
+)
+
+// lookupFailure 是分类后的查询失败，Reason 尽量只保留关键的一行。
+type lookupFailure struct {
+	Kind   domain.FailureKind
+	Reason string
+}
+
+func (f *lookupFailure) Error() string { return f.Reason }
+
+// classifyLookup 将查询结果分类，成功拿到到期时间时返回 nil。
+func classifyLookup(result lookup.Result, err error) *lookupFailure {
+	var notRegistered *lookup.NotRegisteredError
+	if errors.As(err, &notRegistered) {
+		return &lookupFailure{Kind: domain.FailureNotRegistered, Reason: notRegistered.Line}
+	}
+	if err != nil {
+		return &lookupFailure{Kind: classifyError(err), Reason: err.Error()}
+	}
+	if result.HasExpiry() {
+		return nil
+	}
+	if line, ok := lookup.ThrottledLine(result.Raw); ok {
+		return &lookupFailure{Kind: domain.FailureThrottled, Reason: line}
+	}
+	if line, ok := lookup.NotFoundLine(result.Raw); ok {
+		return &lookupFailure{Kind: domain.FailureNotRegistered, Reason: line}
+	}
+	if strings.TrimSpace(result.Raw) == "" {
+		return &lookupFailure{Kind: domain.FailureNetwork, Reason: "查询返回空响应"}
+	}
+	return &lookupFailure{Kind: domain.FailureUnparseable, Reason: truncateReason("未找到到期时间字段: " + result.Raw)}
+}
+
+func classifyError(err error) domain.FailureKind {
+	if errors.Is(err, lookup.ErrNoRDAPServer) || errors.Is(err, lookup.ErrNotApplicable) || errors.Is(err, whois.ErrWhoisServerNotFound) || errors.Is(err, whois.ErrDomainEmpty) {
+		return domain.FailureUnsupported
+	}
+	var notRegistered *lookup.NotRegisteredError
+	if errors.As(err, &notRegistered) {
+		return domain.FailureNotRegistered
+	}
+	var clientErr *rdap.ClientError
+	if errors.As(err, &clientErr) {
+		switch clientErr.Type {
+		case rdap.ObjectDoesNotExist:
+			return domain.FailureNotRegistered
+		case rdap.NoWorkingServers:
+			// 所有 RDAP 服务器都没有正常响应，多为超时或服务端临时错误
+			return domain.FailureNetwork
+		}
+	}
+	if _, ok := lookup.ThrottledLine(err.Error()); ok {
+		return domain.FailureThrottled
+	}
+	if isNetworkError(err) {
+		return domain.FailureNetwork
+	}
+	// 其余错误（响应格式错误等）重试也不会恢复，不按网络错误重试，避免反复访问限流严格的服务器
+	return domain.FailureUnknown
+}
+
+// isNetworkError 判断是否为超时、连接被拒绝或重置等可以重试的网络错误。
+func isNetworkError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	for _, errno := range []syscall.Errno{
+		syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.ECONNABORTED,
+		syscall.ETIMEDOUT, syscall.EHOSTUNREACH, syscall.ENETUNREACH, syscall.EPIPE,
+	} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
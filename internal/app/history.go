@@ -0,0 +1,44 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"DomainC/domain"
+)
+
+// expiryTolerance 以内的差异视为 RDAP/WHOIS 时间精度不同，不算到期时间变化。
+const expiryTolerance = 24 * time.Hour
+
+// expiryChange 比较上次与本次的到期时间，首次查询到时 From 为零值。
+func expiryChange(previous, current time.Time, now time.Time) (domain.ExpiryChange, bool) {
+	if current.IsZero() {
+		return domain.ExpiryChange{}, false
+	}
+	if !previous.IsZero() {
+		diff := current.Sub(previous)
+		if diff < expiryTolerance && diff > -expiryTolerance {
+			return domain.ExpiryChange{}, false
+		}
+	}
+	return domain.ExpiryChange{At: now, From: previous, To: current}, true
+}
+
+// describeExpiryChange 输出 "2026-01-02 → 2027-01-02 (+1 年)" 形式的说明。
+func describeExpiryChange(change domain.ExpiryChange) string {
+	if change.From.IsZero() {
+		return fmt.Sprintf("首次记录到期时间: %s", change.To.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("到期时间: %s → %s (%s)", change.From.Format("2006-01-02"), change.To.Format("2006-01-02"), expiryDelta(change.From, change.To))
+}
+
+// expiryDelta 将变化量换算为年，不足一年时按天显示。
+func expiryDelta(from, to time.Time) string {
+	days := int(math.Round(to.Sub(from).Hours() / 24))
+	if days >= 360 || days <= -360 {
+		years := int(math.Round(float64(days) / 365.25))
+		return fmt.Sprintf("%+d 年", years)
+	}
+	return fmt.Sprintf("%+d 天", days)
+}
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/config"
+	"DomainC/domain"
+)
+
+// httpBodyLimit 为检查响应内容时最多读取的字节数。
+const httpBodyLimit = 1 << 20
+
+// HTTPProbe 按域名文件与配置中的检查访问站点，未通过的检查以事件返回。
+// 同时供 /probe、/setdns 之后的验证以及到期提醒使用，未配置检查的主机访问 https://host/。
+type HTTPProbe struct {
+	// Checks 为配置文件中的检查，按域名分组。
+	Checks    map[string][]domain.HTTPCheck
+	Timeout   time.Duration
+	Transport http.RoundTripper
+
+	mu sync.Mutex
+	// fileChecks 为最近一次 Run 时域名文件中的检查。
+	fileChecks map[string][]domain.HTTPCheck
+}
+
+func (p *HTTPProbe) Name() string { return "HTTP 检查" }
+
+func (p *HTTPProbe) Run(ctx context.Context, domains []domain.DomainSource) ([]domain.Event, error) {
+	fileChecks := make(map[string][]domain.HTTPCheck)
+	byName := make(map[string]domain.DomainSource)
+	var events []domain.Event
+	for _, ds := range domains {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		if _, ok := byName[ds.Domain]; !ok {
+			byName[ds.Domain] = ds
+		}
+		if len(ds.HTTPChecks) == 0 {
+			continue
+		}
+		fileChecks[ds.Domain] = append(fileChecks[ds.Domain], ds.HTTPChecks...)
+		if ev, ok := p.evaluate(ctx, ds, ds.HTTPChecks); ok {
+			events = append(events, ev)
+		}
+	}
+	p.mu.Lock()
+	p.fileChecks = fileChecks
+	p.mu.Unlock()
+
+	names := make([]string, 0, len(p.Checks))
+	for name := range p.Checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		ds, ok := byName[name]
+		if !ok {
+			ds = domain.DomainSource{Domain: name, Source: "httpProbes"}
+		}
+		if ev, ok := p.evaluate(ctx, ds, p.Checks[name]); ok {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+// evaluate 执行域名的全部检查，任一未通过时合并为一个事件；无法访问或 5xx 时为紧急事件。
+func (p *HTTPProbe) evaluate(ctx context.Context, ds domain.DomainSource, checks []domain.HTTPCheck) (domain.Event, bool) {
+	var failed []string
+	urgent := false
+	for _, check := range checks {
+		res := p.Check(ctx, check)
+		if res.OK() {
+			continue
+		}
+		failed = append(failed, res.Summary())
+		if res.Err != "" || res.StatusCode >= 500 {
+			urgent = true
+		}
+	}
+	if len(failed) == 0 {
+		return domain.Event{}, false
+	}
+	return domain.Event{
+		Kind:   domain.EventHTTPCheckFailed,
+		Domain: ds.Domain,
+		Source: ds.Source,
+		IsCF:   ds.IsCF,
+		Detail: strings.Join(failed, "\n"),
+		Urgent: urgent,
+		At:     time.Now(),
+	}, true
+}
+
+// CheckHTTP 检查域名或 URL：传入 URL（可带 status=200 等条件，格式同域名文件）时只访问该地址，
+// 传入主机名时执行为其配置的检查，未配置时访问 https://host/。
+func (p *HTTPProbe) CheckHTTP(ctx context.Context, target string) []domain.HTTPCheckResult {
+	var checks []domain.HTTPCheck
+	if strings.ContainsAny(target, "/ ") {
+		parsed, err := domain.ParseHTTPChecks(target)
+		if err != nil || len(parsed) == 0 {
+			return []domain.HTTPCheckResult{{Check: domain.HTTPCheck{URL: target}, Err: "无效的地址"}}
+		}
+		checks = parsed[:1]
+	} else {
+		checks = p.checksFor(target)
+	}
+
+	out := make([]domain.HTTPCheckResult, 0, len(checks))
+	for _, check := range checks {
+		out = append(out, p.Check(ctx, check))
+	}
+	return out
+}
+
+// checksFor 返回主机名对应的检查：域名下配置的检查中 URL 主机名一致的，或主机名本身即为该域名时的全部检查。
+func (p *HTTPProbe) checksFor(host string) []domain.HTTPCheck {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	p.mu.Lock()
+	groups := []map[string][]domain.HTTPCheck{p.Checks, p.fileChecks}
+	var out []domain.HTTPCheck
+	for _, group := range groups {
+		for name, checks := range group {
+			for _, c := range checks {
+				if name == host || c.Host() == host {
+					out = append(out, c)
+				}
+			}
+		}
+	}
+	p.mu.Unlock()
+	if len(out) == 0 {
+		out = append(out, domain.DefaultHTTPCheck(host))
+	}
+	return out
+}
+
+// Check 执行一次检查。设置 Redirect 时不跟随跳转，否则跟随跳转并以最终响应判断。
+func (p *HTTPProbe) Check(ctx context.Context, check domain.HTTPCheck) domain.HTTPCheckResult {
+	res := domain.HTTPCheckResult{Check: check}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	client := &http.Client{Timeout: timeout, Transport: p.Transport}
+	if check.Redirect != "" {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		res.Err = err.Error()
+		return res
+	}
+	req.Header.Set("User-Agent", "DomainC-probe/1.0")
+	start := time.Now()
+	resp, err := client.Do(req)
+	res.Latency = time.Since(start)
+	if err != nil {
+		res.Err = err.Error()
+		return res
+	}
+	defer resp.Body.Close()
+	res.StatusCode = resp.StatusCode
+
+	switch {
+	case check.Status != 0 && resp.StatusCode != check.Status:
+		res.Problems = append(res.Problems, fmt.Sprintf("状态码 %d，期望 %d", resp.StatusCode, check.Status))
+	case check.Status == 0 && resp.StatusCode >= 400:
+		res.Problems = append(res.Problems, fmt.Sprintf("状态码 %d", resp.StatusCode))
+	}
+
+	if check.Redirect != "" {
+		if loc, err := resp.Location(); err == nil {
+			res.Location = loc.String()
+		}
+		switch {
+		case res.Location == "":
+			res.Problems = append(res.Problems, "未跳转，期望跳转到 "+check.Redirect)
+		case res.Location != check.Redirect:
+			res.Problems = append(res.Problems, "跳转目标不符，期望 "+check.Redirect)
+		}
+	} else {
+		res.Location = resp.Request.URL.String()
+	}
+
+	if check.Contains != "" {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, httpBodyLimit))
+		if err != nil {
+			res.Problems = append(res.Problems, fmt.Sprintf("读取内容失败: %v", err))
+		} else if !strings.Contains(string(body), check.Contains) {
+			res.Problems = append(res.Problems, fmt.Sprintf("内容不包含 %q", check.Contains))
+		}
+	}
+
+	if check.MaxLatency > 0 && res.Latency > check.MaxLatency {
+		res.Problems = append(res.Problems, fmt.Sprintf("响应耗时 %dms，超过 %dms", res.Latency.Milliseconds(), check.MaxLatency.Milliseconds()))
+	}
+	return res
+}
+
+// HTTPChecksFromConfig 将配置中的检查按域名分组，未填写域名时取 URL 的主机名。
+func HTTPChecksFromConfig(probes []config.HTTPProbe) map[string][]domain.HTTPCheck {
+	out := make(map[string][]domain.HTTPCheck)
+	for _, c := range probes {
+		if strings.TrimSpace(c.URL) == "" {
+			continue
+		}
+		check := domain.HTTPCheck{
+			URL:        domain.NormalizeCheckURL(c.URL),
+			Status:     c.Status,
+			Redirect:   c.Redirect,
+			Contains:   c.Contains,
+			MaxLatency: c.MaxLatency,
+		}
+		name := strings.ToLower(strings.TrimSpace(c.Domain))
+		if name == "" {
+			name = check.Host()
+		}
+		out[name] = append(out[name], check)
+	}
+	return out
+}
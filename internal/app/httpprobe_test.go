@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/config"
+	"DomainC/domain"
+)
+
+func TestHTTPProbeReportsFailedChecks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("service ready")) })
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/new", http.StatusMovedPermanently) })
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("new")) })
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("slow"))
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "oops", http.StatusBadGateway) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	probe := &HTTPProbe{
+		Timeout: time.Second,
+		Checks: HTTPChecksFromConfig([]config.HTTPProbe{
+			{Domain: "cfg.com", URL: srv.URL + "/broken"},
+		}),
+	}
+	domains := []domain.DomainSource{
+		{Domain: "good.com", Source: "acc", IsCF: true, HTTPChecks: []domain.HTTPCheck{
+			{URL: srv.URL + "/ok", Status: 200, Contains: "ready"},
+			{URL: srv.URL + "/old", Redirect: srv.URL + "/new"},
+		}},
+		{Domain: "bad.com", Source: "domains.txt", HTTPChecks: []domain.HTTPCheck{
+			{URL: srv.URL + "/ok", Contains: "maintenance"},
+			{URL: srv.URL + "/old", Redirect: srv.URL + "/elsewhere"},
+			{URL: srv.URL + "/slow", MaxLatency: time.Millisecond},
+		}},
+		{Domain: "cfg.com", Source: "acc", IsCF: true},
+	}
+
+	events, err := probe.Run(context.Background(), domains)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	bad := events[0]
+	if bad.Domain != "bad.com" || bad.Kind != domain.EventHTTPCheckFailed || bad.Urgent {
+		t.Fatalf("unexpected event %+v", bad)
+	}
+	for _, want := range []string{`内容不包含 "maintenance"`, "跳转目标不符", "超过 1ms"} {
+		if !strings.Contains(bad.Detail, want) {
+			t.Fatalf("expected %q in detail:\n%s", want, bad.Detail)
+		}
+	}
+	if cfg := events[1]; cfg.Domain != "cfg.com" || !cfg.IsCF || cfg.Source != "acc" || !cfg.Urgent || !strings.Contains(cfg.Detail, "状态码 502") {
+		t.Fatalf("unexpected config event %+v", cfg)
+	}
+}
+
+func TestHTTPProbeCheckHTTPUsesKnownChecks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.Write([]byte("ok"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	hostname := host[:strings.LastIndex(host, ":")]
+
+	probe := &HTTPProbe{Timeout: time.Second}
+	if _, err := probe.Run(context.Background(), []domain.DomainSource{
+		{Domain: "example.com", HTTPChecks: []domain.HTTPCheck{{URL: srv.URL + "/health", Contains: "ok"}}},
+	}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	results := probe.CheckHTTP(context.Background(), hostname)
+	if len(results) != 1 || !results[0].OK() || results[0].Check.URL != srv.URL+"/health" {
+		t.Fatalf("expected known check for host, got %+v", results)
+	}
+	results = probe.CheckHTTP(context.Background(), srv.URL+"/missing status=200")
+	if len(results) != 1 || results[0].OK() || !strings.Contains(results[0].Summary(), "状态码 404，期望 200") {
+		t.Fatalf("unexpected URL check result %+v", results)
+	}
+	if got := probe.checksFor("unknown.example"); len(got) != 1 || got[0].URL != "https://unknown.example/" {
+		t.Fatalf("expected default check, got %+v", got)
+	}
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/dnsquery"
+	"DomainC/domain"
+	"DomainC/tools"
+)
+
+// NSResolver 实时查询域名的 NS 记录。
+type NSResolver interface {
+	LookupNS(ctx context.Context, name string) ([]string, error)
+}
+
+// NameServerProbe 对比 CF 分配的 NS 与注册局实际委派的 NS。
+// 委派 NS 默认取自最近一次 RDAP/WHOIS 结果（State），没有记录或 UseDNS 为 true 时通过 Resolver 实时查询。
+// CF 中 Zone 可能仍为 active，而注册商已把 NS 指向别处，这种情况只有在这里才能发现。
+// pending 等未激活的 Zone 本来就在等待 NS 切换，不做比较。
+type NameServerProbe struct {
+	State    domain.StateStore
+	Resolver NSResolver
+	UseDNS   bool
+}
+
+func (p *NameServerProbe) Name() string { return "NS 委派检查" }
+
+func (p *NameServerProbe) Run(ctx context.Context, domains []domain.DomainSource) ([]domain.Event, error) {
+	var events []domain.Event
+	for _, ds := range domains {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		if !ds.IsCF || !strings.EqualFold(ds.Status, "active") || len(ds.NameServers) == 0 {
+			continue
+		}
+
+		delegated, from, err := p.delegation(ctx, ds.Domain)
+		if err != nil {
+			log.Printf("查询 %s 的委派 NS 失败: %v", ds.Domain, err)
+			continue
+		}
+		if len(delegated) == 0 {
+			continue
+		}
+
+		assigned := normalizeNameServers(ds.NameServers)
+		if sameNameServers(assigned, delegated) {
+			continue
+		}
+		events = append(events, domain.Event{
+			Kind:   domain.EventNameServerDrift,
+			Domain: ds.Domain,
+			Source: ds.Source,
+			IsCF:   true,
+			Detail: fmt.Sprintf("CF 分配: %s\n实际委派(%s): %s", strings.Join(assigned, ", "), from, strings.Join(delegated, ", ")),
+			Urgent: true,
+			At:     time.Now(),
+		})
+	}
+	return events, nil
+}
+
+// delegation 返回委派 NS 及其来源说明。
+func (p *NameServerProbe) delegation(ctx context.Context, zone string) ([]string, string, error) {
+	if !p.UseDNS && p.State != nil {
+		name, err := tools.RegistrableDomain(zone)
+		if err != nil {
+			return nil, "", err
+		}
+		if st, ok := p.State.Get(name); ok && st.Lookup != nil && len(st.Lookup.NameServers) > 0 {
+			return normalizeNameServers(st.Lookup.NameServers), strings.ToUpper(string(st.Lookup.Protocol)), nil
+		}
+	}
+	if p.Resolver == nil {
+		return nil, "", nil
+	}
+	ns, err := p.Resolver.LookupNS(ctx, zone)
+	if err != nil {
+		return nil, "", err
+	}
+	return normalizeNameServers(ns), "DNS", nil
+}
+
+func normalizeNameServers(ns []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, n := range ns {
+		n = dnsquery.NormalizeName(n)
+		if n != "" && !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// sameNameServers 比较两个已排序去重的 NS 列表。
+func sameNameServers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/dnsquery"
+	"DomainC/dnsquery/dnstest"
+	"DomainC/domain"
+	"DomainC/lookup"
+)
+
+func TestNameServerProbeDetectsDrift(t *testing.T) {
+	store, err := domain.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("NewFileStateStore: %v", err)
+	}
+	store.Put(domain.DomainState{Domain: "ok.com", Lookup: &lookup.Result{Protocol: lookup.ProtocolRDAP, NameServers: []string{"BOB.NS.CLOUDFLARE.COM", "ada.ns.cloudflare.com."}}})
+	store.Put(domain.DomainState{Domain: "moved.com", Lookup: &lookup.Result{Protocol: lookup.ProtocolWHOIS, NameServers: []string{"ns1.parking.example"}}})
+
+	srv, err := dnstest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	srv.SetNS("live.com", "ns1.other.example", "ns2.other.example")
+	srv.SetNS("pending.com", "ns1.other.example", "ns2.other.example")
+
+	cfNS := []string{"ada.ns.cloudflare.com", "bob.ns.cloudflare.com"}
+	domains := []domain.DomainSource{
+		{Domain: "ok.com", Source: "acc", IsCF: true, Status: "active", NameServers: cfNS},
+		{Domain: "moved.com", Source: "acc", IsCF: true, Status: "active", NameServers: cfNS},
+		{Domain: "live.com", Source: "acc", IsCF: true, Status: "active", NameServers: cfNS},
+		{Domain: "pending.com", Source: "acc", IsCF: true, Status: "pending", NameServers: cfNS},
+		{Domain: "file.com", Source: "domains.txt"},
+	}
+
+	probe := &NameServerProbe{State: store, Resolver: dnsquery.NewClient(srv.Addr, time.Second)}
+	events, err := probe.Run(context.Background(), domains)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(events) != 2 || events[0].Domain != "moved.com" || events[1].Domain != "live.com" {
+		t.Fatalf("expected drift for moved.com and live.com, got %+v", events)
+	}
+	if events[0].Kind != domain.EventNameServerDrift || !strings.Contains(events[0].Detail, "实际委派(WHOIS): ns1.parking.example") {
+		t.Fatalf("unexpected event %+v", events[0])
+	}
+	if !strings.Contains(events[1].Detail, "实际委派(DNS)") {
+		t.Fatalf("expected live.com to fall back to DNS, got %q", events[1].Detail)
+	}
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -14,39 +15,57 @@ import (
 	"DomainC/tools"
 )
 
+// autoDeleteWithin 为 CF 域名自动删除的剩余时间上限，按实际剩余时长判断，与任务执行时刻无关。
+const autoDeleteWithin = 24 * time.Hour
+
 type NotifierService struct {
 	Sender        telegram.Sender
 	CFClient      cfclient.Client
 	DeleteTimeout time.Duration
+	// Location 为展示到期时间与倒计时的时区，为空时使用系统时区。
+	Location *time.Location
+	// HTTP 不为空时在到期提醒中附上站点访问检查结果，便于判断是否需要续费。
+	HTTP telegram.HTTPChecker
+	// Zones 不为空时自动删除前以索引确定账号，删除后同步更新索引。
+	Zones *cfclient.ZoneIndex
 }
 
+// Notify 发送到期提醒，单条发送失败不影响其他域名，有失败时返回错误以便下次重新提醒。
 func (n *NotifierService) Notify(ctx context.Context, domains []domain.DomainSource) error {
 	if n.Sender == nil {
 		return ErrMissingDependencies
 	}
+	var errs []error
 	for _, ds := range domains {
-		days, err := tools.DaysUntilExpiry(ds.Expiry)
-		if err != nil {
-			log.Printf("无法计算剩余天数: %v", err)
+		if ds.Expiry.IsZero() {
+			log.Printf("缺少到期时间，跳过提醒: %s", ds.Domain)
 			continue
 		}
+		countdown := tools.CountdownTo(ds.Expiry, time.Now(), n.Location)
 
 		if ds.IsCF {
-			n.notifyCloudflare(ctx, ds, days)
+			if err := n.notifyCloudflare(ctx, ds, countdown); err != nil {
+				log.Printf("发送 CF 域名提醒失败: %v", err)
+				errs = append(errs, err)
+			}
 			continue
 		}
 
 		msg := fmt.Sprintf(
-			"【域名即将到期】\n域名: %s\n来源: %s\n到期时间: %s\n非CF账户的域名请手工处理。",
+			"【域名即将到期】\n域名: %s\n来源: %s\n到期时间: %s (%s)\n%s%s非CF账户的域名请手工处理。",
 			ds.Domain,
 			ds.Source,
-			ds.Expiry,
+			tools.FormatExpiry(ds.Expiry, n.Location),
+			countdown,
+			whoisSummary(ds),
+			n.httpSummary(ctx, ds.Domain),
 		)
 		if err := n.Sender.Send(ctx, msg); err != nil {
 			log.Printf("发送非CF域名提醒失败: %v", err)
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 func (n *NotifierService) NotifyFailures(ctx context.Context, failures []domain.FailureRecord) error {
 	if n.Sender == nil {
@@ -56,20 +75,106 @@ func (n *NotifierService) NotifyFailures(ctx context.Context, failures []domain.
 		return nil
 	}
 
+	groups := make(map[domain.FailureKind][]domain.FailureRecord)
+	for _, f := range failures {
+		groups[f.Kind] = append(groups[f.Kind], f)
+	}
+	kinds := append([]domain.FailureKind{}, domain.FailureKinds...)
+	for kind := range groups {
+		if !containsKind(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+
 	var builder strings.Builder
 	builder.WriteString("【以下域名未能从rdap及whois获取到期时间】\n")
-	for _, f := range failures {
-		builder.WriteString(fmt.Sprintf("- %s (来源: %s): %s\n", f.Domain, f.Source, f.Reason))
+	for _, kind := range kinds {
+		group := groups[kind]
+		if len(group) == 0 {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("\n[%s] %d 个\n", kind.Label(), len(group)))
+		for _, f := range group {
+			builder.WriteString(fmt.Sprintf("- %s (来源: %s): %s\n", f.Domain, f.Source, f.Reason))
+		}
 	}
 
 	return n.Sender.Send(ctx, builder.String())
 }
-func (n *NotifierService) notifyCloudflare(ctx context.Context, ds domain.DomainSource, days int) {
+
+// NotifyEvents 逐条发送域名事件：域名已不再注册为最高优先级提醒，其次为到期时间提前与续费。
+func (n *NotifierService) NotifyEvents(ctx context.Context, events []domain.Event) error {
+	if n.Sender == nil {
+		return ErrMissingDependencies
+	}
+	for _, ev := range events {
+		var msg string
+		switch ev.Kind {
+		case domain.EventDropped:
+			msg = fmt.Sprintf(
+				"🚨【紧急：%s】\n域名: %s\n来源: %s\n注册局应答: %s\n域名可能已过期被删除，请立即核实并尽快重新注册。",
+				ev.Kind.Label(),
+				ev.Domain,
+				ev.Source,
+				ev.Detail,
+			)
+		case domain.EventRenewed:
+			msg = fmt.Sprintf("✅【%s】\n域名: %s\n来源: %s\n%s", ev.Kind.Label(), ev.Domain, ev.Source, ev.Detail)
+		case domain.EventExpiryMovedBack:
+			msg = fmt.Sprintf(
+				"⚠️【%s】\n域名: %s\n来源: %s\n%s\n注册局数据异常，请核实域名是否被转移或删除。",
+				ev.Kind.Label(),
+				ev.Domain,
+				ev.Source,
+				ev.Detail,
+			)
+		case domain.EventNameServerDrift:
+			msg = fmt.Sprintf(
+				"⚠️【%s】\n域名: %s\n账号: %s\n%s\nCF 中的 Zone 仍为 active，但注册商的 NS 已指向别处，解析可能已不受 CF 控制。",
+				ev.Kind.Label(),
+				ev.Domain,
+				ev.Source,
+				ev.Detail,
+			)
+		case domain.EventStatusChanged:
+			prefix := "ℹ️"
+			if ev.Urgent {
+				prefix = "🚨"
+			}
+			msg = fmt.Sprintf("%s【%s】\n域名: %s\n来源: %s\n%s", prefix, ev.Kind.Label(), ev.Domain, ev.Source, ev.Detail)
+		default:
+			prefix := ""
+			if ev.Urgent {
+				prefix = "🚨"
+			}
+			msg = fmt.Sprintf("%s【%s】\n域名: %s\n来源: %s\n%s", prefix, ev.Kind.Label(), ev.Domain, ev.Source, ev.Detail)
+		}
+		if ev.IsCF {
+			buttons := [][]telegram.Button{{
+				{Text: "查询解析", CallbackData: fmt.Sprintf("DNS|%s|%s", ev.Source, ev.Domain)},
+			}}
+			if err := n.Sender.SendWithButtons(ctx, msg, buttons); err != nil {
+				log.Printf("发送域名事件失败: %v", err)
+			}
+			continue
+		}
+		if err := n.Sender.Send(ctx, msg); err != nil {
+			log.Printf("发送域名事件失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// notifyCloudflare 发送带操作按钮的提醒。
+func (n *NotifierService) notifyCloudflare(ctx context.Context, ds domain.DomainSource, countdown tools.Countdown) error {
 	msg := fmt.Sprintf(
-		"【域名即将到期】\n域名: %s\n来源: %s\n到期时间: %s\n注意：如果没人响应，遇到到期后将自动从CF删除",
+		"【域名即将到期】\n域名: %s\n来源: %s\n到期时间: %s (%s)\n%s%s注意：如果没人响应，到期前 24 小时内将自动从CF删除",
 		ds.Domain,
 		ds.Source,
-		ds.Expiry,
+		tools.FormatExpiry(ds.Expiry, n.Location),
+		countdown,
+		whoisSummary(ds),
+		n.httpSummary(ctx, ds.Domain),
 	)
 	buttons := [][]telegram.Button{{
 		{Text: "暂停域名", CallbackData: fmt.Sprintf("pause|%s|%s|yes", ds.Source, ds.Domain)},
@@ -77,15 +182,35 @@ func (n *NotifierService) notifyCloudflare(ctx context.Context, ds domain.Domain
 		{Text: "查询解析", CallbackData: fmt.Sprintf("DNS|%s|%s", ds.Source, ds.Domain)},
 		{Text: "删除域名", CallbackData: fmt.Sprintf("delete|%s|%s", ds.Source, ds.Domain)},
 	}}
-	if err := n.Sender.SendWithButtons(ctx, msg, buttons); err != nil {
-		log.Printf("发送 CF 域名提醒失败: %v", err)
-	}
+	return n.Sender.SendWithButtons(ctx, msg, buttons)
+}
 
-	if days == 1 && n.CFClient != nil {
+// AutoDelete 将剩余时间在 autoDeleteWithin 内的 CF 域名从 CF 删除。domains 为提醒窗口内的全部域名，
+// 与本次是否发送提醒无关，避免提醒去重后错过删除时机。
+func (n *NotifierService) AutoDelete(ctx context.Context, domains []domain.DomainSource) error {
+	if n.Sender == nil {
+		return ErrMissingDependencies
+	}
+	if n.CFClient == nil {
+		return nil
+	}
+	for _, ds := range domains {
+		if !ds.IsCF || ds.Expiry.IsZero() {
+			continue
+		}
+		countdown := tools.CountdownTo(ds.Expiry, time.Now(), n.Location)
+		if countdown.Expired() || countdown.Remaining > autoDeleteWithin {
+			continue
+		}
+		// 只在检测到期时间的账号中删除，不按索引改用其他账号
 		account := cfclient.GetAccountByLabel(ds.Source)
 		if account == nil {
 			log.Printf("未找到账号: %s", ds.Source)
-			return
+			continue
+		}
+		if n.Zones != nil && n.Zones.Lost(ds.Source, ds.Domain) {
+			log.Printf("账号 %s 中已没有 %s，跳过自动删除", ds.Source, ds.Domain)
+			continue
 		}
 		deleteCtx := ctx
 		cancel := func() {}
@@ -98,7 +223,48 @@ func (n *NotifierService) notifyCloudflare(ctx context.Context, ds domain.Domain
 				_ = n.Sender.Send(ctx, fmt.Sprintf("⚠️ 自动删除域名失败: %s (%v)", domain, err))
 				return
 			}
+			if n.Zones != nil {
+				n.Zones.Remove(acc.Label, domain)
+			}
 			_ = n.Sender.Send(ctx, fmt.Sprintf("✅ 已自动删除即将到期的域名: %s", domain))
 		}(*account, ds.Domain)
 	}
+	return nil
+}
+
+// httpSummary 返回域名的访问检查结果，每行一条，未启用时为空。
+func (n *NotifierService) httpSummary(ctx context.Context, name string) string {
+	if n.HTTP == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("访问检查:\n")
+	for _, res := range n.HTTP.CheckHTTP(ctx, name) {
+		sb.WriteString(res.Summary() + "\n")
+	}
+	return sb.String()
+}
+
+func containsKind(kinds []domain.FailureKind, kind domain.FailureKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// whoisSummary 输出查询结果中的注册商和状态，每项一行；没有查询结果时为空。
+func whoisSummary(ds domain.DomainSource) string {
+	if ds.Whois == nil {
+		return ""
+	}
+	var sb strings.Builder
+	if ds.Whois.Registrar != "" {
+		sb.WriteString(fmt.Sprintf("注册商: %s\n", ds.Whois.Registrar))
+	}
+	if len(ds.Whois.Statuses) > 0 {
+		sb.WriteString(fmt.Sprintf("状态: %s\n", strings.Join(ds.Whois.Statuses, ", ")))
+	}
+	return sb.String()
 }
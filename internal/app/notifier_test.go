@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -40,12 +41,29 @@ func (f *fakeSender) SendWithButtons(ctx context.Context, msg string, buttons []
 	return nil
 }
 
+// sent 返回已发送消息的副本，供测试在后台发送之后读取。
+func (f *fakeSender) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.messages...)
+}
+
 func (f *fakeSender) StartListener(ctx context.Context, handleCallback func(data string, user *tgbotapi.User), handleMessage func(msg *tgbotapi.Message)) error {
 	<-ctx.Done()
 	return nil
 }
 
-type fakeCF struct{ deleted []string }
+type fakeCF struct {
+	mu      sync.Mutex
+	deleted []string
+}
+
+// deletedDomains 返回已删除域名的副本，自动删除在后台协程中进行。
+func (f *fakeCF) deletedDomains() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.deleted...)
+}
 
 func (f *fakeCF) FetchAllDomains(ctx context.Context, account config.CF) ([]cfclient.DomainInfo, error) {
 	return nil, nil
@@ -57,6 +75,8 @@ func (f *fakeCF) PauseDomain(ctx context.Context, account config.CF, domain stri
 	return nil
 }
 func (f *fakeCF) DeleteDomain(ctx context.Context, account config.CF, domain string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.deleted = append(f.deleted, domain)
 	return nil
 }
@@ -66,16 +86,42 @@ func (f *fakeCF) GetZoneDetails(ctx context.Context, account config.CF, domain s
 func (f *fakeCF) CreateZone(ctx context.Context, account config.CF, domain string) (cfclient.ZoneDetail, error) {
 	return cfclient.ZoneDetail{}, nil
 }
-func (f *fakeCF) UpsertDNSRecord(ctx context.Context, account config.CF, domain string, params cfclient.DNSRecordParams) (cloudflare.DNSRecord, error) {
+func (f *fakeCF) GetDNSSEC(ctx context.Context, account config.CF, domain string) (cfclient.DNSSECStatus, error) {
+	return cfclient.DNSSECStatus{}, nil
+}
+func (f *fakeCF) ListCertificatePacks(ctx context.Context, account config.CF, domain string) ([]cfclient.CertificatePack, error) {
+	return nil, nil
+}
+func (f *fakeCF) ListCustomHostnames(ctx context.Context, account config.CF, domain string) ([]cfclient.CustomHostname, error) {
+	return nil, nil
+}
+func (f *fakeCF) UpsertDNSRecord(ctx context.Context, account config.CF, domain string, params cfclient.DNSRecordParams, overlay func(*cfclient.DNSRecordParams) error) (cloudflare.DNSRecord, error) {
+	return cloudflare.DNSRecord{}, nil
+}
+func (f *fakeCF) SearchDNSRecords(ctx context.Context, account config.CF, domain string, filter cfclient.DNSRecordFilter) ([]cloudflare.DNSRecord, error) {
+	return nil, nil
+}
+func (f *fakeCF) GetDNSRecord(ctx context.Context, account config.CF, domain, id string) (cloudflare.DNSRecord, error) {
+	return cloudflare.DNSRecord{}, nil
+}
+func (f *fakeCF) CreateDNSRecord(ctx context.Context, account config.CF, domain string, params cfclient.DNSRecordParams) (cloudflare.DNSRecord, error) {
+	return cloudflare.DNSRecord{}, nil
+}
+func (f *fakeCF) UpdateDNSRecord(ctx context.Context, account config.CF, domain, id string, params cfclient.DNSRecordParams) (cloudflare.DNSRecord, error) {
 	return cloudflare.DNSRecord{}, nil
 }
+func (f *fakeCF) DeleteDNSRecord(ctx context.Context, account config.CF, domain, id string) error {
+	return nil
+}
 func TestNotifierSendsAlertsAndDeletes(t *testing.T) {
 	sender := &fakeSender{}
 	cf := &fakeCF{}
 	notifier := &NotifierService{Sender: sender, CFClient: cf, DeleteTimeout: time.Second}
 
-	expiry := time.Now().Add(48 * time.Hour).Format("2006-01-02")
-	domains := []domain.DomainSource{{Domain: "example.com", Source: "acc", Expiry: expiry, IsCF: true}}
+	domains := []domain.DomainSource{
+		{Domain: "example.com", Source: "acc", Expiry: time.Now().Add(12 * time.Hour), IsCF: true},
+		{Domain: "later.com", Source: "acc", Expiry: time.Now().Add(30 * time.Hour), IsCF: true},
+	}
 
 	cfg := config.CF{Label: "acc"}
 	config.Cfg.CloudflareAccounts = []config.CF{cfg}
@@ -83,13 +129,88 @@ func TestNotifierSendsAlertsAndDeletes(t *testing.T) {
 	if err := notifier.Notify(context.Background(), domains); err != nil {
 		t.Fatalf("notify returned error: %v", err)
 	}
+	if len(cf.deleted) != 0 {
+		t.Fatalf("expected Notify to leave deletion to AutoDelete, got %v", cf.deleted)
+	}
+	if err := notifier.AutoDelete(context.Background(), domains); err != nil {
+		t.Fatalf("auto delete returned error: %v", err)
+	}
 
 	time.Sleep(200 * time.Millisecond)
 
-	if len(sender.messages) == 0 {
+	messages := sender.sent()
+	if len(messages) == 0 {
 		t.Fatalf("expected messages to be sent")
 	}
-	if len(cf.deleted) == 0 || cf.deleted[0] != "example.com" {
-		t.Fatalf("expected domain deletion to be triggered")
+	if deleted := cf.deletedDomains(); len(deleted) != 1 || deleted[0] != "example.com" {
+		t.Fatalf("expected only the domain expiring within 24h to be deleted, got %v", deleted)
+	}
+	if !strings.Contains(messages[0], "剩余 11 小时") {
+		t.Fatalf("expected hour-level countdown in alert:\n%s", messages[0])
+	}
+}
+
+func TestAutoDeleteSkipsZoneLostFromSourceAccount(t *testing.T) {
+	sender := &fakeSender{}
+	cf := &fakeCF{}
+	config.Cfg.CloudflareAccounts = []config.CF{{Label: "acc"}, {Label: "other"}}
+	zones := cfclient.NewZoneIndex(cf, config.Cfg.CloudflareAccounts)
+	// 索引显示该 Zone 已转到 other 账号，自动删除不能改在 other 账号执行
+	zones.Put("other", cfclient.ZoneDetail{Name: "example.com", Status: "active"})
+	notifier := &NotifierService{Sender: sender, CFClient: cf, Zones: zones, DeleteTimeout: time.Second}
+
+	domains := []domain.DomainSource{{Domain: "example.com", Source: "acc", Expiry: time.Now().Add(12 * time.Hour), IsCF: true}}
+	if err := notifier.AutoDelete(context.Background(), domains); err != nil {
+		t.Fatalf("auto delete returned error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if deleted := cf.deletedDomains(); len(deleted) != 0 {
+		t.Fatalf("expected no deletion outside the source account, got %v", deleted)
+	}
+}
+
+func TestNotifyFailuresGroupsByKind(t *testing.T) {
+	sender := &fakeSender{}
+	notifier := &NotifierService{Sender: sender}
+
+	failures := []domain.FailureRecord{
+		{Domain: "a.com", Source: "s", Kind: domain.FailureNetwork, Reason: "timeout"},
+		{Domain: "b.us.com", Source: "s", Kind: domain.FailureNotRegistered, Reason: "No match"},
+		{Domain: "c.com", Source: "s", Kind: domain.FailureNetwork, Reason: "reset"},
+	}
+	if err := notifier.NotifyFailures(context.Background(), failures); err != nil {
+		t.Fatalf("NotifyFailures returned error: %v", err)
+	}
+	if len(sender.messages) != 1 {
+		t.Fatalf("expected a single report, got %d", len(sender.messages))
+	}
+	msg := sender.messages[0]
+	notFound := strings.Index(msg, domain.FailureNotRegistered.Label())
+	network := strings.Index(msg, domain.FailureNetwork.Label())
+	if notFound < 0 || network < 0 || notFound > network {
+		t.Fatalf("expected failures grouped with not_registered first:\n%s", msg)
+	}
+	if !strings.Contains(msg, "[网络错误] 2 个") {
+		t.Fatalf("expected network group count in report:\n%s", msg)
+	}
+}
+
+func TestNotifyEventsSendsDroppedAlert(t *testing.T) {
+	sender := &fakeSender{}
+	notifier := &NotifierService{Sender: sender}
+
+	events := []domain.Event{{Kind: domain.EventDropped, Domain: "gamestore.us.com", Source: "acc", IsCF: true, Detail: "No match for \"GAMESTORE.US.COM\"."}}
+	if err := notifier.NotifyEvents(context.Background(), events); err != nil {
+		t.Fatalf("NotifyEvents returned error: %v", err)
+	}
+	if len(sender.messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(sender.messages))
+	}
+	if msg := sender.messages[0]; !strings.Contains(msg, "紧急") || !strings.Contains(msg, "No match for") {
+		t.Fatalf("expected urgent dropped alert with registry answer:\n%s", msg)
+	}
+	if len(sender.buttons) != 1 || sender.buttons[0] != "DNS|acc|gamestore.us.com" {
+		t.Fatalf("unexpected buttons %v", sender.buttons)
 	}
 }
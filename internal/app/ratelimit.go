@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/tools"
+)
+
+// keyedLimiter 按限流键（公共后缀、WHOIS/RDAP 服务器等）分别限速，
+// 不同键之间互不阻塞。
+type keyedLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	overrides map[string]time.Duration
+	next      map[string]time.Time
+}
+
+func newKeyedLimiter(interval time.Duration, overrides map[string]time.Duration) *keyedLimiter {
+	normalized := make(map[string]time.Duration, len(overrides))
+	for k, v := range overrides {
+		normalized[strings.ToLower(strings.Trim(k, ". "))] = v
+	}
+	return &keyedLimiter{interval: interval, overrides: normalized, next: make(map[string]time.Time)}
+}
+
+// intervalFor 按最长后缀匹配覆盖配置，例如 "ph" 同时作用于 "com.ph"。
+func (l *keyedLimiter) intervalFor(key string) time.Duration {
+	for k := key; ; {
+		if d, ok := l.overrides[k]; ok {
+			return d
+		}
+		idx := strings.IndexByte(k, '.')
+		if idx < 0 {
+			return l.interval
+		}
+		k = k[idx+1:]
+	}
+}
+
+// Wait 为 key 预约下一个可用时间片并等待，ctx 取消时提前返回。
+func (l *keyedLimiter) Wait(ctx context.Context, key string) error {
+	return l.WaitFor(ctx, key, l.intervalFor(key))
+}
+
+// WaitFor 与 Wait 相同，但使用调用方给出的间隔，用于限流键为服务器、间隔按后缀配置的情况。
+func (l *keyedLimiter) WaitFor(ctx context.Context, key string, interval time.Duration) error {
+	if interval <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[key]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[key] = slot.Add(interval)
+	l.mu.Unlock()
+
+	return sleepContext(ctx, time.Until(slot))
+}
+
+// readyAt 返回 key 下一个可用时间片，不预约。
+func (l *keyedLimiter) readyAt(key string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next[key]
+}
+
+// take 在已确认时间片可用时占用它，下一个时间片为 now+interval。
+func (l *keyedLimiter) take(key string, interval time.Duration, now time.Time) {
+	if interval <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if next := now.Add(interval); next.After(l.next[key]) {
+		l.next[key] = next
+	}
+}
+
+// suffixKey 以公共后缀作为默认限流键，使 us.com 与 com 分开限速。
+func suffixKey(name string) string {
+	if suffix := tools.PublicSuffix(name); suffix != "" {
+		return suffix
+	}
+	return tools.NormalizeDomain(name)
+}
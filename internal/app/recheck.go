@@ -0,0 +1,86 @@
+package app
+
+import (
+	"time"
+
+	"DomainC/domain"
+)
+
+// recheckSlack 抵消每日任务执行时间的细微抖动，避免 24h 间隔被推迟到第三天。
+const recheckSlack = time.Hour
+
+// RecheckTier 表示距离到期超过 Beyond 时，每隔 Every 重新查询一次。
+type RecheckTier struct {
+	Beyond time.Duration
+	Every  time.Duration
+}
+
+// RecheckPolicy 根据距离到期的时间决定缓存结果多久后需要重新查询。
+// 告警窗口内或最近续费过的域名每次运行都会查询。
+type RecheckPolicy struct {
+	// Tiers 取剩余时间超过的最大 Beyond 对应的间隔，都不满足时使用 Default。
+	Tiers   []RecheckTier
+	Default time.Duration
+	// RenewedGrace 为续费后仍保持每次查询的时长。
+	RenewedGrace time.Duration
+	// StatusEvery 为不论到期远近的最长复查间隔，用于及时发现 clientHold 等状态变化，0 表示不限制。
+	StatusEvery time.Duration
+}
+
+// DefaultRecheckPolicy 返回默认的复查频率：一年以上每月、半年以上每两周、三个月以上每周，其余每天。
+func DefaultRecheckPolicy() RecheckPolicy {
+	day := 24 * time.Hour
+	return RecheckPolicy{
+		Tiers: []RecheckTier{
+			{Beyond: 365 * day, Every: 30 * day},
+			{Beyond: 180 * day, Every: 14 * day},
+			{Beyond: 90 * day, Every: 7 * day},
+		},
+		Default:      day,
+		RenewedGrace: 7 * day,
+	}
+}
+
+// Interval 返回缓存结果的有效期，0 表示每次都需要查询。
+func (p RecheckPolicy) Interval(st domain.DomainState, alertWithin time.Duration, now time.Time) time.Duration {
+	if st.Lookup == nil || !st.Lookup.HasExpiry() {
+		return 0
+	}
+	if !st.RenewedAt.IsZero() && now.Sub(st.RenewedAt) < p.RenewedGrace {
+		return 0
+	}
+
+	remaining := st.Lookup.Expiry().Sub(now)
+	if remaining <= alertWithin {
+		return 0
+	}
+	// 存在暂停解析、待删除等严重状态时按最短间隔复查，直到状态解除
+	if len(st.Lookup.CriticalStatuses()) > 0 {
+		return p.Default
+	}
+	// 转移锁被解除或转移进行中时同样每天复查，不受 StatusEvery 放宽的影响
+	if transferAtRisk(st) {
+		return p.Default
+	}
+	interval := p.Default
+	var matched time.Duration
+	for _, tier := range p.Tiers {
+		if remaining > tier.Beyond && tier.Beyond >= matched {
+			matched = tier.Beyond
+			interval = tier.Every
+		}
+	}
+	if p.StatusEvery > 0 && interval > p.StatusEvery {
+		interval = p.StatusEvery
+	}
+	return interval
+}
+
+// Fresh 判断缓存结果在 now 时是否仍可直接使用。
+func (p RecheckPolicy) Fresh(st domain.DomainState, alertWithin time.Duration, now time.Time) bool {
+	interval := p.Interval(st, alertWithin, now)
+	if interval <= 0 || st.CheckedAt.IsZero() {
+		return false
+	}
+	return now.Sub(st.CheckedAt)+recheckSlack < interval
+}
@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"DomainC/cfclient"
+	"DomainC/dnsquery"
+	"DomainC/domain"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// RecordResolver 向单个解析器查询记录，dnsquery.Client 满足该接口。
+type RecordResolver interface {
+	LookupRecords(ctx context.Context, name string, qtype dnsmessage.Type) ([]string, error)
+	Addr() string
+}
+
+// resolutionTypes 为需要检查的记录类型，按该顺序查询。
+var resolutionTypes = []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA, dnsmessage.TypeCNAME, dnsmessage.TypeMX}
+
+// ResolutionProbe 向每个解析器查询域名根与关键主机名的 A/AAAA/CNAME/MX 记录。
+// 应答 SERVFAIL、NXDOMAIN 时为紧急事件；CF 域名未代理的记录还会与 ListDNSRecords 对比，不一致时提醒。
+// 已代理的记录与域名根的 CNAME（CF 会展开为 A 记录）只检查能否解析。非 CF 域名只检查域名根。
+type ResolutionProbe struct {
+	CF        cfclient.Client
+	Resolvers []RecordResolver
+	// Hosts 为主机名前缀，例如 www，@ 表示域名根。
+	Hosts []string
+}
+
+// resolutionQuery 为一个主机名需要执行的查询；Want 为空且 Compare 为 false 时只检查应答码。
+type resolutionQuery struct {
+	Name    string
+	Type    dnsmessage.Type
+	Want    []string
+	Compare bool
+	// Required 为 true 时应答为空也视为异常，用于已代理记录。
+	Required bool
+}
+
+func (p *ResolutionProbe) Name() string { return "解析检查" }
+
+func (p *ResolutionProbe) Run(ctx context.Context, domains []domain.DomainSource) ([]domain.Event, error) {
+	if len(p.Resolvers) == 0 {
+		return nil, ErrMissingDependencies
+	}
+	var events []domain.Event
+	for _, ds := range domains {
+		if err := ctx.Err(); err != nil {
+			return events, err
+		}
+		queries := p.plan(ctx, ds)
+		var problems []string
+		urgent := false
+		for _, r := range p.Resolvers {
+			for _, q := range queries {
+				problem, critical := p.resolve(ctx, r, q)
+				if problem == "" {
+					continue
+				}
+				problems = append(problems, fmt.Sprintf("[%s] %s %s: %s", r.Addr(), q.Name, typeName(q.Type), problem))
+				urgent = urgent || critical
+			}
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		events = append(events, domain.Event{
+			Kind:   domain.EventResolutionFailed,
+			Domain: ds.Domain,
+			Source: ds.Source,
+			IsCF:   ds.IsCF,
+			Detail: strings.Join(problems, "\n"),
+			Urgent: urgent,
+			At:     time.Now(),
+		})
+	}
+	return events, nil
+}
+
+// plan 根据 CF 中的记录生成查询列表；CF 记录获取失败时退化为只检查域名根能否解析。
+// 未激活的 Zone 尚未由 CF 应答，公网结果与 CF 记录不同属于正常情况，同样只检查域名根。
+func (p *ResolutionProbe) plan(ctx context.Context, ds domain.DomainSource) []resolutionQuery {
+	apex := dnsquery.NormalizeName(ds.Domain)
+	rootOnly := []resolutionQuery{{Name: apex, Type: dnsmessage.TypeA}}
+	if !ds.IsCF || !strings.EqualFold(ds.Status, "active") || p.CF == nil {
+		return rootOnly
+	}
+	account := cfclient.GetAccountByLabel(ds.Source)
+	if account == nil {
+		log.Printf("未找到账号: %s", ds.Source)
+		return rootOnly
+	}
+	records, err := p.CF.ListDNSRecords(ctx, *account, ds.Domain)
+	if err != nil {
+		log.Printf("获取 %s 解析失败: %v", ds.Domain, err)
+		return rootOnly
+	}
+
+	type recordSet struct {
+		values  []string
+		proxied bool
+	}
+	byName := make(map[string]map[dnsmessage.Type]*recordSet)
+	for _, r := range records {
+		var qtype dnsmessage.Type
+		switch r.Type {
+		case "A":
+			qtype = dnsmessage.TypeA
+		case "AAAA":
+			qtype = dnsmessage.TypeAAAA
+		case "CNAME":
+			qtype = dnsmessage.TypeCNAME
+		case "MX":
+			qtype = dnsmessage.TypeMX
+		default:
+			continue
+		}
+		name := dnsquery.NormalizeName(r.Name)
+		if byName[name] == nil {
+			byName[name] = make(map[dnsmessage.Type]*recordSet)
+		}
+		set := byName[name][qtype]
+		if set == nil {
+			set = &recordSet{}
+			byName[name][qtype] = set
+		}
+		set.values = append(set.values, normalizeRecordValue(r.Content))
+		if r.Proxied != nil && *r.Proxied {
+			set.proxied = true
+		}
+	}
+
+	var queries []resolutionQuery
+	for _, name := range p.hostnames(apex) {
+		sets := byName[name]
+		if len(sets) == 0 {
+			// 关键主机名在 CF 中不存在时 NXDOMAIN 属于正常情况，只检查域名根
+			if name == apex {
+				queries = append(queries, resolutionQuery{Name: name, Type: dnsmessage.TypeA})
+			}
+			continue
+		}
+		resolvable := false
+		for _, qtype := range resolutionTypes {
+			set, ok := sets[qtype]
+			if !ok {
+				continue
+			}
+			if qtype != dnsmessage.TypeMX && (set.proxied || (qtype == dnsmessage.TypeCNAME && name == apex)) {
+				resolvable = true
+				continue
+			}
+			queries = append(queries, resolutionQuery{Name: name, Type: qtype, Want: set.values, Compare: true})
+		}
+		if resolvable {
+			queries = append(queries, resolutionQuery{Name: name, Type: dnsmessage.TypeA, Required: true})
+		}
+	}
+	return queries
+}
+
+// hostnames 返回需要检查的主机名，域名根总在第一位。
+func (p *ResolutionProbe) hostnames(apex string) []string {
+	out := []string{apex}
+	seen := map[string]bool{apex: true}
+	for _, h := range p.Hosts {
+		h = dnsquery.NormalizeName(h)
+		name := apex
+		if h != "" && h != "@" {
+			name = h + "." + apex
+		}
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// resolve 执行一次查询，返回异常说明以及是否为 SERVFAIL/NXDOMAIN 等解析中断。
+// 网络超时等解析器自身的问题只记录日志。
+func (p *ResolutionProbe) resolve(ctx context.Context, r RecordResolver, q resolutionQuery) (string, bool) {
+	answers, err := r.LookupRecords(ctx, q.Name, q.Type)
+	if err != nil {
+		var rcodeErr *dnsquery.RCodeError
+		switch {
+		case errors.Is(err, dnsquery.ErrNXDomain):
+			return "NXDOMAIN", true
+		case errors.As(err, &rcodeErr):
+			return dnsquery.RCodeName(rcodeErr.RCode), rcodeErr.RCode == dnsmessage.RCodeServerFailure
+		default:
+			log.Printf("解析器 %s 查询 %s %s 失败: %v", r.Addr(), q.Name, typeName(q.Type), err)
+			return "", false
+		}
+	}
+	if q.Required && len(answers) == 0 {
+		return "应答为空", true
+	}
+	if !q.Compare {
+		return "", false
+	}
+	for i := range answers {
+		answers[i] = normalizeRecordValue(answers[i])
+	}
+	got, want := sortedJoin(answers), sortedJoin(q.Want)
+	if got != want {
+		return fmt.Sprintf("应答 %s，CF 中为 %s", orDash(got), orDash(want)), false
+	}
+	return "", false
+}
+
+// typeName 返回 A、MX 等记录类型名称。
+func typeName(t dnsmessage.Type) string {
+	return strings.TrimPrefix(t.String(), "Type")
+}
+
+// normalizeRecordValue 统一 IP 地址写法，主机名转为小写并去掉末尾的点。
+func normalizeRecordValue(v string) string {
+	if ip := net.ParseIP(strings.TrimSpace(v)); ip != nil {
+		return ip.String()
+	}
+	return dnsquery.NormalizeName(v)
+}
+
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
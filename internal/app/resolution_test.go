@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/config"
+	"DomainC/dnsquery"
+	"DomainC/dnsquery/dnstest"
+	"DomainC/domain"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestResolutionProbeComparesWithCloudflare(t *testing.T) {
+	on, off := true, false
+	cf := &recordsCF{records: map[string][]cloudflare.DNSRecord{
+		"good.com": {
+			{Type: "A", Name: "good.com", Content: "192.0.2.1", Proxied: &off},
+			{Type: "CNAME", Name: "www.good.com", Content: "good.com", Proxied: &off},
+			{Type: "MX", Name: "good.com", Content: "mx.good.com"},
+		},
+		"drift.com": {
+			{Type: "A", Name: "drift.com", Content: "192.0.2.10", Proxied: &off},
+			{Type: "AAAA", Name: "www.drift.com", Content: "2001:db8:0::1", Proxied: &off},
+			{Type: "MX", Name: "drift.com", Content: "MX.Drift.com."},
+		},
+		"pending.com": {
+			{Type: "A", Name: "pending.com", Content: "192.0.2.40"},
+		},
+		"proxied.com": {
+			{Type: "CNAME", Name: "proxied.com", Content: "origin.example.net", Proxied: &on},
+			{Type: "A", Name: "www.proxied.com", Content: "192.0.2.20", Proxied: &on},
+		},
+	}}
+	config.Cfg.CloudflareAccounts = []config.CF{{Label: "acc"}}
+
+	primary, err := dnstest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer primary.Close()
+	secondary, err := dnstest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer secondary.Close()
+
+	for _, srv := range []*dnstest.Server{primary, secondary} {
+		srv.SetA("good.com", "192.0.2.1")
+		srv.SetCNAME("www.good.com", "good.com")
+		srv.SetMX("good.com", "mx.good.com")
+		srv.SetA("drift.com", "192.0.2.10")
+		srv.SetMX("drift.com", "mx.drift.com")
+		srv.SetAAAA("www.drift.com", "2001:db8::1")
+		srv.SetA("proxied.com", "104.16.0.1")
+		srv.SetA("www.proxied.com", "104.16.0.2")
+		srv.SetA("file.com", "192.0.2.30")
+		// 尚未切换 NS 的 Zone 仍由旧服务商应答
+		srv.SetA("pending.com", "198.51.100.1")
+	}
+	// 第二个解析器上 MX 指向旧服务器，www 解析失败，文件域名已被删除
+	secondary.SetMX("drift.com", "old-mx.drift.com")
+	secondary.SetRCode("www.proxied.com", dnsmessage.TypeA, dnsmessage.RCodeServerFailure)
+	secondary.SetRCode("file.com", dnsmessage.TypeA, dnsmessage.RCodeNameError)
+
+	probe := &ResolutionProbe{
+		CF: cf,
+		Resolvers: []RecordResolver{
+			dnsquery.NewClient(primary.Addr, time.Second),
+			dnsquery.NewClient(secondary.Addr, time.Second),
+		},
+		Hosts: []string{"www", "@"},
+	}
+	domains := []domain.DomainSource{
+		{Domain: "good.com", Source: "acc", IsCF: true, Status: "active"},
+		{Domain: "drift.com", Source: "acc", IsCF: true, Status: "active"},
+		{Domain: "proxied.com", Source: "acc", IsCF: true, Status: "active"},
+		{Domain: "pending.com", Source: "acc", IsCF: true, Status: "pending"},
+		{Domain: "file.com", Source: "domains.txt"},
+	}
+	events, err := probe.Run(context.Background(), domains)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := make(map[string]domain.Event)
+	for _, ev := range events {
+		if ev.Kind != domain.EventResolutionFailed {
+			t.Fatalf("unexpected event kind %+v", ev)
+		}
+		got[ev.Domain] = ev
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if ev := got["drift.com"]; ev.Urgent || strings.Count(ev.Detail, "\n") != 0 ||
+		!strings.Contains(ev.Detail, "["+secondary.Addr+"] drift.com MX: 应答 old-mx.drift.com，CF 中为 mx.drift.com") {
+		t.Fatalf("unexpected drift event %+v", ev)
+	}
+	if ev := got["proxied.com"]; !ev.Urgent || !strings.Contains(ev.Detail, "www.proxied.com A: SERVFAIL") || strings.Contains(ev.Detail, primary.Addr) {
+		t.Fatalf("unexpected proxied event %+v", ev)
+	}
+	if ev := got["file.com"]; !ev.Urgent || ev.IsCF || !strings.Contains(ev.Detail, "file.com A: NXDOMAIN") {
+		t.Fatalf("unexpected file domain event %+v", ev)
+	}
+}
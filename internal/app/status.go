@@ -0,0 +1,62 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"DomainC/lookup"
+)
+
+// statusChange 比较上次与本次查询到的 EPP 状态，返回带说明的变化文本，以及是否出现了严重状态。
+// 没有上次结果时只报告严重状态；本次结果不含任何状态时视为未知，不做比较。
+// 转移锁与 pendingTransfer 由 transferEvents 单独报告。
+func statusChange(previous *lookup.Result, current lookup.Result) (detail string, urgent bool, changed bool) {
+	if len(current.Statuses) == 0 {
+		return "", false, false
+	}
+
+	var added, removed []string
+	if previous == nil || len(previous.Statuses) == 0 {
+		for _, s := range current.CriticalStatuses() {
+			if !isTransferStatus(s) {
+				added = append(added, s)
+			}
+		}
+	} else {
+		for _, s := range current.Statuses {
+			if !previous.HasStatus(s) && !isTransferStatus(s) {
+				added = append(added, s)
+			}
+		}
+		for _, s := range previous.Statuses {
+			if !current.HasStatus(s) && !isTransferStatus(s) {
+				removed = append(removed, s)
+			}
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return "", false, false
+	}
+
+	var sb strings.Builder
+	for _, s := range added {
+		info, _ := lookup.DescribeStatus(s)
+		if info.Critical {
+			urgent = true
+		}
+		sb.WriteString(fmt.Sprintf("新增 %s: %s\n", s, statusMeaning(info)))
+	}
+	for _, s := range removed {
+		info, _ := lookup.DescribeStatus(s)
+		sb.WriteString(fmt.Sprintf("解除 %s: %s\n", s, statusMeaning(info)))
+	}
+	sb.WriteString(fmt.Sprintf("当前状态: %s", strings.Join(current.Statuses, ", ")))
+	return sb.String(), urgent, true
+}
+
+func statusMeaning(info lookup.StatusInfo) string {
+	if info.Meaning == "" {
+		return "未收录的状态码，请查阅注册局说明。"
+	}
+	return info.Meaning
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"DomainC/domain"
+	"DomainC/lookup"
+)
+
+// transferLocks 为防止域名被转走的 EPP 状态。
+var transferLocks = []string{"clientTransferProhibited", "serverTransferProhibited"}
+
+const statusPendingTransfer = "pendingTransfer"
+
+// isTransferStatus 表示状态由转移监控单独报告，不再重复出现在状态变化事件中。
+func isTransferStatus(status string) bool {
+	if strings.EqualFold(status, statusPendingTransfer) {
+		return true
+	}
+	for _, l := range transferLocks {
+		if strings.EqualFold(status, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// locksOf 返回结果中存在的转移锁。
+func locksOf(r lookup.Result) []string {
+	var out []string
+	for _, l := range transferLocks {
+		if r.HasStatus(l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// transferAtRisk 判断缓存结果是否显示转移进行中，或曾经有过的转移锁已被解除。
+// 从未出现过转移锁的后缀不算在内。
+func transferAtRisk(st domain.DomainState) bool {
+	if st.Lookup == nil {
+		return false
+	}
+	if st.Lookup.HasStatus(statusPendingTransfer) {
+		return true
+	}
+	if len(st.Lookup.Statuses) == 0 || len(locksOf(*st.Lookup)) > 0 {
+		return false
+	}
+	for _, r := range st.Registrars {
+		if len(r.Locks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// registrarRecord 从查询结果得到注册商与转移锁记录。
+func registrarRecord(r lookup.Result, now time.Time) domain.RegistrarRecord {
+	return domain.RegistrarRecord{At: now, Registrar: strings.TrimSpace(r.Registrar), IANAID: strings.TrimSpace(r.RegistrarIANAID), Locks: locksOf(r)}
+}
+
+// sameRegistrar 优先按 IANA ID 比较，任一方缺少 ID 时按名称（忽略大小写）比较。
+func sameRegistrar(a, b domain.RegistrarRecord) bool {
+	if a.IANAID != "" && b.IANAID != "" {
+		return a.IANAID == b.IANAID
+	}
+	return strings.EqualFold(a.Registrar, b.Registrar)
+}
+
+// transferEvents 比较上次与本次的注册商和转移锁，返回注册商变更、转移锁解除、转移进行中事件，
+// 并在注册商或转移锁变化时更新 st 中的记录。本次结果没有状态时不判断转移锁。
+func transferEvents(st *domain.DomainState, previous *lookup.Result, current lookup.Result, now time.Time) []domain.Event {
+	var events []domain.Event
+	event := func(kind domain.EventKind, detail string) {
+		events = append(events, domain.Event{Kind: kind, Domain: st.Domain, Detail: detail, Urgent: true, At: now})
+	}
+
+	if current.HasStatus(statusPendingTransfer) && (previous == nil || !previous.HasStatus(statusPendingTransfer)) {
+		event(domain.EventTransferPending, fmt.Sprintf("注册商: %s\n域名正在转移到其他注册商，如非本方操作请立即在注册商处拒绝转移。", orDash(current.Registrar)))
+	}
+
+	cur := registrarRecord(current, now)
+	last, ok := st.LastRegistrar()
+	if !ok {
+		if cur.Registrar != "" || cur.IANAID != "" || len(cur.Locks) > 0 {
+			st.AddRegistrar(cur)
+		}
+		return events
+	}
+
+	changed := false
+	if (cur.Registrar != "" || cur.IANAID != "") && !sameRegistrar(last, cur) {
+		if last.Registrar != "" || last.IANAID != "" {
+			event(domain.EventRegistrarChanged, fmt.Sprintf("注册商: %s → %s", describeRegistrar(last), describeRegistrar(cur)))
+		}
+		changed = true
+	} else if cur.Registrar == "" && cur.IANAID == "" {
+		// 本次结果没有注册商信息时沿用上次记录，避免协议切换造成误报
+		cur.Registrar, cur.IANAID = last.Registrar, last.IANAID
+	}
+
+	if len(current.Statuses) > 0 {
+		var removed []string
+		for _, l := range last.Locks {
+			if !current.HasStatus(l) {
+				removed = append(removed, l)
+			}
+		}
+		if len(removed) > 0 {
+			event(domain.EventTransferLockRemoved, fmt.Sprintf("已解除: %s\n当前状态: %s\n域名可以被转出，如非本方操作请立即联系注册商恢复转移锁。", strings.Join(removed, ", "), strings.Join(current.Statuses, ", ")))
+		}
+		if strings.Join(last.Locks, ",") != strings.Join(cur.Locks, ",") {
+			changed = true
+		}
+	} else {
+		cur.Locks = last.Locks
+	}
+
+	if changed {
+		st.AddRegistrar(cur)
+	}
+	return events
+}
+
+func describeRegistrar(r domain.RegistrarRecord) string {
+	if r.IANAID != "" {
+		return fmt.Sprintf("%s (IANA %s)", orDash(r.Registrar), r.IANAID)
+	}
+	return orDash(r.Registrar)
+}
+
+func orDash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,161 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"DomainC/config"
+)
+
+// APIBackend 通过注册商的 HTTP JSON 接口查询到期时间，适合 RDAP/WHOIS 信息不全的后缀。
+type APIBackend struct {
+	name            string
+	urlFormat       string
+	headers         map[string]string
+	registrar       string
+	expiryField     string
+	statusField     string
+	nameServerField string
+	dateLayouts     []string
+	HTTP            *http.Client
+}
+
+// NewAPIBackend 根据配置构造注册商 API 后端。
+func NewAPIBackend(cfg config.RegistrarAPI) (*APIBackend, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Name))
+	if name == "" {
+		return nil, fmt.Errorf("注册商 API 缺少 name: %s", cfg.URL)
+	}
+	if strings.Count(cfg.URL, "%s") != 1 {
+		return nil, fmt.Errorf("注册商 API [%s] 的 url 必须包含一个 %%s", name)
+	}
+	if strings.TrimSpace(cfg.ExpiryField) == "" {
+		return nil, fmt.Errorf("注册商 API [%s] 缺少 expiryField", name)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &APIBackend{
+		name:            name,
+		urlFormat:       cfg.URL,
+		headers:         cfg.Headers,
+		registrar:       cfg.Registrar,
+		expiryField:     cfg.ExpiryField,
+		statusField:     cfg.StatusField,
+		nameServerField: cfg.NameServerField,
+		dateLayouts:     cfg.DateLayouts,
+		HTTP:            &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (a *APIBackend) Name() string { return a.name }
+
+func (a *APIBackend) Lookup(ctx context.Context, domain string) (Result, error) {
+	endpoint := fmt.Sprintf(a.urlFormat, url.PathEscape(domain))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range a.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.HTTP.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Result{}, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return Result{}, fmt.Errorf("%w: 注册商 %s 中没有 %s", ErrNotApplicable, a.name, domain)
+	}
+	if resp.StatusCode >= 300 {
+		return Result{}, fmt.Errorf("注册商 API 返回 %s: %s", resp.Status, firstLine(string(body)))
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return Result{}, fmt.Errorf("解析注册商 API 响应失败: %w", err)
+	}
+
+	result := Result{Domain: domain, Registrar: a.registrar, Protocol: ProtocolAPI, Server: req.URL.Host, Raw: string(body)}
+	for _, v := range jsonStrings(jsonPath(doc, a.expiryField)) {
+		if t, ok := parseDateLayouts(v, a.dateLayouts); ok {
+			result.RegistryExpiry = t
+			result.ExpiryConfidence = 1
+			break
+		}
+	}
+	for _, v := range jsonStrings(jsonPath(doc, a.statusField)) {
+		result.Statuses = appendUnique(result.Statuses, normalizeStatus(v))
+	}
+	for _, v := range jsonStrings(jsonPath(doc, a.nameServerField)) {
+		result.NameServers = appendUnique(result.NameServers, strings.ToLower(strings.TrimSuffix(v, ".")))
+	}
+	return result, nil
+}
+
+// jsonPath 按 "a.b.0.c" 取值，路径为空或不存在时返回 nil。
+func jsonPath(doc interface{}, path string) interface{} {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil
+	}
+	cur := doc
+	for _, part := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			cur = v[part]
+		case []interface{}:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil
+			}
+			cur = v[idx]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+// jsonStrings 将字符串、数字或数组值展开为字符串列表。
+func jsonStrings(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		if strings.TrimSpace(t) == "" {
+			return nil
+		}
+		return []string{t}
+	case float64:
+		// Unix 时间戳按秒处理
+		return []string{time.Unix(int64(t), 0).UTC().Format(time.RFC3339)}
+	case []interface{}:
+		var out []string
+		for _, item := range t {
+			out = append(out, jsonStrings(item)...)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
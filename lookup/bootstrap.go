@@ -0,0 +1,239 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openrdap/rdap/bootstrap"
+)
+
+// DefaultBootstrapURL 为 IANA 发布的 DNS RDAP bootstrap 文件。
+const DefaultBootstrapURL = "https://data.iana.org/rdap/dns.json"
+
+const bootstrapCacheName = "dns.json"
+
+// Bootstrap 维护 TLD → RDAP 服务地址的映射。数据来自本地文件或缓存目录，
+// 可按计划从 IANA 刷新，配置中的覆盖地址优先。
+type Bootstrap struct {
+	// File 为手工维护的 bootstrap 文件，设置后不会被刷新覆盖。
+	File string
+	// CacheDir 为下载数据的缓存目录。
+	CacheDir string
+	// SourceURL 为下载地址，为空时使用 DefaultBootstrapURL。
+	SourceURL string
+	HTTP      *http.Client
+
+	mu        sync.RWMutex
+	entries   map[string][]*url.URL
+	overrides map[string]*url.URL
+}
+
+// NewBootstrap 创建 bootstrap，overrides 的键为 TLD 或后缀（如 "us.com"），值为 RDAP 基础地址。
+func NewBootstrap(file, cacheDir, sourceURL string, overrides map[string]string) (*Bootstrap, error) {
+	b := &Bootstrap{File: file, CacheDir: cacheDir, SourceURL: sourceURL, HTTP: &http.Client{Timeout: 30 * time.Second}}
+	b.overrides = make(map[string]*url.URL, len(overrides))
+	for suffix, raw := range overrides {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("RDAP 覆盖地址无效 [%s]: %s", suffix, raw)
+		}
+		b.overrides[normalizeSuffix(suffix)] = u
+	}
+	return b, nil
+}
+
+// Load 依次尝试本地文件与缓存目录；都不可用时从 SourceURL 下载一次。
+func (b *Bootstrap) Load(ctx context.Context) error {
+	if b.File != "" {
+		data, err := os.ReadFile(b.File)
+		if err != nil {
+			return fmt.Errorf("读取 RDAP bootstrap 文件失败: %w", err)
+		}
+		return b.apply(data)
+	}
+
+	if path := b.cachePath(); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			if err := b.apply(data); err == nil {
+				return nil
+			}
+			log.Printf("RDAP bootstrap 缓存损坏，重新下载: %s", path)
+		}
+	}
+	return b.Refresh(ctx)
+}
+
+// Refresh 从 SourceURL 下载最新数据并写入缓存目录。配置了本地文件时不做任何事。
+func (b *Bootstrap) Refresh(ctx context.Context) error {
+	if b.File != "" {
+		return nil
+	}
+	source := b.SourceURL
+	if source == "" {
+		source = DefaultBootstrapURL
+	}
+	client := b.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return fmt.Errorf("构造 RDAP bootstrap 请求失败: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("下载 RDAP bootstrap 失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("下载 RDAP bootstrap 失败: HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 RDAP bootstrap 失败: %w", err)
+	}
+	if err := b.apply(data); err != nil {
+		return err
+	}
+
+	if path := b.cachePath(); path != "" {
+		if err := writeFileAtomic(path, data); err != nil {
+			log.Printf("写入 RDAP bootstrap 缓存失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// Start 按 interval 在后台刷新数据，ctx 取消后退出。
+func (b *Bootstrap) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || b.File != "" {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := b.Refresh(ctx); err != nil {
+					log.Printf("刷新 RDAP bootstrap 失败，继续使用旧数据: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// ServersFor 返回域名对应的 RDAP 基础地址，按最长后缀匹配，覆盖地址优先。
+// 返回的 URL 为副本，可以安全修改。
+func (b *Bootstrap) ServersFor(domain string) []*url.URL {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	name := normalizeSuffix(domain)
+	for {
+		if u, ok := b.overrides[name]; ok {
+			return []*url.URL{cloneURL(u)}
+		}
+		if urls, ok := b.entries[name]; ok {
+			out := make([]*url.URL, 0, len(urls))
+			for _, u := range urls {
+				out = append(out, cloneURL(u))
+			}
+			return out
+		}
+		idx := strings.IndexByte(name, '.')
+		if idx < 0 {
+			return nil
+		}
+		name = name[idx+1:]
+	}
+}
+
+// Loaded 表示是否已有可用的 bootstrap 数据或覆盖地址。
+func (b *Bootstrap) Loaded() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.entries != nil || len(b.overrides) > 0
+}
+
+func (b *Bootstrap) apply(data []byte) error {
+	file, err := bootstrap.NewFile(data)
+	if err != nil {
+		return fmt.Errorf("解析 RDAP bootstrap 失败: %w", err)
+	}
+	entries := make(map[string][]*url.URL, len(file.Entries))
+	for suffix, urls := range file.Entries {
+		entries[normalizeSuffix(suffix)] = preferHTTPS(urls)
+	}
+
+	b.mu.Lock()
+	b.entries = entries
+	b.mu.Unlock()
+	log.Printf("已加载 RDAP bootstrap: %d 个后缀 (发布于 %s)", len(entries), file.Publication)
+	return nil
+}
+
+func (b *Bootstrap) cachePath() string {
+	if b.CacheDir == "" {
+		return ""
+	}
+	return filepath.Join(b.CacheDir, bootstrapCacheName)
+}
+
+// preferHTTPS 将 https 地址排在前面，与 openrdap 的默认行为一致。
+func preferHTTPS(urls []*url.URL) []*url.URL {
+	out := make([]*url.URL, 0, len(urls))
+	for _, u := range urls {
+		if u.Scheme == "https" {
+			out = append(out, u)
+		}
+	}
+	for _, u := range urls {
+		if u.Scheme != "https" {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+func cloneURL(u *url.URL) *url.URL {
+	c := *u
+	return &c
+}
+
+func normalizeSuffix(s string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(s), "."))
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免进程中断留下半个文件。
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,130 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRDAPDomain = `{
+  "objectClassName": "domain",
+  "ldhName": "%s",
+  "status": ["client transfer prohibited"],
+  "events": [
+    {"eventAction": "registration", "eventDate": "2020-01-08T00:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "2027-01-08T12:00:00Z"}
+  ],
+  "nameservers": [{"objectClassName": "nameserver", "ldhName": "NS1.EXAMPLE.NET"}],
+  "secureDNS": {"delegationSigned": true},
+  "entities": [{
+    "objectClassName": "entity",
+    "roles": ["registrar"],
+    "publicIds": [{"type": "IANA Registrar ID", "identifier": "1910"}],
+    "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Test Registrar"]]]
+  }]
+}`
+
+func newRDAPServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/domain/")
+		if !strings.HasSuffix(name, ".test") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rdap+json")
+		fmt.Fprintf(w, testRDAPDomain, name)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func bootstrapJSON(tld, server string) string {
+	return fmt.Sprintf(`{"version":"1.0","publication":"2026-01-01T00:00:00Z","services":[[["%s"],["%s/"]]]}`, tld, server)
+}
+
+func TestLookupUsesLocalBootstrapFile(t *testing.T) {
+	srv := newRDAPServer(t)
+	path := filepath.Join(t.TempDir(), "dns.json")
+	if err := os.WriteFile(path, []byte(bootstrapJSON("test", srv.URL)), 0o644); err != nil {
+		t.Fatalf("write bootstrap: %v", err)
+	}
+
+	boot, err := NewBootstrap(path, "", "", nil)
+	if err != nil {
+		t.Fatalf("NewBootstrap: %v", err)
+	}
+	if err := boot.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	client := NewClient()
+	client.Bootstrap = boot
+	r, err := client.Lookup(context.Background(), "Example.TEST")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if r.Protocol != ProtocolRDAP {
+		t.Fatalf("expected RDAP result, got %s", r.Protocol)
+	}
+	if want := time.Date(2027, 1, 8, 12, 0, 0, 0, time.UTC); !r.Expiry().Equal(want) {
+		t.Errorf("expected expiry %v, got %v", want, r.Expiry())
+	}
+	if r.Registrar != "Test Registrar" || r.RegistrarIANAID != "1910" {
+		t.Errorf("unexpected registrar %q (%q)", r.Registrar, r.RegistrarIANAID)
+	}
+	if !r.HasStatus("clientTransferProhibited") || !r.DNSSEC {
+		t.Errorf("unexpected status/dnssec: %v %v", r.Statuses, r.DNSSEC)
+	}
+	if len(r.NameServers) != 1 || r.NameServers[0] != "ns1.example.net" {
+		t.Errorf("unexpected name servers %v", r.NameServers)
+	}
+	if !strings.HasPrefix(srv.URL, "http://"+r.Server) {
+		t.Errorf("expected server %s, got %s", srv.URL, r.Server)
+	}
+}
+
+func TestBootstrapOverridesAndCache(t *testing.T) {
+	srv := newRDAPServer(t)
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, bootstrapJSON("com", "https://rdap.example.com"))
+	}))
+	defer source.Close()
+
+	cacheDir := t.TempDir()
+	boot, err := NewBootstrap("", cacheDir, source.URL, map[string]string{"us.test": srv.URL})
+	if err != nil {
+		t.Fatalf("NewBootstrap: %v", err)
+	}
+	if err := boot.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, bootstrapCacheName)); err != nil {
+		t.Fatalf("expected bootstrap to be cached: %v", err)
+	}
+
+	if got := boot.ServersFor("a.b.us.test"); len(got) != 1 || got[0].String() != srv.URL {
+		t.Errorf("expected override for us.test, got %v", got)
+	}
+	if got := boot.ServersFor("example.com"); len(got) != 1 || got[0].Host != "rdap.example.com" {
+		t.Errorf("expected IANA entry for com, got %v", got)
+	}
+	if got := boot.ServersFor("example.ph"); len(got) != 0 {
+		t.Errorf("expected no server for ph, got %v", got)
+	}
+
+	// 缓存可在离线时重新加载
+	offline, _ := NewBootstrap("", cacheDir, "http://127.0.0.1:0/unreachable", nil)
+	if err := offline.Load(context.Background()); err != nil {
+		t.Fatalf("expected cached bootstrap to load offline: %v", err)
+	}
+	if got := offline.ServersFor("example.com"); len(got) != 1 {
+		t.Errorf("expected cached entry for com, got %v", got)
+	}
+}
@@ -0,0 +1,307 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/config"
+)
+
+// 内置后端名称。
+const (
+	BackendRDAP   = "rdap"
+	BackendWHOIS  = "whois"
+	BackendManual = "manual"
+)
+
+// DefaultBackends 为未匹配任何策略时的查询顺序。
+var DefaultBackends = []string{BackendRDAP, BackendWHOIS}
+
+// ErrNotApplicable 表示后端不负责该域名，例如手工配置中没有这个域名，链路会直接尝试下一个后端。
+var ErrNotApplicable = errors.New("后端不适用")
+
+// Backend 为一种查询注册信息的方式（RDAP、WHOIS、注册商 API、手工配置等）。
+type Backend interface {
+	Name() string
+	Lookup(ctx context.Context, domain string) (Result, error)
+}
+
+// Strategy 为一个后缀或来源指定查询顺序，Source 非空时优先于 Suffix 匹配。
+type Strategy struct {
+	Suffix   string
+	Source   string
+	Backends []string
+}
+
+// Chain 按策略依次调用后端，返回第一个带到期时间的结果，并在 Result.Backend 中记录来源。
+type Chain struct {
+	mu         sync.RWMutex
+	backends   map[string]Backend
+	bySource   map[string][]string
+	bySuffix   map[string][]string
+	defaultSeq []string
+}
+
+// NewChain 返回包含 client 的 RDAP/WHOIS 后端、默认顺序为 RDAP→WHOIS 的查询链。
+func NewChain(client *Client) *Chain {
+	if client == nil {
+		client = NewClient()
+	}
+	c := &Chain{
+		backends:   make(map[string]Backend),
+		bySource:   make(map[string][]string),
+		bySuffix:   make(map[string][]string),
+		defaultSeq: DefaultBackends,
+	}
+	c.Register(rdapBackend{client})
+	c.Register(whoisBackend{client})
+	return c
+}
+
+// NewChainFromConfig 根据 lookup 配置注册手工值、注册商 API，并加载查询策略。
+func NewChainFromConfig(client *Client, cfg config.Lookup) (*Chain, error) {
+	c := NewChain(client)
+	if len(cfg.Manual) > 0 {
+		manual, err := NewManualBackend(cfg.Manual)
+		if err != nil {
+			return nil, err
+		}
+		c.Register(manual)
+	}
+	for _, api := range cfg.RegistrarAPIs {
+		backend, err := NewAPIBackend(api)
+		if err != nil {
+			return nil, err
+		}
+		if c.has(backend.Name()) {
+			return nil, fmt.Errorf("注册商 API 名称 %s 与已有后端重复", backend.Name())
+		}
+		c.Register(backend)
+	}
+	if len(cfg.Backends) > 0 {
+		if err := c.SetDefault(cfg.Backends); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range cfg.Strategies {
+		if err := c.AddStrategy(Strategy{Suffix: s.Suffix, Source: s.Source, Backends: s.Backends}); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Register 注册或替换同名后端。
+func (c *Chain) Register(b Backend) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.backends[strings.ToLower(b.Name())] = b
+}
+
+func (c *Chain) has(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.backends[strings.ToLower(name)]
+	return ok
+}
+
+// SetDefault 设置未匹配任何策略时的查询顺序。
+func (c *Chain) SetDefault(backends []string) error {
+	seq, err := c.sequence(backends)
+	if err != nil {
+		return fmt.Errorf("默认查询顺序: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultSeq = seq
+	return nil
+}
+
+// AddStrategy 添加按来源或后缀的查询顺序，引用未注册的后端时返回错误。
+func (c *Chain) AddStrategy(s Strategy) error {
+	source := strings.TrimSpace(s.Source)
+	suffix := normalizeSuffix(s.Suffix)
+	if source == "" && suffix == "" {
+		return fmt.Errorf("查询策略需要 suffix 或 source: %+v", s)
+	}
+	seq, err := c.sequence(s.Backends)
+	if err != nil {
+		return fmt.Errorf("查询策略 [%s%s]: %w", source, suffix, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if source != "" {
+		c.bySource[source] = seq
+	} else {
+		c.bySuffix[suffix] = seq
+	}
+	return nil
+}
+
+func (c *Chain) sequence(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, errors.New("backends 不能为空")
+	}
+	seq := make([]string, 0, len(names))
+	for _, n := range names {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if !c.has(n) {
+			return nil, fmt.Errorf("未知的查询后端 %s", n)
+		}
+		seq = append(seq, n)
+	}
+	return seq, nil
+}
+
+// Backends 返回域名在某个来源下的查询顺序：先按来源匹配，再按最长后缀匹配，最后为默认顺序。
+func (c *Chain) Backends(domain, source string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if seq, ok := c.bySource[strings.TrimSpace(source)]; ok {
+		return seq
+	}
+	for name := normalizeSuffix(domain); ; {
+		if seq, ok := c.bySuffix[name]; ok {
+			return seq
+		}
+		idx := strings.IndexByte(name, '.')
+		if idx < 0 {
+			return c.defaultSeq
+		}
+		name = name[idx+1:]
+	}
+}
+
+// Lookup 按默认策略查询，不区分来源。
+func (c *Chain) Lookup(ctx context.Context, domain string) (Result, error) {
+	return c.LookupSource(ctx, domain, "")
+}
+
+// LookupSource 按来源与后缀选择查询顺序。某个后端给出到期时间时立即返回；
+// 都没有到期时间时返回最后一个成功的结果；全部失败时返回最后一个错误。
+func (c *Chain) LookupSource(ctx context.Context, domain, source string) (Result, error) {
+	domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+	names := c.Backends(domain, source)
+
+	c.mu.RLock()
+	backends := make([]Backend, 0, len(names))
+	for _, name := range names {
+		backends = append(backends, c.backends[name])
+	}
+	c.mu.RUnlock()
+	return runBackends(ctx, domain, backends)
+}
+
+// RateKey 返回按 source 的查询顺序查询 domain 时第一个后端访问的服务器，与 LookupSource 实际使用的顺序一致，
+// 用作限流键，使共用同一 RDAP/WHOIS 服务器的后缀一起限速；无法确定服务器时返回空字符串，由调用方按公共后缀限流。
+func (c *Chain) RateKey(domain, source string) string {
+	domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+	names := c.Backends(domain, source)
+	if len(names) == 0 {
+		return ""
+	}
+	c.mu.RLock()
+	first := c.backends[names[0]]
+	c.mu.RUnlock()
+	switch b := first.(type) {
+	case rdapBackend:
+		if host := b.c.rdapServer(domain); host != "" {
+			return "rdap:" + host
+		}
+	case whoisBackend:
+		if host := b.c.whoisServer(domain); host != "" {
+			return "whois:" + host
+		}
+	case *APIBackend:
+		return "api:" + b.Name()
+	}
+	return ""
+}
+
+func runBackends(ctx context.Context, domain string, backends []Backend) (Result, error) {
+	var partial *Result
+	var lastErr, notRegistered error
+	for i, b := range backends {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		result, err := b.Lookup(ctx, domain)
+		if err == nil {
+			if result.Backend == "" {
+				result.Backend = b.Name()
+			}
+			if result.HasExpiry() {
+				return result, nil
+			}
+			partial = &result
+			continue
+		}
+
+		lastErr = fmt.Errorf("%s错误: %w", strings.ToUpper(b.Name()), err)
+		if errors.As(err, new(*NotRegisteredError)) {
+			notRegistered = lastErr
+		}
+		if i < len(backends)-1 && !errors.Is(err, ErrNotApplicable) && !errors.Is(err, ErrNoRDAPServer) {
+			log.Printf("%s 查询失败，尝试下一个后端 (%s): %v", strings.ToUpper(b.Name()), domain, err)
+		}
+	}
+	// 注册局明确应答未注册时，不用其他后端缺少到期时间的部分结果掩盖
+	if notRegistered != nil {
+		return Result{}, notRegistered
+	}
+	if partial != nil {
+		return *partial, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("%s 没有可用的查询后端", domain)
+	}
+	return Result{}, lastErr
+}
+
+type rdapBackend struct{ c *Client }
+
+func (b rdapBackend) Name() string { return BackendRDAP }
+func (b rdapBackend) Lookup(ctx context.Context, domain string) (Result, error) {
+	return b.c.queryRDAP(ctx, domain)
+}
+
+type whoisBackend struct{ c *Client }
+
+func (b whoisBackend) Name() string { return BackendWHOIS }
+func (b whoisBackend) Lookup(ctx context.Context, domain string) (Result, error) {
+	return b.c.queryWhois(ctx, domain)
+}
+
+// ManualBackend 返回手工配置的到期时间，用于没有公开 RDAP/WHOIS 的后缀。
+type ManualBackend struct {
+	expiries map[string]time.Time
+}
+
+// NewManualBackend 解析 "域名: 日期" 形式的配置，日期格式同 ParseDate。
+func NewManualBackend(values map[string]string) (*ManualBackend, error) {
+	m := &ManualBackend{expiries: make(map[string]time.Time, len(values))}
+	for domain, value := range values {
+		t, ok := ParseDate(value)
+		if !ok {
+			return nil, fmt.Errorf("手工到期时间格式错误 %s: %s", domain, value)
+		}
+		m.expiries[normalizeSuffix(domain)] = t
+	}
+	return m, nil
+}
+
+func (m *ManualBackend) Name() string { return BackendManual }
+
+func (m *ManualBackend) Lookup(ctx context.Context, domain string) (Result, error) {
+	t, ok := m.expiries[normalizeSuffix(domain)]
+	if !ok {
+		return Result{}, fmt.Errorf("%w: 未手工配置 %s", ErrNotApplicable, domain)
+	}
+	return Result{Domain: domain, RegistryExpiry: t, ExpiryConfidence: 1, Protocol: ProtocolManual}, nil
+}
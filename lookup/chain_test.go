@@ -0,0 +1,218 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"DomainC/config"
+)
+
+type stubBackend struct {
+	name   string
+	result Result
+	err    error
+	calls  int
+}
+
+func (s *stubBackend) Name() string { return s.name }
+
+func (s *stubBackend) Lookup(ctx context.Context, domain string) (Result, error) {
+	s.calls++
+	if s.err != nil {
+		return Result{}, s.err
+	}
+	r := s.result
+	r.Domain = domain
+	return r, nil
+}
+
+func TestChainSelectsStrategyBySourceAndSuffix(t *testing.T) {
+	c := NewChain(NewClient())
+	c.Register(&stubBackend{name: "registrar"})
+	c.Register(&stubBackend{name: "manual"})
+
+	if err := c.AddStrategy(Strategy{Suffix: "co.uk", Backends: []string{"whois"}}); err != nil {
+		t.Fatalf("AddStrategy suffix: %v", err)
+	}
+	if err := c.AddStrategy(Strategy{Suffix: "uk", Backends: []string{"manual"}}); err != nil {
+		t.Fatalf("AddStrategy suffix: %v", err)
+	}
+	if err := c.AddStrategy(Strategy{Source: "godaddy", Backends: []string{"registrar", "rdap"}}); err != nil {
+		t.Fatalf("AddStrategy source: %v", err)
+	}
+	if err := c.AddStrategy(Strategy{Suffix: "io", Backends: []string{"missing"}}); err == nil {
+		t.Fatalf("expected unknown backend error")
+	}
+
+	cases := []struct {
+		domain, source string
+		want           []string
+	}{
+		{"shop.example.co.uk", "", []string{"whois"}},
+		{"example.org.uk", "", []string{"manual"}},
+		{"example.co.uk", "godaddy", []string{"registrar", "rdap"}},
+		{"example.com", "", DefaultBackends},
+	}
+	for _, tc := range cases {
+		got := c.Backends(tc.domain, tc.source)
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s/%s: got %v, want %v", tc.domain, tc.source, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Fatalf("%s/%s: got %v, want %v", tc.domain, tc.source, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestChainFallsThroughAndRecordsBackend(t *testing.T) {
+	expiry := time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)
+	skip := &stubBackend{name: "first", err: ErrNotApplicable}
+	partial := &stubBackend{name: "second", result: Result{Registrar: "Example Registrar"}}
+	full := &stubBackend{name: "third", result: Result{RegistryExpiry: expiry}}
+	unused := &stubBackend{name: "fourth", result: Result{RegistryExpiry: expiry}}
+
+	c := NewChain(NewClient())
+	for _, b := range []Backend{skip, partial, full, unused} {
+		c.Register(b)
+	}
+	if err := c.SetDefault([]string{"first", "second", "third", "fourth"}); err != nil {
+		t.Fatalf("SetDefault: %v", err)
+	}
+
+	result, err := c.Lookup(context.Background(), "Example.COM.")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if result.Backend != "third" || !result.Expiry().Equal(expiry) || result.Domain != "example.com" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if unused.calls != 0 {
+		t.Fatalf("chain should stop at the first result with an expiry")
+	}
+
+	if err := c.SetDefault([]string{"first", "second"}); err != nil {
+		t.Fatalf("SetDefault: %v", err)
+	}
+	result, err = c.Lookup(context.Background(), "example.com")
+	if err != nil || result.Backend != "second" || result.Registrar != "Example Registrar" {
+		t.Fatalf("expected partial result from second backend, got %+v, %v", result, err)
+	}
+
+	if err := c.SetDefault([]string{"first"}); err != nil {
+		t.Fatalf("SetDefault: %v", err)
+	}
+	if _, err := c.Lookup(context.Background(), "example.com"); !errors.Is(err, ErrNotApplicable) {
+		t.Fatalf("expected ErrNotApplicable, got %v", err)
+	}
+
+	dropped := &stubBackend{name: "dropped", err: &NotRegisteredError{Domain: "example.com", Line: "No match for \"EXAMPLE.COM\"."}}
+	c.Register(dropped)
+	if err := c.SetDefault([]string{"second", "dropped"}); err != nil {
+		t.Fatalf("SetDefault: %v", err)
+	}
+	var notRegistered *NotRegisteredError
+	if _, err := c.Lookup(context.Background(), "example.com"); !errors.As(err, &notRegistered) {
+		t.Fatalf("expected not-registered answer to win over partial result, got %v", err)
+	}
+}
+
+func TestChainFromConfigManualAndAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/domains/example.io":
+			w.Write([]byte(`{"data":{"expires":"02/01/2031","status":["clientTransferProhibited"],"nameservers":[{"host":"NS1.Example.net."}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Lookup{
+		Manual: map[string]string{"example.ly": "2029-05-06"},
+		RegistrarAPIs: []config.RegistrarAPI{{
+			Name:            "dynadot",
+			URL:             srv.URL + "/domains/%s",
+			Headers:         map[string]string{"X-Api-Key": "secret"},
+			Registrar:       "Dynadot",
+			ExpiryField:     "data.expires",
+			StatusField:     "data.status",
+			NameServerField: "data.nameservers.0.host",
+			DateLayouts:     []string{"02/01/2006"},
+		}},
+		Strategies: []config.LookupStrategy{
+			{Suffix: "ly", Backends: []string{"manual"}},
+			{Source: "dynadot", Backends: []string{"dynadot", "manual"}},
+		},
+	}
+	c, err := NewChainFromConfig(NewClient(), cfg)
+	if err != nil {
+		t.Fatalf("NewChainFromConfig: %v", err)
+	}
+
+	manual, err := c.Lookup(context.Background(), "example.ly")
+	if err != nil {
+		t.Fatalf("manual lookup: %v", err)
+	}
+	if manual.Backend != BackendManual || manual.Protocol != ProtocolManual || manual.Expiry().Format("2006-01-02") != "2029-05-06" {
+		t.Fatalf("unexpected manual result %+v", manual)
+	}
+
+	api, err := c.LookupSource(context.Background(), "example.io", "dynadot")
+	if err != nil {
+		t.Fatalf("api lookup: %v", err)
+	}
+	if api.Backend != "dynadot" || api.Protocol != ProtocolAPI || api.Registrar != "Dynadot" {
+		t.Fatalf("unexpected api result %+v", api)
+	}
+	if api.Expiry().Format("2006-01-02") != "2031-01-02" {
+		t.Fatalf("unexpected api expiry %v", api.Expiry())
+	}
+	if !api.HasStatus("clientTransferProhibited") || len(api.NameServers) != 1 || api.NameServers[0] != "ns1.example.net" {
+		t.Fatalf("unexpected api status/ns %+v", api)
+	}
+
+	// 注册商 404 时回退到手工配置，两者都没有时报告不适用
+	if _, err := c.LookupSource(context.Background(), "other.io", "dynadot"); !errors.Is(err, ErrNotApplicable) {
+		t.Fatalf("expected ErrNotApplicable, got %v", err)
+	}
+
+	if _, err := NewChainFromConfig(NewClient(), config.Lookup{Backends: []string{"rdap", "epp"}}); err == nil {
+		t.Fatalf("expected error for unknown default backend")
+	}
+}
+
+func TestChainRateKeyUsesFirstBackendServer(t *testing.T) {
+	client := NewClient()
+	client.WhoisRules = NewWhoisRules()
+	client.WhoisRules.Add(WhoisRule{Suffix: "ph", Server: "whois.dot.ph"})
+	c := NewChain(client)
+	if err := c.AddStrategy(Strategy{Suffix: "ph", Backends: []string{"whois"}}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+
+	if got := c.RateKey("Example.com.ph", ""); got != "whois:whois.dot.ph" {
+		t.Fatalf("expected WHOIS server key, got %q", got)
+	}
+	// 未加载 bootstrap 时无法确定 RDAP 服务器，由调用方按后缀限流
+	if got := c.RateKey("example.com", ""); got != "" {
+		t.Fatalf("expected empty key without RDAP bootstrap, got %q", got)
+	}
+	// 来源策略改变第一个后端时限流键随之改变
+	client.WhoisRules.Add(WhoisRule{Suffix: "com", Server: "whois.verisign-grs.com"})
+	if err := c.AddStrategy(Strategy{Source: "legacy", Backends: []string{"whois", "rdap"}}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if got := c.RateKey("example.com", "legacy"); got != "whois:whois.verisign-grs.com" {
+		t.Fatalf("expected source strategy to pick the WHOIS server key, got %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package lookup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// notFoundPatterns 为常见注册局对不存在域名的应答（小写匹配）。只收录注册局特有的完整说法，
+// 避免 "not found" 之类的泛化词误伤包含该词的正常应答；其他后缀通过 WHOIS 规则的 notFound 补充。
+var notFoundPatterns = []string{
+	"no match for",
+	"no match!!",
+	"no data found",
+	"no entries found",
+	"no object found",
+	"domain not found",
+	"object does not exist",
+	"status: free",
+	"status: available",
+	"is available for registration",
+	"no matching record",
+	"the queried object does not exist",
+}
+
+// throttledPatterns 为常见的限流应答（小写匹配）。
+var throttledPatterns = []string{
+	"limit exceeded",
+	"rate limit",
+	"too many requests",
+	"too many queries",
+	"queries exceeded",
+	"quota exceeded",
+	"try again later",
+	"request limit",
+}
+
+// NotRegisteredError 表示注册局明确应答域名不存在，Line 为命中的原文。
+type NotRegisteredError struct {
+	Domain string
+	Line   string
+}
+
+func (e *NotRegisteredError) Error() string {
+	return fmt.Sprintf("%s 未注册: %s", e.Domain, e.Line)
+}
+
+// NotFoundLine 判断 WHOIS 原文是否为"域名不存在"应答，返回命中的那一行。
+func NotFoundLine(raw string) (string, bool) {
+	return matchLine(raw, notFoundPatterns)
+}
+
+// ThrottledLine 判断 WHOIS 原文是否为限流应答，返回命中的那一行。
+func ThrottledLine(raw string) (string, bool) {
+	return matchLine(raw, throttledPatterns)
+}
+
+// notFoundLine 先匹配后缀规则中配置的应答，再匹配通用应答。
+func notFoundLine(raw string, extra []string) (string, bool) {
+	if line, ok := matchLine(raw, extra); ok {
+		return line, true
+	}
+	return NotFoundLine(raw)
+}
+
+func matchLine(raw string, patterns []string) (string, bool) {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+		if line == "" || strings.HasPrefix(lower, "%") || strings.HasPrefix(lower, ">>>") ||
+			strings.HasPrefix(lower, "notice:") || strings.Contains(lower, "terms of use") {
+			continue
+		}
+		for _, p := range patterns {
+			if p != "" && strings.Contains(lower, strings.ToLower(p)) {
+				return line, true
+			}
+		}
+	}
+	return "", false
+}
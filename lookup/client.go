@@ -0,0 +1,243 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/likexian/whois"
+	"github.com/openrdap/rdap"
+)
+
+// ErrNoRDAPServer 表示 bootstrap 数据中没有该域名后缀的 RDAP 服务。
+var ErrNoRDAPServer = errors.New("no RDAP server for domain")
+
+// Client 先查询 RDAP，拿不到到期时间时回退到 WHOIS。
+type Client struct {
+	HTTP *http.Client
+	// Bootstrap 决定 RDAP 服务地址；为空时由 openrdap 每次在线获取 IANA 数据。
+	Bootstrap *Bootstrap
+	// WhoisRules 按后缀指定 WHOIS 服务器与解析器，未匹配时使用通用逻辑。
+	WhoisRules   *WhoisRules
+	WhoisTimeout time.Duration
+}
+
+// NewClient 返回使用默认超时的查询客户端。
+func NewClient() *Client {
+	return &Client{HTTP: &http.Client{}, WhoisTimeout: 15 * time.Second}
+}
+
+var defaultClient = NewClient()
+
+// SetDefaultClient 替换包级默认客户端，nil 时忽略。
+func SetDefaultClient(c *Client) {
+	if c != nil {
+		defaultClient = c
+	}
+}
+
+// Lookup 使用默认客户端查询域名。
+func Lookup(ctx context.Context, domain string) (Result, error) {
+	return defaultClient.Lookup(ctx, domain)
+}
+
+// Lookup 查询域名注册信息。RDAP 给出到期时间时直接返回，否则回退到 WHOIS；
+// WHOIS 也失败时返回已拿到的 RDAP 结果。需要按后缀或来源调整顺序时使用 Chain。
+func (c *Client) Lookup(ctx context.Context, domain string) (Result, error) {
+	domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+	return runBackends(ctx, domain, []Backend{rdapBackend{c}, whoisBackend{c}})
+}
+
+func (c *Client) queryRDAP(ctx context.Context, domain string) (Result, error) {
+	client := &rdap.Client{HTTP: c.HTTP}
+	req := rdap.NewDomainRequest(domain).WithContext(ctx)
+	if c.Bootstrap == nil {
+		return c.doRDAP(client, domain, req)
+	}
+
+	servers := c.Bootstrap.ServersFor(domain)
+	if len(servers) == 0 {
+		return Result{}, fmt.Errorf("%w: %s", ErrNoRDAPServer, domain)
+	}
+	var lastErr error
+	for _, server := range servers {
+		result, err := c.doRDAP(client, domain, req.WithServer(server))
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return Result{}, lastErr
+}
+
+// rdapServer 返回 domain 的首选 RDAP 服务器主机名，未加载 bootstrap 或没有服务时返回空。
+func (c *Client) rdapServer(domain string) string {
+	if c.Bootstrap == nil {
+		return ""
+	}
+	servers := c.Bootstrap.ServersFor(domain)
+	if len(servers) == 0 {
+		return ""
+	}
+	return servers[0].Host
+}
+
+// whoisServer 返回 WHOIS 规则中为 domain 指定的服务器，自动发现的服务器无法提前得知，返回空。
+func (c *Client) whoisServer(domain string) string {
+	rule, _ := c.WhoisRules.Match(domain)
+	return rule.Server
+}
+
+func (c *Client) doRDAP(client *rdap.Client, domain string, req *rdap.Request) (Result, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	d, ok := resp.Object.(*rdap.Domain)
+	if !ok {
+		return Result{}, fmt.Errorf("RDAP 返回了非域名对象: %T", resp.Object)
+	}
+
+	result := FromRDAP(domain, d)
+	if n := len(resp.HTTP); n > 0 {
+		last := resp.HTTP[n-1]
+		result.Raw = string(last.Body)
+		if u, err := url.Parse(last.URL); err == nil {
+			result.Server = u.Host
+		}
+	}
+	return result, nil
+}
+
+func (c *Client) queryWhois(ctx context.Context, domain string) (Result, error) {
+	rule, matched := c.WhoisRules.Match(domain)
+
+	var data string
+	var err error
+	if matched && rule.Server != "" {
+		query := domain
+		if rule.Query != "" {
+			query = fmt.Sprintf(rule.Query, domain)
+		}
+		data, err = queryWhoisServer(ctx, rule.Server, query, c.WhoisTimeout)
+	} else {
+		data, err = c.queryWhoisDefault(ctx, domain)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	var parser Parser = ParserFunc(ParseWhois)
+	if matched && rule.Parser != nil {
+		parser = rule.Parser
+	}
+	result := parser.Parse(domain, data)
+	if result.Server == "" && matched {
+		result.Server = rule.Server
+	}
+	if !result.HasExpiry() {
+		if line, ok := notFoundLine(data, rule.NotFound); ok {
+			return result, &NotRegisteredError{Domain: domain, Line: line}
+		}
+	}
+	return result, nil
+}
+
+// queryWhoisDefault 使用 likexian/whois 自动发现服务器并跟随 referral。
+func (c *Client) queryWhoisDefault(ctx context.Context, domain string) (string, error) {
+	type response struct {
+		data string
+		err  error
+	}
+	ch := make(chan response, 1)
+	go func() {
+		client := whois.NewClient()
+		if c.WhoisTimeout > 0 {
+			client.SetTimeout(c.WhoisTimeout)
+		}
+		data, err := client.Whois(domain)
+		ch <- response{data: data, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case res := <-ch:
+		return res.data, res.err
+	}
+}
+
+// FromRDAP 将 RDAP 域名对象转换为结构化结果。
+func FromRDAP(domain string, d *rdap.Domain) Result {
+	result := Result{Domain: domain, Protocol: ProtocolRDAP}
+	for _, event := range d.Events {
+		t, ok := ParseDate(event.Date)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(event.Action) {
+		case "expiration":
+			result.RegistryExpiry = t
+		case "registrar expiration":
+			result.RegistrarExpiry = t
+		case "registration":
+			result.Created = t
+		case "last changed":
+			result.Updated = t
+		}
+	}
+
+	if result.HasExpiry() {
+		result.ExpiryConfidence = 1
+	}
+	for _, s := range d.Status {
+		result.Statuses = appendUnique(result.Statuses, normalizeStatus(s))
+	}
+	for _, ns := range d.Nameservers {
+		result.NameServers = appendUnique(result.NameServers, strings.ToLower(strings.TrimSuffix(ns.LDHName, ".")))
+	}
+	if d.SecureDNS != nil {
+		for _, ds := range d.SecureDNS.DS {
+			if ds.KeyTag != nil {
+				result.DSKeyTags = append(result.DSKeyTags, int(*ds.KeyTag))
+			}
+		}
+		if d.SecureDNS.DelegationSigned != nil {
+			result.DNSSEC = *d.SecureDNS.DelegationSigned
+		} else {
+			result.DNSSEC = len(d.SecureDNS.DS) > 0
+		}
+	}
+
+	for _, e := range d.Entities {
+		if !hasRole(e.Roles, "registrar") {
+			continue
+		}
+		if e.VCard != nil {
+			result.Registrar = e.VCard.Name()
+		}
+		for _, id := range e.PublicIDs {
+			if strings.EqualFold(id.Type, "IANA Registrar ID") {
+				result.RegistrarIANAID = id.Identifier
+			}
+		}
+		break
+	}
+	return result
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package lookup
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts 按从具体到宽松的顺序排列，带时区的格式在前，
+// 解析时数字月日（"1"、"2"）也能匹配两位写法。
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05-07",
+	"2006-01-02 15:04:05 MST",
+	"2006-1-2 15:04:05",
+	"2006-1-2 15:04",
+	"2006-1-2",
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006/1/2",
+	"2006.1.2 15:04:05",
+	"2006.1.2",
+	"2.1.2006 15:04:05",
+	"2.1.2006",
+	"2-Jan-2006 15:04:05",
+	"2-Jan-2006",
+	"2-January-2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"Mon Jan _2 15:04:05 2006",
+	"Mon Jan _2 15:04:05 MST 2006",
+	"Mon, 02 Jan 2006 15:04:05 -0700",
+	"20060102",
+}
+
+// zoneOffsets 为 WHOIS 中常见的时区缩写（秒）。CST 在注册局应答中多指中国标准时间。
+var zoneOffsets = map[string]int{
+	"UTC":  0,
+	"GMT":  0,
+	"Z":    0,
+	"JST":  9 * 3600,
+	"KST":  9 * 3600,
+	"CST":  8 * 3600,
+	"HKT":  8 * 3600,
+	"SGT":  8 * 3600,
+	"AWST": 8 * 3600,
+	"ICT":  7 * 3600,
+	"MSK":  3 * 3600,
+	"EET":  2 * 3600,
+	"EEST": 3 * 3600,
+	"CET":  1 * 3600,
+	"CEST": 2 * 3600,
+	"BST":  1 * 3600,
+	"EST":  -5 * 3600,
+	"EDT":  -4 * 3600,
+	"PST":  -8 * 3600,
+	"PDT":  -7 * 3600,
+	"AEST": 10 * 3600,
+	"AEDT": 11 * 3600,
+	"NZST": 12 * 3600,
+}
+
+// cjkDate 将 "2026年01月08日" 写法替换为 "2026-01-08"。
+var cjkDate = strings.NewReplacer("年", "-", "月", "-", "日", " ", "時", ":", "时", ":", "分", ":", "秒", " ")
+
+// ParseDate 按常见的注册局日期格式解析字符串，支持中日文日期、"(JST)" 等时区缩写、
+// "UTC+8" 偏移以及 Unix 时间戳（秒或毫秒）。未注明时区时按 UTC 处理。
+func ParseDate(value string) (time.Time, bool) {
+	return parseDateLayouts(value, nil)
+}
+
+// parseDateLayouts 先尝试自定义格式，再按内置格式解析。
+func parseDateLayouts(value string, layouts []string) (time.Time, bool) {
+	cleaned, loc := cleanDate(value)
+	if cleaned == "" {
+		return time.Time{}, false
+	}
+	if t, ok := parseEpoch(cleaned); ok {
+		return t, true
+	}
+	for _, set := range [][]string{layouts, dateLayouts} {
+		for _, layout := range set {
+			if t, err := time.ParseInLocation(layout, cleaned, loc); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// cleanDate 规整空白与中日文日期写法，并去掉末尾的时区缩写，返回对应的时区。
+func cleanDate(value string) (string, *time.Location) {
+	cleaned := strings.TrimSpace(strings.Trim(strings.TrimSpace(value), ":"))
+	cleaned = cjkDate.Replace(cleaned)
+	fields := strings.Fields(cleaned)
+	loc := time.UTC
+	if n := len(fields); n > 1 {
+		if zone, ok := parseZone(fields[n-1]); ok {
+			loc = zone
+			fields = fields[:n-1]
+		}
+	}
+	cleaned = strings.Join(fields, " ")
+	cleaned = strings.TrimRight(cleaned, "-:")
+	return cleaned, loc
+}
+
+// parseZone 识别 "JST"、"(JST)"、"UTC+8"、"(GMT+05:30)" 等时区写法。
+func parseZone(token string) (*time.Location, bool) {
+	token = strings.ToUpper(strings.Trim(token, "()[]"))
+	if offset, ok := zoneOffsets[token]; ok {
+		return time.FixedZone(token, offset), true
+	}
+	for _, prefix := range []string{"UTC", "GMT"} {
+		if !strings.HasPrefix(token, prefix) || len(token) <= len(prefix)+1 {
+			continue
+		}
+		rest := token[len(prefix):]
+		sign := 1
+		switch rest[0] {
+		case '+':
+		case '-':
+			sign = -1
+		default:
+			return nil, false
+		}
+		hours, minutes := rest[1:], "0"
+		if idx := strings.IndexByte(hours, ':'); idx >= 0 {
+			hours, minutes = hours[:idx], hours[idx+1:]
+		}
+		h, err1 := strconv.Atoi(hours)
+		m, err2 := strconv.Atoi(minutes)
+		if err1 != nil || err2 != nil || h > 14 || m > 59 {
+			return nil, false
+		}
+		return time.FixedZone(token, sign*(h*3600+m*60)), true
+	}
+	return nil, false
+}
+
+// parseEpoch 解析 10 位秒级或 13 位毫秒级的 Unix 时间戳。
+func parseEpoch(value string) (time.Time, bool) {
+	if len(value) != 10 && len(value) != 13 {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+	if len(value) == 13 {
+		return time.UnixMilli(n).UTC(), true
+	}
+	return time.Unix(n, 0).UTC(), true
+}
@@ -0,0 +1,174 @@
+package lookup
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExpiryKind 表示到期时间字段的来源类别，决定候选值的基础分。
+type ExpiryKind int
+
+const (
+	// ExpiryGeneric 为 "Expires"、"paid-till"、"有効期限" 等未区分注册局/注册商的字段。
+	ExpiryGeneric ExpiryKind = iota
+	// ExpiryRegistrar 为注册商给出的到期时间。
+	ExpiryRegistrar
+	// ExpiryRegistry 为注册局给出的到期时间。
+	ExpiryRegistry
+)
+
+// expiryScores 为各类字段的基础置信度。
+var expiryScores = map[ExpiryKind]float64{
+	ExpiryRegistry:  1.0,
+	ExpiryRegistrar: 0.8,
+	ExpiryGeneric:   0.6,
+}
+
+// registryExpiryKeys、registrarExpiryKeys 与 genericExpiryKeys 为字段名（小写）包含的关键字，按顺序匹配。
+var (
+	registryExpiryKeys  = []string{"registry expiry", "registry expiration"}
+	registrarExpiryKeys = []string{"registrar registration expiration", "registrar expiration", "registrar expiry"}
+	genericExpiryKeys   = []string{"expir", "paid-till", "paid till", "valid until", "renewal date", "有効期限", "到期", "过期", "過期", "만료"}
+	// notExpiryKeys 排除 "Expiration Notice" 之类含关键字但不是日期的字段
+	notExpiryKeys = []string{"notice", "reminder", "policy", "created", "updated", "creation"}
+)
+
+// expiryConflict 为同类字段之间可视为一致的最大差值，超过时认为原文自相矛盾。
+const expiryConflict = 24 * time.Hour
+
+// DateCandidate 为原文中一个可解析的到期时间字段。
+type DateCandidate struct {
+	Field string
+	Value string
+	Time  time.Time
+	Kind  ExpiryKind
+	// Line 为字段在原文中的行号（从 0 开始），同分时取靠前的。
+	Line int
+}
+
+// ExpiryExtraction 为从原文中选出的到期时间及其置信度（0~1）。
+type ExpiryExtraction struct {
+	DateCandidate
+	Confidence float64
+	// Candidates 为全部候选值，按得分从高到低排列。
+	Candidates []DateCandidate
+}
+
+// ExtractExpiry 收集 WHOIS 原文中所有到期时间字段，按 注册局 > 注册商 > 通用 的优先级选出结果。
+// 同类字段给出不同日期时降低置信度，不同类字段相互印证时提高置信度。
+func ExtractExpiry(raw string) (ExpiryExtraction, bool) {
+	candidates := expiryCandidates(raw)
+	if len(candidates) == 0 {
+		return ExpiryExtraction{}, false
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Kind != candidates[j].Kind {
+			return candidates[i].Kind > candidates[j].Kind
+		}
+		return candidates[i].Line < candidates[j].Line
+	})
+
+	best := candidates[0]
+	confidence := expiryScores[best.Kind]
+	corroborated := false
+	for _, c := range candidates[1:] {
+		close := absDuration(c.Time.Sub(best.Time)) <= expiryConflict
+		switch {
+		case c.Kind == best.Kind && !close:
+			confidence /= 2
+		case c.Kind != best.Kind && close:
+			corroborated = true
+		}
+	}
+	if corroborated {
+		confidence += 0.1
+	}
+	if confidence > 1 {
+		confidence = 1
+	}
+	return ExpiryExtraction{DateCandidate: best, Confidence: confidence, Candidates: candidates}, true
+}
+
+// expiryCandidates 逐行提取 "字段: 值"、"字段：值" 与 "[字段] 值" 形式的到期时间。
+func expiryCandidates(raw string) []DateCandidate {
+	var out []DateCandidate
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	for i, line := range strings.Split(raw, "\n") {
+		key, value, ok := splitField(line)
+		if !ok {
+			continue
+		}
+		kind, ok := expiryKind(key)
+		if !ok {
+			continue
+		}
+		t, ok := ParseDate(value)
+		if !ok || !plausibleExpiry(t) {
+			continue
+		}
+		out = append(out, DateCandidate{Field: key, Value: value, Time: t, Kind: kind, Line: i})
+	}
+	return out
+}
+
+func splitField(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "[") {
+		if idx := strings.Index(line, "]"); idx > 1 {
+			return normalizeKey(line[1:idx]), strings.TrimSpace(line[idx+1:]), true
+		}
+	}
+	idx := strings.IndexAny(line, ":：")
+	if idx <= 0 {
+		return "", "", false
+	}
+	value = strings.TrimSpace(line[idx:])
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(value, ":"), "："))
+	if value == "" {
+		return "", "", false
+	}
+	return normalizeKey(line[:idx]), value, true
+}
+
+// normalizeKey 去掉 "Expiry date......" 之类的填充点并统一为小写单空格。
+func normalizeKey(key string) string {
+	key = strings.ToLower(strings.TrimRight(strings.TrimSpace(key), ". "))
+	return strings.Join(strings.Fields(key), " ")
+}
+
+func expiryKind(key string) (ExpiryKind, bool) {
+	if containsAny(key, notExpiryKeys) {
+		return 0, false
+	}
+	switch {
+	case containsAny(key, registryExpiryKeys):
+		return ExpiryRegistry, true
+	case containsAny(key, registrarExpiryKeys):
+		return ExpiryRegistrar, true
+	case containsAny(key, genericExpiryKeys):
+		return ExpiryGeneric, true
+	}
+	return 0, false
+}
+
+// plausibleExpiry 排除明显错误的值，例如把序号或版本号当成时间戳。
+func plausibleExpiry(t time.Time) bool {
+	return t.Year() >= 1985 && t.Year() <= 2200
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,249 @@
+package lookup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"DomainC/config"
+)
+
+// Parser 将某个注册局的 WHOIS 原文解析为结构化结果。
+type Parser interface {
+	Parse(domain, raw string) Result
+}
+
+// ParserFunc 让普通函数实现 Parser。
+type ParserFunc func(domain, raw string) Result
+
+func (f ParserFunc) Parse(domain, raw string) Result { return f(domain, raw) }
+
+// WhoisRule 描述一个后缀（TLD 或 us.com 这类二级注册局）的 WHOIS 服务器、查询格式与解析器。
+type WhoisRule struct {
+	Suffix string
+	// Server 为 host 或 host:port，为空时沿用默认的服务器发现逻辑。
+	Server string
+	// Query 为 fmt 格式的查询语句，%s 替换为域名，为空时直接发送域名。
+	Query string
+	// Parser 为空时使用通用解析 ParseWhois。
+	Parser Parser
+	// NotFound 为该注册局"域名不存在"应答中的关键字（不区分大小写），在通用关键字之前匹配。
+	NotFound []string
+}
+
+// WhoisRules 按最长后缀匹配 WhoisRule。
+type WhoisRules struct {
+	mu    sync.RWMutex
+	rules map[string]WhoisRule
+}
+
+func NewWhoisRules() *WhoisRules {
+	return &WhoisRules{rules: make(map[string]WhoisRule)}
+}
+
+// NewWhoisRulesFromConfig 根据 config.yaml 中的 whois.rules 构造规则表。
+func NewWhoisRulesFromConfig(cfg []config.WhoisRule) (*WhoisRules, error) {
+	rules := NewWhoisRules()
+	for _, c := range cfg {
+		if normalizeSuffix(c.Suffix) == "" {
+			return nil, fmt.Errorf("WHOIS 规则缺少 suffix: %+v", c)
+		}
+		if c.Query != "" && c.Server == "" {
+			return nil, fmt.Errorf("WHOIS 规则 [%s] 配置了 query 但缺少 server", c.Suffix)
+		}
+		if c.Query != "" && strings.Count(c.Query, "%s") != 1 {
+			return nil, fmt.Errorf("WHOIS 规则 [%s] 的 query 必须包含一个 %%s", c.Suffix)
+		}
+		rule := WhoisRule{Suffix: c.Suffix, Server: c.Server, Query: c.Query, NotFound: c.NotFound}
+		fp := FieldParser{
+			ExpiryFields:     c.ExpiryFields,
+			RegistrarFields:  c.RegistrarFields,
+			CreatedFields:    c.CreatedFields,
+			StatusFields:     c.StatusFields,
+			NameServerFields: c.NameServerFields,
+			DateLayouts:      c.DateLayouts,
+		}
+		if !fp.empty() {
+			rule.Parser = fp
+		}
+		rules.Add(rule)
+	}
+	return rules, nil
+}
+
+// Add 注册或替换某个后缀的规则，可用于挂载自定义 Parser。
+func (w *WhoisRules) Add(rule WhoisRule) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rules[normalizeSuffix(rule.Suffix)] = rule
+}
+
+// Match 返回域名匹配到的最长后缀规则。
+func (w *WhoisRules) Match(domain string) (WhoisRule, bool) {
+	if w == nil {
+		return WhoisRule{}, false
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	name := normalizeSuffix(domain)
+	for {
+		if rule, ok := w.rules[name]; ok {
+			return rule, true
+		}
+		idx := strings.IndexByte(name, '.')
+		if idx < 0 {
+			return WhoisRule{}, false
+		}
+		name = name[idx+1:]
+	}
+}
+
+var genericExpiryFields = []string{
+	"registry expiry date",
+	"expiration date",
+	"expiry date",
+	"expiration time",
+	"expire date",
+	"expires",
+	"expires on",
+	"paid-till",
+}
+
+// FieldParser 按配置的字段名与日期格式解析，未配置或未命中的字段回退到 ParseWhois。
+type FieldParser struct {
+	ExpiryFields     []string
+	RegistrarFields  []string
+	CreatedFields    []string
+	StatusFields     []string
+	NameServerFields []string
+	DateLayouts      []string
+}
+
+func (p FieldParser) empty() bool {
+	return len(p.ExpiryFields) == 0 && len(p.RegistrarFields) == 0 && len(p.CreatedFields) == 0 &&
+		len(p.StatusFields) == 0 && len(p.NameServerFields) == 0 && len(p.DateLayouts) == 0
+}
+
+func (p FieldParser) Parse(domain, raw string) Result {
+	result := ParseWhois(domain, raw)
+	values := whoisValues(raw)
+
+	expiryFields := p.ExpiryFields
+	if len(expiryFields) == 0 && len(p.DateLayouts) > 0 {
+		// 只配置了日期格式时，用通用字段名配合自定义格式再试一次
+		expiryFields = genericExpiryFields
+	}
+	if t, ok := p.firstDate(values, expiryFields); ok {
+		result.RegistryExpiry = t
+		// 显式配置的字段视为可信；仅凭通用字段名命中时按通用字段评分
+		if len(p.ExpiryFields) > 0 {
+			result.ExpiryConfidence = 1
+		} else if result.ExpiryConfidence == 0 {
+			result.ExpiryConfidence = expiryScores[ExpiryGeneric]
+		}
+	}
+	if t, ok := p.firstDate(values, p.CreatedFields); ok {
+		result.Created = t
+	}
+	for _, f := range p.RegistrarFields {
+		if v := values[strings.ToLower(f)]; len(v) > 0 {
+			result.Registrar = v[0]
+			break
+		}
+	}
+	if len(p.StatusFields) > 0 {
+		var statuses []string
+		for _, f := range p.StatusFields {
+			for _, v := range values[strings.ToLower(f)] {
+				statuses = appendUnique(statuses, normalizeStatus(v))
+			}
+		}
+		if len(statuses) > 0 {
+			result.Statuses = statuses
+		}
+	}
+	if len(p.NameServerFields) > 0 {
+		var servers []string
+		for _, f := range p.NameServerFields {
+			for _, v := range values[strings.ToLower(f)] {
+				servers = appendUnique(servers, strings.ToLower(strings.TrimSuffix(strings.Fields(v)[0], ".")))
+			}
+		}
+		if len(servers) > 0 {
+			result.NameServers = servers
+		}
+	}
+	return result
+}
+
+func (p FieldParser) firstDate(values map[string][]string, fields []string) (time.Time, bool) {
+	for _, f := range fields {
+		for _, v := range values[strings.ToLower(f)] {
+			if t, ok := p.parseDate(v); ok {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func (p FieldParser) parseDate(value string) (time.Time, bool) {
+	return parseDateLayouts(value, p.DateLayouts)
+}
+
+// whoisValues 收集 "key: value" 行，键统一为小写。
+func whoisValues(raw string) map[string][]string {
+	values := make(map[string][]string)
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:idx]))
+		value := strings.TrimSpace(line[idx+1:])
+		if value == "" {
+			continue
+		}
+		values[key] = append(values[key], value)
+	}
+	return values
+}
+
+// queryWhoisServer 直接向指定服务器发送查询，用于需要自定义查询格式的注册局。
+func queryWhoisServer(ctx context.Context, server, query string, timeout time.Duration) (string, error) {
+	host, port := server, "43"
+	if h, p, err := net.SplitHostPort(server); err == nil {
+		host, port = h, p
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return "", fmt.Errorf("连接 WHOIS 服务器失败 [%s]: %w", server, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte(query + "\r\n")); err != nil {
+		return "", fmt.Errorf("发送 WHOIS 查询失败 [%s]: %w", server, err)
+	}
+	data, err := io.ReadAll(conn)
+	if err != nil && len(data) == 0 {
+		return "", fmt.Errorf("读取 WHOIS 响应失败 [%s]: %w", server, err)
+	}
+	return string(data), nil
+}
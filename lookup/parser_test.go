@@ -0,0 +1,192 @@
+package lookup
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"DomainC/config"
+)
+
+// startWhoisServer 启动本地 WHOIS 服务，记录收到的查询并返回固定响应。
+func startWhoisServer(t *testing.T, response string) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	queries := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			queries <- strings.TrimSpace(line)
+			conn.Write([]byte(response))
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String(), queries
+}
+
+func TestWhoisRuleUsesServerQueryAndFields(t *testing.T) {
+	addr, queries := startWhoisServer(t, "Domain: example.us.test\nHolder Registrar: Test NIC\nValid Until: 08.01.2026\nState: clientHold\n")
+
+	rules, err := NewWhoisRulesFromConfig([]config.WhoisRule{{
+		Suffix:          "us.test",
+		Server:          addr,
+		Query:           "-T dn %s",
+		ExpiryFields:    []string{"Valid Until"},
+		RegistrarFields: []string{"Holder Registrar"},
+		StatusFields:    []string{"State"},
+		DateLayouts:     []string{"02.01.2006"},
+	}})
+	if err != nil {
+		t.Fatalf("NewWhoisRulesFromConfig: %v", err)
+	}
+
+	boot, _ := NewBootstrap("", "", "", nil)
+	client := &Client{Bootstrap: boot, WhoisRules: rules, WhoisTimeout: time.Second}
+	r, err := client.Lookup(context.Background(), "example.us.test")
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+
+	if q := <-queries; q != "-T dn example.us.test" {
+		t.Errorf("unexpected query %q", q)
+	}
+	if want := time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC); !r.Expiry().Equal(want) {
+		t.Errorf("expected expiry %v, got %v", want, r.Expiry())
+	}
+	if r.Registrar != "Test NIC" || !r.HasStatus("clientHold") {
+		t.Errorf("unexpected registrar/status: %q %v", r.Registrar, r.Statuses)
+	}
+	if r.Server != addr || r.Protocol != ProtocolWHOIS {
+		t.Errorf("unexpected server/protocol: %s %s", r.Server, r.Protocol)
+	}
+}
+
+func TestWhoisRulesMatchLongestSuffix(t *testing.T) {
+	rules := NewWhoisRules()
+	rules.Add(WhoisRule{Suffix: "com", Server: "whois.verisign-grs.com"})
+	rules.Add(WhoisRule{Suffix: ".us.com", Server: "whois.centralnic.com"})
+
+	if r, ok := rules.Match("gamestore.us.com"); !ok || r.Server != "whois.centralnic.com" {
+		t.Errorf("expected us.com rule, got %+v", r)
+	}
+	if r, ok := rules.Match("example.com"); !ok || r.Server != "whois.verisign-grs.com" {
+		t.Errorf("expected com rule, got %+v", r)
+	}
+	if _, ok := rules.Match("example.ph"); ok {
+		t.Errorf("expected no rule for ph")
+	}
+
+	if _, err := NewWhoisRulesFromConfig([]config.WhoisRule{{Suffix: "de", Query: "-T dn %s"}}); err == nil {
+		t.Errorf("expected query without server to be rejected")
+	}
+}
+
+func TestWhoisRuleNotFoundPatterns(t *testing.T) {
+	addr, _ := startWhoisServer(t, "% Restricted rights.\nDomain: gone.de\nNothing registered under this name\n")
+
+	rules, err := NewWhoisRulesFromConfig([]config.WhoisRule{{Suffix: "de", Server: addr, NotFound: []string{"NOTHING REGISTERED"}}})
+	if err != nil {
+		t.Fatalf("NewWhoisRulesFromConfig: %v", err)
+	}
+	boot, _ := NewBootstrap("", "", "", nil)
+	client := &Client{Bootstrap: boot, WhoisRules: rules, WhoisTimeout: time.Second}
+
+	_, err = client.Lookup(context.Background(), "gone.de")
+	var notRegistered *NotRegisteredError
+	if !errors.As(err, &notRegistered) {
+		t.Fatalf("expected NotRegisteredError, got %v", err)
+	}
+	if notRegistered.Line != "Nothing registered under this name" {
+		t.Errorf("unexpected matching line %q", notRegistered.Line)
+	}
+}
+
+func TestParseDateLocalesAndZones(t *testing.T) {
+	jst := time.FixedZone("JST", 9*3600)
+	cases := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026年01月08日", time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"2026年1月8日 12:00:00", time.Date(2026, 1, 8, 12, 0, 0, 0, time.UTC)},
+		{"08.01.2026", time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"2026/01/08 12:00:00 (JST)", time.Date(2026, 1, 8, 12, 0, 0, 0, jst)},
+		{"2026-01-08 12:00:00 UTC+8", time.Date(2026, 1, 8, 4, 0, 0, 0, time.UTC)},
+		{"1767830400", time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"1767830400000", time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"8-Jan-2026", time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"2026-01-08T00:00:00Z", time.Date(2026, 1, 8, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		got, ok := ParseDate(tc.value)
+		if !ok {
+			t.Fatalf("ParseDate(%q) failed", tc.value)
+		}
+		if !got.Equal(tc.want) {
+			t.Fatalf("ParseDate(%q) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+	if _, ok := ParseDate("not a date"); ok {
+		t.Fatalf("expected failure for garbage input")
+	}
+}
+
+func TestExtractExpiryScoresCandidates(t *testing.T) {
+	raw := strings.Join([]string{
+		"Domain Name: EXAMPLE.COM",
+		"Creation Date: 2001-01-01T00:00:00Z",
+		"Expires: 2027-03-01",
+		"Registry Expiry Date: 2026-01-08T00:00:00Z",
+		"Registrar Registration Expiration Date: 2026-01-08T04:00:00Z",
+		"Expiration Notice: see https://example.com/policy",
+	}, "\n")
+	got, ok := ExtractExpiry(raw)
+	if !ok {
+		t.Fatalf("expected extraction")
+	}
+	if got.Kind != ExpiryRegistry || got.Time.Format("2006-01-02") != "2026-01-08" {
+		t.Fatalf("expected registry expiry to win, got %+v", got.DateCandidate)
+	}
+	if got.Confidence != 1 || len(got.Candidates) != 3 {
+		t.Fatalf("unexpected confidence %.2f / candidates %d", got.Confidence, len(got.Candidates))
+	}
+
+	jp := "[ドメイン名]  EXAMPLE.JP\n[有効期限]  2026/01/31\n[最終更新]  2025/02/01 01:05:03 (JST)"
+	got, ok = ExtractExpiry(jp)
+	if !ok || got.Kind != ExpiryGeneric || got.Time.Format("2006-01-02") != "2026-01-31" || got.Confidence != 0.6 {
+		t.Fatalf("unexpected .jp extraction %+v (%v)", got, ok)
+	}
+
+	conflict := "Expiry date: 2026-01-08\nExpiration Date: 2029-05-01"
+	got, ok = ExtractExpiry(conflict)
+	if !ok || got.Time.Format("2006-01-02") != "2026-01-08" || got.Confidence >= 0.5 {
+		t.Fatalf("conflicting generic dates should lower confidence, got %+v", got)
+	}
+
+	if _, ok := ExtractExpiry("Domain Name: example.com\nCreated: 2020-01-01"); ok {
+		t.Fatalf("expected no expiry candidates")
+	}
+}
+
+func TestParseWhoisUsesRegistrarExpiryWhenAlone(t *testing.T) {
+	r := ParseWhois("example.com", "Registrar Registration Expiration Date: 2026-02-03T00:00:00Z\nExpires On: 2026-02-03")
+	if !r.RegistryExpiry.IsZero() || r.RegistrarExpiry.Format("2006-01-02") != "2026-02-03" {
+		t.Fatalf("unexpected expiry fields %+v", r)
+	}
+	if r.ExpiryConfidence != 0.9 {
+		t.Fatalf("expected corroborated registrar confidence 0.9, got %.2f", r.ExpiryConfidence)
+	}
+}
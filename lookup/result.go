@@ -0,0 +1,119 @@
+package lookup
+
+import (
+	"strings"
+	"time"
+)
+
+// Protocol 标识给出结果的查询协议。
+type Protocol string
+
+const (
+	ProtocolRDAP   Protocol = "rdap"
+	ProtocolWHOIS  Protocol = "whois"
+	ProtocolAPI    Protocol = "api"
+	ProtocolManual Protocol = "manual"
+)
+
+// Result 是一次 RDAP/WHOIS 查询的结构化结果。
+type Result struct {
+	Domain          string    `json:"domain"`
+	Registrar       string    `json:"registrar,omitempty"`
+	RegistrarIANAID string    `json:"registrarIanaId,omitempty"`
+	RegistryExpiry  time.Time `json:"registryExpiry,omitempty"`
+	RegistrarExpiry time.Time `json:"registrarExpiry,omitempty"`
+	Created         time.Time `json:"created,omitempty"`
+	Updated         time.Time `json:"updated,omitempty"`
+	// Statuses 为 EPP 状态码，统一为 clientTransferProhibited 这类驼峰形式。
+	Statuses    []string `json:"statuses,omitempty"`
+	NameServers []string `json:"nameServers,omitempty"`
+	DNSSEC      bool     `json:"dnssec,omitempty"`
+	// DSKeyTags 为注册局 DS 记录的 key tag，仅 RDAP 结果提供。
+	DSKeyTags []int    `json:"dsKeyTags,omitempty"`
+	Protocol  Protocol `json:"protocol"`
+	// ExpiryConfidence 为到期时间的置信度（0~1），结构化来源为 1，WHOIS 原文由 ExtractExpiry 评分。
+	ExpiryConfidence float64 `json:"expiryConfidence,omitempty"`
+	// Backend 为给出结果的查询后端名称，例如 rdap、whois、manual 或注册商 API 的名称。
+	Backend string `json:"backend,omitempty"`
+	// Server 为实际应答的 RDAP 地址或 WHOIS 服务器。
+	Server string `json:"server,omitempty"`
+	Raw    string `json:"raw,omitempty"`
+}
+
+// Expiry 返回到期时间，优先使用注册局时间，其次为注册商时间。
+func (r Result) Expiry() time.Time {
+	if !r.RegistryExpiry.IsZero() {
+		return r.RegistryExpiry
+	}
+	return r.RegistrarExpiry
+}
+
+// HasExpiry 表示结果中是否包含到期时间。
+func (r Result) HasExpiry() bool {
+	return !r.Expiry().IsZero()
+}
+
+// HasStatus 判断是否包含指定 EPP 状态（大小写不敏感）。
+func (r Result) HasStatus(status string) bool {
+	for _, s := range r.Statuses {
+		if strings.EqualFold(s, status) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeStatus 将 "client transfer prohibited"、"clientTransferProhibited https://icann.org/epp#..."
+// 等写法统一为 EPP 驼峰形式。RDAP 的 "active" 与 EPP 的 "ok" 含义相同，统一为 "ok"，
+// 避免 RDAP 与 WHOIS 交替给出结果时误报状态变化。
+func normalizeStatus(raw string) string {
+	fields := strings.Fields(strings.TrimSpace(raw))
+	if len(fields) == 0 {
+		return ""
+	}
+	if len(fields) == 1 || strings.Contains(fields[1], "://") || strings.HasPrefix(fields[1], "(") {
+		if status := lowerFirst(fields[0]); status != "active" {
+			return status
+		}
+		return "ok"
+	}
+
+	var words []string
+	for _, f := range fields {
+		if strings.Contains(f, "://") || strings.HasPrefix(f, "(") {
+			break
+		}
+		words = append(words, strings.ToLower(f))
+	}
+	var sb strings.Builder
+	for i, w := range words {
+		if i == 0 {
+			sb.WriteString(w)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return sb.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	if strings.ToUpper(s) == s {
+		return strings.ToLower(s)
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return list
+		}
+	}
+	return append(list, value)
+}
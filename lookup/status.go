@@ -0,0 +1,60 @@
+package lookup
+
+// StatusInfo 说明 EPP 状态码对域名的影响，Critical 表示会导致解析中断、即将删除或被转走。
+type StatusInfo struct {
+	Meaning  string
+	Critical bool
+}
+
+// eppStatuses 以 normalizeStatus 之后的驼峰形式为键。
+var eppStatuses = map[string]StatusInfo{
+	"ok": {Meaning: "正常状态，没有任何限制。"},
+
+	"clientHold": {Meaning: "注册商暂停解析：域名不再出现在注册局 DNS 中，网站和邮件全部不可用，常见于欠费、实名认证未通过或被投诉。", Critical: true},
+	"serverHold": {Meaning: "注册局暂停解析：域名不再出现在注册局 DNS 中，网站和邮件全部不可用，通常因注册局政策或法律原因。", Critical: true},
+	"inactive":   {Meaning: "域名没有关联 NS，不会解析。", Critical: true},
+	"redemptionPeriod": {
+		Meaning:  "域名已被删除并进入赎回期（约 30 天）：解析已停止，只能通过注册商高价赎回。",
+		Critical: true,
+	},
+	"pendingDelete":   {Meaning: "域名即将被注册局彻底删除并开放注册（通常 5 天内），无法再赎回。", Critical: true},
+	"pendingRestore":  {Meaning: "赎回申请已提交，等待注册局恢复域名。", Critical: true},
+	"pendingTransfer": {Meaning: "域名正在转移到其他注册商，如非本方操作请立即在注册商处拒绝。", Critical: true},
+	"autoRenewPeriod": {Meaning: "域名已过期并由注册局自动续费，处于宽限期，注册商仍可能撤销续费后删除域名。", Critical: true},
+	"serverRenewProhibited": {
+		Meaning:  "注册局禁止续费，域名可能涉及争议或即将被处理。",
+		Critical: true,
+	},
+	"clientRenewProhibited": {Meaning: "注册商禁止续费，请联系注册商确认原因。", Critical: true},
+
+	"pendingCreate":  {Meaning: "注册申请正在处理中。"},
+	"pendingRenew":   {Meaning: "续费请求正在处理中。"},
+	"pendingUpdate":  {Meaning: "信息修改请求正在处理中。"},
+	"addPeriod":      {Meaning: "新注册宽限期，注册后几天内可被注册商撤销。"},
+	"renewPeriod":    {Meaning: "续费宽限期，刚完成续费。"},
+	"transferPeriod": {Meaning: "转移宽限期，刚完成注册商转移。"},
+
+	"clientTransferProhibited": {Meaning: "注册商转移锁：禁止转移到其他注册商，可防止域名被盗转。"},
+	"serverTransferProhibited": {Meaning: "注册局转移锁：禁止转移到其他注册商。"},
+	"clientUpdateProhibited":   {Meaning: "注册商禁止修改域名信息（含 NS）。"},
+	"serverUpdateProhibited":   {Meaning: "注册局禁止修改域名信息（含 NS）。"},
+	"clientDeleteProhibited":   {Meaning: "注册商禁止删除域名。"},
+	"serverDeleteProhibited":   {Meaning: "注册局禁止删除域名。"},
+}
+
+// DescribeStatus 返回 EPP 状态码的说明，未知状态返回 ok=false。
+func DescribeStatus(status string) (StatusInfo, bool) {
+	info, ok := eppStatuses[normalizeStatus(status)]
+	return info, ok
+}
+
+// CriticalStatuses 返回结果中会影响解析或所有权的状态。
+func (r Result) CriticalStatuses() []string {
+	var out []string
+	for _, s := range r.Statuses {
+		if info, ok := DescribeStatus(s); ok && info.Critical {
+			out = append(out, s)
+		}
+	}
+	return out
+}
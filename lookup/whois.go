@@ -0,0 +1,110 @@
+package lookup
+
+import (
+	"strings"
+)
+
+// whoisFields 将 WHOIS 字段名（小写）映射到结果字段。
+var whoisFields = map[string]string{
+	"registrar":                    "registrar",
+	"sponsoring registrar":         "registrar",
+	"registrar name":               "registrar",
+	"registrar iana id":            "ianaID",
+	"sponsoring registrar iana id": "ianaID",
+	"creation date":                "created",
+	"created":                      "created",
+	"created on":                   "created",
+	"registered on":                "created",
+	"registration time":            "created",
+	"updated date":                 "updated",
+	"last updated":                 "updated",
+	"last modified":                "updated",
+	"changed":                      "updated",
+	"domain status":                "status",
+	"status":                       "status",
+	"name server":                  "ns",
+	"nameserver":                   "ns",
+	"nserver":                      "ns",
+	"dnssec":                       "dnssec",
+}
+
+// ParseWhois 将 WHOIS 原文解析为结构化结果，未识别的字段忽略。
+// 到期时间由 ExtractExpiry 在全部候选字段中评分选出。
+func ParseWhois(domain, raw string) Result {
+	result := Result{Domain: domain, Protocol: ProtocolWHOIS, Raw: raw}
+	applyExpiry(&result, raw)
+
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lower := strings.ToLower(line)
+
+		// 跳过提示/免责声明行
+		if strings.HasPrefix(lower, "notice:") ||
+			strings.Contains(lower, "terms of use") ||
+			strings.Contains(lower, "disclaimer") ||
+			strings.Contains(lower, "policy") {
+			continue
+		}
+
+		idx := strings.Index(line, ":")
+		if idx <= 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:idx]))
+		value := strings.TrimSpace(line[idx+1:])
+		if value == "" {
+			continue
+		}
+
+		switch whoisFields[key] {
+		case "registrar":
+			if result.Registrar == "" {
+				result.Registrar = value
+			}
+		case "ianaID":
+			if result.RegistrarIANAID == "" {
+				result.RegistrarIANAID = value
+			}
+		case "created":
+			if t, ok := ParseDate(value); ok && result.Created.IsZero() {
+				result.Created = t
+			}
+		case "updated":
+			if t, ok := ParseDate(value); ok && result.Updated.IsZero() {
+				result.Updated = t
+			}
+		case "status":
+			result.Statuses = appendUnique(result.Statuses, normalizeStatus(value))
+		case "ns":
+			ns := strings.ToLower(strings.TrimSuffix(strings.Fields(value)[0], "."))
+			result.NameServers = appendUnique(result.NameServers, ns)
+		case "dnssec":
+			v := strings.ToLower(value)
+			result.DNSSEC = strings.HasPrefix(v, "signed") || v == "yes" || v == "true"
+		}
+	}
+	return result
+}
+
+// applyExpiry 写入 ExtractExpiry 选出的到期时间：选中注册商字段时只填 RegistrarExpiry，
+// 保证 Result.Expiry 返回的就是得分最高的值；同时保留最靠前的注册商到期时间以供展示。
+func applyExpiry(result *Result, raw string) {
+	extraction, ok := ExtractExpiry(raw)
+	if !ok {
+		return
+	}
+	if extraction.Kind != ExpiryRegistrar {
+		result.RegistryExpiry = extraction.Time
+	}
+	for _, c := range extraction.Candidates {
+		if c.Kind == ExpiryRegistrar {
+			result.RegistrarExpiry = c.Time
+			break
+		}
+	}
+	result.ExpiryConfidence = extraction.Confidence
+}
@@ -0,0 +1,62 @@
+package lookup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWhoisExtractsFields(t *testing.T) {
+	raw := "Domain Name: EXAMPLE.COM\r\n" +
+		"Registrar: Example Registrar, Inc.\r\n" +
+		"Registrar IANA ID: 292\r\n" +
+		"Creation Date: 1995-08-14T04:00:00Z\r\n" +
+		"Registry Expiry Date: 2026-08-13T04:00:00Z\r\n" +
+		"Registrar Registration Expiration Date: 2026-08-12\r\n" +
+		"Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited\r\n" +
+		"Domain Status: serverHold https://icann.org/epp#serverHold\r\n" +
+		"Name Server: A.IANA-SERVERS.NET\r\n" +
+		"Name Server: B.IANA-SERVERS.NET\r\n" +
+		"DNSSEC: signedDelegation\r\n" +
+		"NOTICE: The expiration date displayed in this record is the date the\r\n"
+
+	r := ParseWhois("example.com", raw)
+	if r.Protocol != ProtocolWHOIS {
+		t.Fatalf("unexpected protocol %s", r.Protocol)
+	}
+	if r.Registrar != "Example Registrar, Inc." || r.RegistrarIANAID != "292" {
+		t.Errorf("unexpected registrar %q (%q)", r.Registrar, r.RegistrarIANAID)
+	}
+	want := time.Date(2026, 8, 13, 4, 0, 0, 0, time.UTC)
+	if !r.Expiry().Equal(want) {
+		t.Errorf("expected registry expiry %v, got %v", want, r.Expiry())
+	}
+	if r.RegistrarExpiry.IsZero() || r.Created.IsZero() {
+		t.Errorf("expected registrar expiry and creation date to be parsed")
+	}
+	if len(r.Statuses) != 2 || !r.HasStatus("clientTransferProhibited") || !r.HasStatus("serverHold") {
+		t.Errorf("unexpected statuses %v", r.Statuses)
+	}
+	if len(r.NameServers) != 2 || r.NameServers[0] != "a.iana-servers.net" {
+		t.Errorf("unexpected name servers %v", r.NameServers)
+	}
+	if !r.DNSSEC {
+		t.Errorf("expected DNSSEC to be signed")
+	}
+}
+
+func TestNormalizeStatus(t *testing.T) {
+	cases := map[string]string{
+		"client transfer prohibited":                  "clientTransferProhibited",
+		"pending delete":                              "pendingDelete",
+		"clientHold https://icann.org/epp#clientHold": "clientHold",
+		"ok":     "ok",
+		"OK":     "ok",
+		"active": "ok",
+		"redemptionPeriod (https://icann.org/epp#redemptionPeriod)": "redemptionPeriod",
+	}
+	for in, want := range cases {
+		if got := normalizeStatus(in); got != want {
+			t.Errorf("normalizeStatus(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
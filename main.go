@@ -3,13 +3,18 @@ package main
 import (
 	"context"
 	"log"
+	"strings"
 	"time"
+	// 内置时区数据，容器中没有 zoneinfo 时 timezone 配置仍然可用
+	_ "time/tzdata"
 
 	"DomainC/callback"
 	"DomainC/cfclient"
 	"DomainC/config"
+	"DomainC/dnsquery"
 	"DomainC/domain"
 	"DomainC/internal/app"
+	"DomainC/lookup"
 	"DomainC/scheduler"
 	"DomainC/telegram"
 )
@@ -17,6 +22,7 @@ import (
 const (
 	expiringFile = "expiring_domains.txt"
 	failedFile   = "failed_domains.txt"
+	stateFile    = "domain_state.json"
 )
 
 func main() {
@@ -28,6 +34,63 @@ func main() {
 	defer cancel()
 
 	cfClient := cfclient.NewClient()
+	zoneIndex := cfclient.NewZoneIndex(cfClient, config.Cfg.CloudflareAccounts)
+	zoneIndex.Start(ctx, config.Cfg.ZoneIndexRefresh)
+	callback.Zones = zoneIndex
+
+	rdapCfg := config.Cfg.RDAP
+	if rdapCfg.CacheDir == "" {
+		rdapCfg.CacheDir = "cache/rdap"
+	}
+	if rdapCfg.RefreshInterval <= 0 {
+		rdapCfg.RefreshInterval = 24 * time.Hour
+	}
+	rdapBootstrap, err := lookup.NewBootstrap(rdapCfg.BootstrapFile, rdapCfg.CacheDir, rdapCfg.BootstrapURL, rdapCfg.Servers)
+	if err != nil {
+		log.Fatalf("RDAP 配置错误: %v", err)
+	}
+	if err := rdapBootstrap.Load(ctx); err != nil {
+		log.Printf("加载 RDAP bootstrap 失败，暂时只使用 WHOIS: %v", err)
+	}
+	rdapBootstrap.Start(ctx, rdapCfg.RefreshInterval)
+
+	lookupCfg := config.Cfg.Lookup
+	if lookupCfg.Workers <= 0 {
+		lookupCfg.Workers = 4
+	}
+	if lookupCfg.RateLimit <= 0 {
+		lookupCfg.RateLimit = time.Second
+	}
+	if lookupCfg.QueryTimeout <= 0 {
+		lookupCfg.QueryTimeout = 15 * time.Second
+	}
+	retries := 2
+	if lookupCfg.Retries != nil {
+		retries = *lookupCfg.Retries
+	}
+	if lookupCfg.RetryBackoff <= 0 {
+		lookupCfg.RetryBackoff = 5 * time.Second
+	}
+	if lookupCfg.StatusRecheck <= 0 {
+		lookupCfg.StatusRecheck = 24 * time.Hour
+	}
+	// 未设置时默认 0.5，负数表示关闭，由 checker 按不检查处理
+	if lookupCfg.MinConfidence == 0 {
+		lookupCfg.MinConfidence = 0.5
+	}
+	whoisRules, err := lookup.NewWhoisRulesFromConfig(config.Cfg.Whois.Rules)
+	if err != nil {
+		log.Fatalf("WHOIS 规则配置错误: %v", err)
+	}
+	lookupClient := lookup.NewClient()
+	lookupClient.Bootstrap = rdapBootstrap
+	lookupClient.WhoisRules = whoisRules
+	lookupClient.WhoisTimeout = lookupCfg.QueryTimeout
+	lookup.SetDefaultClient(lookupClient)
+	lookupChain, err := lookup.NewChainFromConfig(lookupClient, lookupCfg)
+	if err != nil {
+		log.Fatalf("查询策略配置错误: %v", err)
+	}
 
 	var sender telegram.Sender
 	botSender, err := telegram.NewBotSender(
@@ -45,33 +108,139 @@ func main() {
 		sender = botSender
 	}
 
+	whoisClient := lookupChain
 	commandHandler := telegram.NewCommandHandler(cfClient, sender, config.Cfg.CloudflareAccounts, int64(config.Cfg.Telegram.ChatID))
+	commandHandler.Zones = zoneIndex
+	callback.DNSPicks = commandHandler.HandleDNSPick
+	commandHandler.Whois = whoisClient
 
-	go func() {
-		if err := sender.StartListener(ctx, callback.HandleCallback, commandHandler.HandleMessage); err != nil {
-			log.Printf("Telegram 监听停止: %v", err)
-		}
-	}()
-
+	location, err := config.Cfg.Location()
+	if err != nil {
+		log.Fatalf("时区配置错误: %v", err)
+	}
 	repository := domain.NewFileRepository(config.Cfg.DomainFiles, expiringFile, failedFile)
+	repository.Location = location
 	service := domain.NewService(cfClient, repository)
 
 	collector := &app.Collector{Service: service, Accounts: config.Cfg.CloudflareAccounts}
+	stateStore, err := domain.NewFileStateStore(stateFile)
+	if err != nil {
+		log.Fatalf("加载域名状态失败: %v", err)
+	}
+	recheck := app.DefaultRecheckPolicy()
+	recheck.StatusEvery = lookupCfg.StatusRecheck
+	if len(lookupCfg.Recheck) > 0 {
+		recheck.Tiers = nil
+		for _, tier := range lookupCfg.Recheck {
+			recheck.Tiers = append(recheck.Tiers, app.RecheckTier{Beyond: tier.Beyond, Every: tier.Every})
+		}
+	}
+
+	alerts := app.AlertPolicyFromConfig(config.Cfg.Alerts, config.Cfg.AlertDays)
+
 	checker := &app.ExpiryCheckerService{
-		Whois:        app.DefaultWhoisClient{},
-		Repo:         repository,
-		AlertWithin:  app.AlertDaysDuration(config.Cfg.AlertDays),
-		RateLimit:    time.Second,
-		QueryTimeout: 15 * time.Second,
+		Whois:         whoisClient,
+		Repo:          repository,
+		AlertWithin:   alerts.Window(),
+		Alerts:        &alerts,
+		RateLimit:     lookupCfg.RateLimit,
+		RateLimits:    lookupCfg.RateLimits,
+		RateKey:       lookupChain.RateKey,
+		Workers:       lookupCfg.Workers,
+		QueryTimeout:  lookupCfg.QueryTimeout,
+		State:         stateStore,
+		Recheck:       recheck,
+		Retries:       retries,
+		RetryBackoff:  lookupCfg.RetryBackoff,
+		MinConfidence: lookupCfg.MinConfidence,
+		Location:      location,
+	}
+	commandHandler.Refresher = checker
+	commandHandler.History = checker
+	notifier := &app.NotifierService{Sender: sender, CFClient: cfClient, DeleteTimeout: 10 * time.Second, Location: location, Zones: zoneIndex}
+	commandHandler.Events = notifier
+	httpCfg := config.Cfg.HTTPProbes
+	httpProbe := &app.HTTPProbe{Checks: app.HTTPChecksFromConfig(httpCfg.Checks), Timeout: httpCfg.Timeout}
+	if !httpCfg.Disabled {
+		commandHandler.HTTP = httpProbe
+		notifier.HTTP = httpProbe
 	}
-	notifier := &app.NotifierService{Sender: sender, CFClient: cfClient, DeleteTimeout: 10 * time.Second}
 	sched := scheduler.NewDailyScheduler()
 
+	var probes []app.Probe
+	if nsCfg := config.Cfg.NameServers; !nsCfg.Disabled {
+		probes = append(probes, &app.NameServerProbe{
+			State:    stateStore,
+			Resolver: dnsquery.NewClient(nsCfg.Resolver, nsCfg.Timeout),
+			UseDNS:   strings.EqualFold(nsCfg.Source, "dns"),
+		})
+	}
+	dnssecCfg := config.Cfg.DNSSEC
+	dnssecProbe := &app.DNSSECProbe{
+		CF:       cfClient,
+		State:    stateStore,
+		Resolver: dnsquery.NewClient(dnssecCfg.Resolver, dnssecCfg.Timeout),
+		UseDNS:   strings.EqualFold(dnssecCfg.Source, "dns"),
+	}
+	commandHandler.DNSSEC = dnssecProbe
+	if !dnssecCfg.Disabled {
+		probes = append(probes, dnssecProbe)
+	}
+	certCfg := config.Cfg.Certificates
+	if certCfg.AlertDays <= 0 {
+		certCfg.AlertDays = 14
+	}
+	if !certCfg.Disabled {
+		probes = append(probes, &app.CertProbe{
+			CF:             cfClient,
+			Extra:          app.CertTargetsFromConfig(certCfg.ExtraHosts),
+			Within:         app.AlertDaysDuration(certCfg.AlertDays),
+			IncludeProxied: certCfg.IncludeProxied,
+			Timeout:        certCfg.Timeout,
+			Location:       location,
+		})
+	}
+	if !certCfg.EdgeDisabled {
+		probes = append(probes, &app.EdgeCertProbe{
+			CF:           cfClient,
+			State:        stateStore,
+			Within:       app.AlertDaysDuration(certCfg.AlertDays),
+			PendingAfter: certCfg.PendingAfter,
+			Location:     location,
+		})
+	}
+
+	if !httpCfg.Disabled {
+		probes = append(probes, httpProbe)
+	}
+	if resCfg := config.Cfg.Resolution; !resCfg.Disabled {
+		servers := resCfg.Resolvers
+		if len(servers) == 0 {
+			servers = []string{"1.1.1.1:53", "8.8.8.8:53"}
+		}
+		hosts := resCfg.Hosts
+		if len(hosts) == 0 {
+			hosts = []string{"www"}
+		}
+		resolvers := make([]app.RecordResolver, 0, len(servers))
+		for _, server := range servers {
+			resolvers = append(resolvers, dnsquery.NewClient(server, resCfg.Timeout))
+		}
+		probes = append(probes, &app.ResolutionProbe{CF: cfClient, Resolvers: resolvers, Hosts: hosts})
+	}
+
+	go func() {
+		if err := sender.StartListener(ctx, callback.HandleCallback, commandHandler.HandleMessage); err != nil {
+			log.Printf("Telegram 监听停止: %v", err)
+		}
+	}()
+
 	application := &app.App{
 		Collector: collector,
 		Checker:   checker,
 		Notifier:  notifier,
 		Scheduler: sched,
+		Probes:    probes,
 		AlertHour: 15,
 		AlertMin:  0,
 	}
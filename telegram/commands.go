@@ -4,15 +4,55 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"math/rand"
 	"strings"
+	"time"
 
 	"DomainC/cfclient"
 	"DomainC/config"
+	"DomainC/domain"
+	"DomainC/lookup"
+	"DomainC/tools"
 
+	cloudflare "github.com/cloudflare/cloudflare-go"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// DomainLookup 查询域名注册信息，供 /whois 命令使用。
+type DomainLookup interface {
+	Lookup(ctx context.Context, domain string) (lookup.Result, error)
+}
+
+// CacheRefresher 跳过缓存重新查询注册信息，供 /refresh 命令使用，并返回与上次结果相比发现的变化。
+type CacheRefresher interface {
+	Refresh(ctx context.Context, domain string) (lookup.Result, []domain.Event, error)
+	InvalidateCache() error
+}
+
+// EventNotifier 推送事件通知，供 /refresh 发现变化时使用。
+type EventNotifier interface {
+	NotifyEvents(ctx context.Context, events []domain.Event) error
+}
+
+// DomainHistory 返回域名的到期时间与注册商变化记录，供 /history 命令使用。
+type DomainHistory interface {
+	History(domain string) (domain.DomainState, error)
+}
+
+// DNSSECChecker 对比注册局与 CF 的 DNSSEC 状态，供 /dnssec 命令使用。
+type DNSSECChecker interface {
+	CheckDNSSEC(ctx context.Context, account config.CF, zone string) (domain.DNSSECCheck, error)
+}
+
+// HTTPChecker 对主机名或 URL 执行 HTTP 访问检查，供 /probe 命令及 /setdns 之后的验证使用。
+type HTTPChecker interface {
+	CheckHTTP(ctx context.Context, target string) []domain.HTTPCheckResult
+}
+
+// setDNSProbeDelay 为 /setdns 成功后等待解析生效再做访问检查的时间。
+var setDNSProbeDelay = 10 * time.Second
+
 // CommandHandler 处理群组中的命令消息
 // 需要传入 Cloudflare 客户端与账号列表。
 type CommandHandler struct {
@@ -20,7 +60,18 @@ type CommandHandler struct {
 	Accounts []config.CF
 	Sender   Sender
 	ChatID   int64
+	// Whois 为空时 /whois 使用 lookup 包的默认客户端。
+	Whois     DomainLookup
+	Refresher CacheRefresher
+	// Events 推送 /refresh 发现的变化，为空时只回复查询结果。
+	Events  EventNotifier
+	History DomainHistory
+	DNSSEC  DNSSECChecker
+	HTTP    HTTPChecker
+	// Zones 为跨账号 Zone 索引，为空时逐个账号查询。
+	Zones    *cfclient.ZoneIndex
 	operator *tgbotapi.User
+	picks    dnsPicks
 }
 
 func NewCommandHandler(cf cfclient.Client, sender Sender, accounts []config.CF, chatID int64) *CommandHandler {
@@ -45,7 +96,7 @@ func (h *CommandHandler) HandleMessage(msg *tgbotapi.Message) {
 		return
 	}
 	h.operator = msg.From
-	args := strings.Fields(msg.CommandArguments())
+	args := splitArgs(msg.CommandArguments())
 	switch msg.Command() {
 	case "dns":
 		go h.handleDNSCommand(strings.ToLower(msg.Command()), args)
@@ -57,15 +108,41 @@ func (h *CommandHandler) HandleMessage(msg *tgbotapi.Message) {
 		go h.handleDeleteCommand(args)
 	case "setdns":
 		go h.handleSetDNSCommand(args)
+	case "adddns":
+		go h.handleAddDNSCommand(args)
+	case "editdns":
+		go h.handleEditDNSCommand(args)
+	case "deldns":
+		go h.handleDelDNSCommand(args)
+	case "whois":
+		go h.handleWhoisCommand(args)
+	case "refresh":
+		go h.handleRefreshCommand(args)
+	case "history":
+		go h.handleHistoryCommand(args)
+	case "dnssec":
+		go h.handleDNSSECCommand(args)
+	case "probe":
+		go h.handleProbeCommand(args)
 	}
 }
 
 func (h *CommandHandler) handleDNSCommand(_ string, args []string) {
 	if len(args) < 1 {
-		h.sendText("用法: /dns <domain.com>")
+		h.sendText("用法: /dns <domain.com> [type] [name]")
+		return
+	}
+	domain, ok := h.zoneArg(args[0])
+	if !ok {
 		return
 	}
-	domain := strings.ToLower(args[0])
+	var filter cfclient.DNSRecordFilter
+	if len(args) >= 2 {
+		filter.Type = strings.ToUpper(args[1])
+	}
+	if len(args) >= 3 {
+		filter.Name = args[2]
+	}
 
 	account, zone, err := h.findZone(domain)
 	if err != nil {
@@ -77,24 +154,20 @@ func (h *CommandHandler) handleDNSCommand(_ string, args []string) {
 		return
 	}
 
-	records, err := h.CFClient.ListDNSRecords(context.Background(), *account, zone.Name)
+	records, err := h.CFClient.SearchDNSRecords(context.Background(), *account, zone.Name, filter)
 	if err != nil {
 		h.sendText(fmt.Sprintf("获取 %s 解析失败: %v", domain, err))
 		return
 	}
 	if len(records) == 0 {
-		h.sendText(fmt.Sprintf("域名 %s 在 %s 中没有解析记录。", domain, account.Label))
+		h.sendText(fmt.Sprintf("域名 %s 在 %s 中没有符合条件的解析记录。", domain, account.Label))
 		return
 	}
 
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("【域名解析记录】\n域名: %s\n账号: %s\n\n", zone.Name, account.Label))
 	for _, r := range records {
-		proxied := "off"
-		if r.Proxied != nil && *r.Proxied {
-			proxied = "on"
-		}
-		sb.WriteString(fmt.Sprintf("%s %s → %s (代理:%s)\n", r.Type, r.Name, r.Content, proxied))
+		sb.WriteString(fmt.Sprintf("%s\nID: %s\n", formatRecord(r), r.ID))
 	}
 	h.sendText(sb.String())
 }
@@ -104,7 +177,10 @@ func (h *CommandHandler) handleGetNSCommand(args []string) {
 		h.sendText("用法: /getns <domain.com>")
 		return
 	}
-	domain := strings.ToLower(args[0])
+	domain, ok := h.zoneArg(args[0])
+	if !ok {
+		return
+	}
 
 	if account, zone, err := h.findZone(domain); err == nil {
 		h.sendText(fmt.Sprintf("域名 %s 已在账号 %s 下，NS: %s", zone.Name, account.Label, strings.Join(zone.NameServers, ", ")))
@@ -122,6 +198,9 @@ func (h *CommandHandler) handleGetNSCommand(args []string) {
 		h.sendText(fmt.Sprintf("添加域名失败: %v,%s---%s", err, domain, account.Label))
 		return
 	}
+	if h.Zones != nil {
+		h.Zones.Put(account.Label, zone)
+	}
 
 	h.sendText(fmt.Sprintf("已将 %s 添加到账号 %s，NS 请设置为: %s", zone.Name, account.Label, strings.Join(zone.NameServers, ", ")))
 }
@@ -131,7 +210,10 @@ func (h *CommandHandler) handleStatusCommand(args []string) {
 		h.sendText("用法: /status <domain.com>")
 		return
 	}
-	domain := strings.ToLower(args[0])
+	domain, ok := h.zoneArg(args[0])
+	if !ok {
+		return
+	}
 
 	account, zone, err := h.findZone(domain)
 	if err != nil {
@@ -151,7 +233,12 @@ func (h *CommandHandler) handleDeleteCommand(args []string) {
 		h.sendText("用法: /delete <domain.com>")
 		return
 	}
-	domain := strings.ToLower(args[0])
+	domain := tools.NormalizeDomain(args[0])
+	if tools.IsSubdomain(domain) {
+		zone, _ := tools.RegistrableDomain(domain)
+		h.sendText(fmt.Sprintf("%s 是子域名，删除 Zone 请输入完整的 Zone 名称，例如 /delete %s", domain, zone))
+		return
+	}
 	op := formatOperator(h.operator)
 	account, _, err := h.findZone(domain)
 	if err != nil {
@@ -186,68 +273,459 @@ func (h *CommandHandler) handleDeleteCommand(args []string) {
 	// h.sendText(text)
 }
 func (h *CommandHandler) handleSetDNSCommand(args []string) {
-	if len(args) < 4 {
-		h.sendText("用法: /setdns <type> <sub.domain.com> <target> <on|off>")
-		h.sendText("示例: /setdns cname abc.example.com k8s-internat-tgnlbdir-cebb795ee4-10a8cd291bfbaf76.elb.us-west-1.amazonaws.com on")
+	if len(args) < 3 {
+		h.sendText("用法: /setdns <type> <sub.domain.com> <target> [on|off] [domain.com] [参数]\n已有同名记录时只修改内容与给出的参数，其余保持不变。\n" + recordOptionsUsage)
+		h.sendText("示例: /setdns cname abc.example.com k8s-internat-tgnlbdir-cebb795ee4-10a8cd291bfbaf76.elb.us-west-1.amazonaws.com on\n" +
+			"/setdns mx example.com mail.example.com priority=10 ttl=3600\n" +
+			"/setdns srv _sip._tcp.example.com sip.example.com priority=10 weight=5 port=5060\n" +
+			"/setdns caa example.com letsencrypt.org flags=0 tag=issue")
 		return
 	}
 
-	recordType := strings.ToUpper(args[0])
-	name := args[1]
-	content := args[2]
-	proxied := strings.EqualFold(args[3], "on")
-	domain := deriveDomainFromName(name)
-	if len(args) >= 5 {
-		domain = strings.ToLower(args[4])
+	params, zoneName, ok := h.recordArgs(args)
+	if !ok {
+		return
+	}
+	account, zone, ok := h.recordZone(args[1], zoneName)
+	if !ok {
+		return
 	}
 
-	if domain == "" {
-		h.sendText("请使用完整的域名或明确指定要操作的域名。")
+	// 修改已有记录时只覆盖命令中给出的参数，未给出的代理、TTL 等沿用原记录
+	overlay := func(p *cfclient.DNSRecordParams) error {
+		_, err := applyRecordOptions(p, args[3:])
+		return err
+	}
+	record, err := h.CFClient.UpsertDNSRecord(context.Background(), *account, zone.Name, params, overlay)
+	if err != nil {
+		h.sendText(fmt.Sprintf("设置解析失败: %v", err))
 		return
 	}
 
-	account, zone, err := h.findZone(domain)
+	h.sendText(fmt.Sprintf("已在账号 %s 设置记录: %s", account.Label, formatRecord(record)))
+
+	switch record.Type {
+	case "A", "AAAA", "CNAME":
+		if h.HTTP != nil {
+			time.Sleep(setDNSProbeDelay)
+			h.sendText("【解析生效检查】\n" + h.probe(record.Name))
+		}
+	}
+}
+
+func (h *CommandHandler) handleAddDNSCommand(args []string) {
+	if len(args) < 3 {
+		h.sendText("用法: /adddns <type> <sub.domain.com> <target> [on|off] [domain.com] [参数]\n" + recordOptionsUsage)
+		return
+	}
+	params, zoneName, ok := h.recordArgs(args)
+	if !ok {
+		return
+	}
+	account, zone, ok := h.recordZone(args[1], zoneName)
+	if !ok {
+		return
+	}
+
+	record, err := h.CFClient.CreateDNSRecord(context.Background(), *account, zone.Name, params)
+	if err != nil {
+		h.sendText(fmt.Sprintf("添加解析失败: %v", err))
+		return
+	}
+	h.sendText(fmt.Sprintf("已在账号 %s 添加记录: %s\nID: %s", account.Label, formatRecord(record), record.ID))
+}
+
+func (h *CommandHandler) handleEditDNSCommand(args []string) {
+	if len(args) < 3 {
+		h.sendText("用法: /editdns <domain.com> <记录ID> <target> [on|off] [参数]\n记录 ID 可通过 /dns 查看，未指定的参数保持不变。\n" + recordOptionsUsage)
+		return
+	}
+	account, zone, ok := h.recordZone(args[0], "")
+	if !ok {
+		return
+	}
+
+	current, err := h.CFClient.GetDNSRecord(context.Background(), *account, zone.Name, args[1])
+	if err != nil {
+		h.sendText(fmt.Sprintf("未找到记录 %s: %v", args[1], err))
+		return
+	}
+	params := cfclient.ParamsFromRecord(current)
+	params.Content = args[2]
+	rest, err := applyRecordOptions(&params, args[3:])
+	if err != nil {
+		h.sendText(err.Error())
+		return
+	}
+	if len(rest) > 0 {
+		h.sendText(fmt.Sprintf("无法识别的参数: %s", strings.Join(rest, " ")))
+		return
+	}
+
+	record, err := h.CFClient.UpdateDNSRecord(context.Background(), *account, zone.Name, current.ID, params)
+	if err != nil {
+		h.sendText(fmt.Sprintf("修改解析失败: %v", err))
+		return
+	}
+	h.sendText(fmt.Sprintf("已在账号 %s 修改记录: %s\n原记录: %s", account.Label, formatRecord(record), formatRecord(current)))
+}
+
+// recordArgs 解析 <type> <name> <target> 及其后的可选参数并校验记录，
+// 返回记录参数与明确指定的 Zone 名称（未指定时为空）。
+func (h *CommandHandler) recordArgs(args []string) (cfclient.DNSRecordParams, string, bool) {
+	params := cfclient.DNSRecordParams{Type: strings.ToUpper(args[0]), Name: args[1], Content: args[2]}
+	rest, err := applyRecordOptions(&params, args[3:])
+	if err != nil {
+		h.sendText(err.Error())
+		return params, "", false
+	}
+	if len(rest) > 1 {
+		h.sendText(fmt.Sprintf("无法识别的参数: %s", strings.Join(rest[1:], " ")))
+		return params, "", false
+	}
+	if err := params.Validate(); err != nil {
+		h.sendText(fmt.Sprintf("记录无效: %v", err))
+		return params, "", false
+	}
+	zoneName := ""
+	if len(rest) == 1 {
+		zoneName = rest[0]
+	}
+	return params, zoneName, true
+}
+
+func (h *CommandHandler) handleDelDNSCommand(args []string) {
+	if len(args) < 1 {
+		h.sendText("用法: /deldns <sub.domain.com> [type] [target]")
+		return
+	}
+	account, zone, ok := h.recordZone(args[0], "")
+	if !ok {
+		return
+	}
+	filter := cfclient.DNSRecordFilter{Name: args[0]}
+	if len(args) >= 2 {
+		filter.Type = strings.ToUpper(args[1])
+	}
+
+	records, err := h.CFClient.SearchDNSRecords(context.Background(), *account, zone.Name, filter)
+	if err != nil {
+		h.sendText(fmt.Sprintf("查询解析记录失败: %v", err))
+		return
+	}
+	if len(args) >= 3 {
+		var matched []cloudflare.DNSRecord
+		for _, r := range records {
+			if strings.EqualFold(strings.TrimSuffix(r.Content, "."), strings.TrimSuffix(args[2], ".")) {
+				matched = append(matched, r)
+			}
+		}
+		records = matched
+	}
+
+	if len(records) == 0 {
+		h.sendText(fmt.Sprintf("%s 没有符合条件的解析记录。", cfclient.RecordFQDN(args[0], zone.Name)))
+		return
+	}
+	// 只匹配到一条时同样需要按钮确认，避免误删
+	h.sendDNSPicker(*account, zone.Name, records)
+}
+
+// recordZone 找到记录所属的 Zone，找不到时回复提示。明确指定了 zoneName 时按原样查找，
+// 以支持建在子域名上的 Zone；否则按记录名的可注册域名查找。
+func (h *CommandHandler) recordZone(name, zoneName string) (*config.CF, cfclient.ZoneDetail, bool) {
+	var (
+		domain  string
+		account *config.CF
+		zone    cfclient.ZoneDetail
+		err     error
+	)
+	if zoneName != "" {
+		domain = tools.NormalizeDomain(zoneName)
+		account, zone, err = h.lookupZone(domain)
+	} else {
+		domain = deriveDomainFromName(name)
+		if domain == "" {
+			h.sendText("请使用完整的域名或明确指定要操作的域名。")
+			return nil, cfclient.ZoneDetail{}, false
+		}
+		account, zone, err = h.findZone(domain)
+	}
 	if err != nil {
 		if errors.Is(err, cfclient.ErrZoneNotFound) {
-			h.sendText(fmt.Sprintf("域名 %s 不存在于 Cloudflare，无法设置解析。", domain))
-			return
+			h.sendText(fmt.Sprintf("域名 %s 不存在于 Cloudflare。", domain))
+			return nil, cfclient.ZoneDetail{}, false
 		}
 		h.sendText(fmt.Sprintf("查询域名失败: %v", err))
+		return nil, cfclient.ZoneDetail{}, false
+	}
+	return account, zone, true
+}
+
+// formatRecord 返回一行记录说明，例如 "MX example.com → 10 mail.example.com (代理:off, TTL:auto)"。
+func formatRecord(r cloudflare.DNSRecord) string {
+	proxied := "off"
+	if r.Proxied != nil && *r.Proxied {
+		proxied = "on"
+	}
+	ttl := "auto"
+	if r.TTL > 1 {
+		ttl = fmt.Sprint(r.TTL)
+	}
+	content := r.Content
+	if r.Type == "MX" && r.Priority != nil {
+		content = fmt.Sprintf("%d %s", *r.Priority, content)
+	}
+	line := fmt.Sprintf("%s %s → %s (代理:%s, TTL:%s)", r.Type, r.Name, content, proxied, ttl)
+	if r.Comment != "" {
+		line += " # " + r.Comment
+	}
+	return line
+}
+
+func (h *CommandHandler) handleProbeCommand(args []string) {
+	if len(args) < 1 {
+		h.sendText("用法: /probe <sub.domain.com|URL> [status=200] [redirect=URL] [contains=文本] [latency=2s]")
+		return
+	}
+	if h.HTTP == nil {
+		h.sendText("未启用 HTTP 检查。")
+		return
+	}
+	h.sendText("【HTTP 检查】\n" + h.probe(strings.Join(args, " ")))
+}
+
+// probe 执行 HTTP 检查并逐行返回结果。
+func (h *CommandHandler) probe(target string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	var lines []string
+	for _, res := range h.HTTP.CheckHTTP(ctx, target) {
+		lines = append(lines, res.Summary())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (h *CommandHandler) handleWhoisCommand(args []string) {
+	if len(args) < 1 {
+		h.sendText("用法: /whois <domain.com>")
+		return
+	}
+	domain, ok := h.zoneArg(args[0])
+	if !ok {
 		return
 	}
 
-	params := cfclient.DNSRecordParams{Type: recordType, Name: name, Content: content, Proxied: proxied}
-	record, err := h.CFClient.UpsertDNSRecord(context.Background(), *account, zone.Name, params)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var result lookup.Result
+	var err error
+	if h.Whois != nil {
+		result, err = h.Whois.Lookup(ctx, domain)
+	} else {
+		result, err = lookup.Lookup(ctx, domain)
+	}
 	if err != nil {
-		h.sendText(fmt.Sprintf("设置解析失败: %v", err))
+		h.sendText(fmt.Sprintf("查询 %s 注册信息失败: %v", domain, err))
+		return
+	}
+	h.sendText(formatWhois(result))
+}
+
+func (h *CommandHandler) handleRefreshCommand(args []string) {
+	if len(args) < 1 {
+		h.sendText("用法: /refresh <domain.com|all>")
+		return
+	}
+	if h.Refresher == nil {
+		h.sendText("未启用查询缓存。")
+		return
+	}
+
+	if strings.EqualFold(args[0], "all") {
+		if err := h.Refresher.InvalidateCache(); err != nil {
+			h.sendText(fmt.Sprintf("清除查询缓存失败: %v", err))
+			return
+		}
+		h.sendText(fmt.Sprintf("已清除全部查询缓存，下次检测将重新查询所有域名。(操作人:%s)", formatOperator(h.operator)))
 		return
 	}
 
-	proxyStatus := "off"
-	if record.Proxied != nil && *record.Proxied {
-		proxyStatus = "on"
+	domain, ok := h.zoneArg(args[0])
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	result, events, err := h.Refresher.Refresh(ctx, domain)
+	if err != nil {
+		h.sendText(fmt.Sprintf("刷新 %s 失败: %v", domain, err))
+		return
+	}
+	h.sendText("已刷新缓存\n" + formatWhois(result))
+	if len(events) > 0 && h.Events != nil {
+		if err := h.Events.NotifyEvents(ctx, events); err != nil {
+			log.Printf("发送刷新事件通知失败: %v", err)
+		}
 	}
-	h.sendText(fmt.Sprintf("已在账号 %s 设置记录: %s %s → %s (代理:%s)", account.Label, record.Type, record.Name, record.Content, proxyStatus))
 }
 
-func (h *CommandHandler) findZone(domain string) (*config.CF, cfclient.ZoneDetail, error) {
-	var lastErr error
-	for i := range h.Accounts {
-		acc := h.Accounts[i]
-		zone, err := h.CFClient.GetZoneDetails(context.Background(), acc, domain)
-		if err != nil {
-			if errors.Is(err, cfclient.ErrZoneNotFound) {
-				lastErr = err
-				continue
+func (h *CommandHandler) handleHistoryCommand(args []string) {
+	if len(args) < 1 {
+		h.sendText("用法: /history <domain.com>")
+		return
+	}
+	if h.History == nil {
+		h.sendText("未启用到期历史记录。")
+		return
+	}
+	name, ok := h.zoneArg(args[0])
+	if !ok {
+		return
+	}
+	st, err := h.History.History(name)
+	if err != nil {
+		h.sendText(fmt.Sprintf("查询 %s 历史失败: %v", name, err))
+		return
+	}
+	if len(st.History) == 0 && len(st.Registrars) == 0 {
+		h.sendText(fmt.Sprintf("域名 %s 暂无历史记录。", name))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【域名历史】\n域名: %s\n", name))
+	if len(st.History) > 0 {
+		sb.WriteString("\n到期时间:\n")
+		for _, c := range st.History {
+			from := "-"
+			if !c.From.IsZero() {
+				from = c.From.Format("2006-01-02")
 			}
-			return nil, cfclient.ZoneDetail{}, err
+			sb.WriteString(fmt.Sprintf("%s: %s → %s\n", c.At.Format("2006-01-02 15:04"), from, c.To.Format("2006-01-02")))
 		}
-		return &acc, zone, nil
 	}
-	if lastErr == nil {
-		lastErr = cfclient.ErrZoneNotFound
+	if len(st.Registrars) > 0 {
+		sb.WriteString("\n注册商/转移锁:\n")
+		for _, r := range st.Registrars {
+			registrar := r.Registrar
+			if registrar == "" {
+				registrar = "-"
+			}
+			if r.IANAID != "" {
+				registrar += " (IANA " + r.IANAID + ")"
+			}
+			locks := "无转移锁"
+			if len(r.Locks) > 0 {
+				locks = strings.Join(r.Locks, ", ")
+			}
+			sb.WriteString(fmt.Sprintf("%s: %s, %s\n", r.At.Format("2006-01-02 15:04"), registrar, locks))
+		}
+	}
+	h.sendText(sb.String())
+}
+
+func (h *CommandHandler) handleDNSSECCommand(args []string) {
+	if len(args) < 1 {
+		h.sendText("用法: /dnssec <domain.com>")
+		return
+	}
+	if h.DNSSEC == nil {
+		h.sendText("未启用 DNSSEC 检查。")
+		return
+	}
+	domain, ok := h.zoneArg(args[0])
+	if !ok {
+		return
+	}
+
+	account, zone, err := h.findZone(domain)
+	if err != nil {
+		if errors.Is(err, cfclient.ErrZoneNotFound) {
+			h.sendText(fmt.Sprintf("域名 %s 不属于任何 Cloudflare 账号。", domain))
+			return
+		}
+		h.sendText(fmt.Sprintf("查询域名失败: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	check, err := h.DNSSEC.CheckDNSSEC(ctx, *account, zone.Name)
+	if err != nil {
+		h.sendText(fmt.Sprintf("检查 %s 的 DNSSEC 失败: %v", domain, err))
+		return
 	}
-	return nil, cfclient.ZoneDetail{}, lastErr
+	h.sendText(formatDNSSEC(account.Label, check))
+}
+
+func formatDNSSEC(label string, c domain.DNSSECCheck) string {
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	registry := "无 DS 记录"
+	if c.RegistrySigned {
+		registry = "存在 DS 记录"
+		if len(c.RegistryKeyTags) > 0 {
+			tags := make([]string, len(c.RegistryKeyTags))
+			for i, t := range c.RegistryKeyTags {
+				tags[i] = fmt.Sprint(t)
+			}
+			registry += fmt.Sprintf(" (key tag %s)", strings.Join(tags, ", "))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【DNSSEC 检查】\n域名: %s\n账号: %s\n", c.Domain, label))
+	sb.WriteString(fmt.Sprintf("注册局(%s): %s\n", orDash(c.RegistryFrom), registry))
+	cf := orDash(c.CFStatus)
+	if c.CFKeyTag != 0 {
+		cf += fmt.Sprintf(" (key tag %d)", c.CFKeyTag)
+	}
+	sb.WriteString(fmt.Sprintf("CF: %s\n", cf))
+	if c.CFDS != "" {
+		sb.WriteString(fmt.Sprintf("CF DS: %s\n", c.CFDS))
+	}
+	switch {
+	case c.Consistent():
+		sb.WriteString("\n✅ 两边状态一致")
+	case c.Urgent:
+		sb.WriteString("\n🚨 " + c.Problem)
+	default:
+		sb.WriteString("\n⚠️ " + c.Problem)
+	}
+	return sb.String()
+}
+
+// zoneArg 将命令参数归一化为可注册域名（Zone 名称），无法识别时回复提示。
+func (h *CommandHandler) zoneArg(arg string) (string, bool) {
+	zone, err := tools.RegistrableDomain(arg)
+	if err != nil {
+		h.sendText(fmt.Sprintf("无法识别域名 %s: %v", arg, err))
+		return "", false
+	}
+	return zone, true
+}
+
+// findZone 查找域名所属的 Zone，子域名按可注册域名查找。
+func (h *CommandHandler) findZone(domain string) (*config.CF, cfclient.ZoneDetail, error) {
+	if zone, err := tools.RegistrableDomain(domain); err == nil {
+		domain = zone
+	}
+	return h.lookupZone(domain)
+}
+
+// lookupZone 按名称原样查找 Zone。配置了 Zones 时通过索引定位账号，
+// 否则逐个账号实时查询，单个账号出错不影响其他账号。
+func (h *CommandHandler) lookupZone(domain string) (*config.CF, cfclient.ZoneDetail, error) {
+	zones := h.Zones
+	if zones == nil {
+		zones = cfclient.NewZoneIndex(h.CFClient, h.Accounts)
+	}
+	return zones.Find(context.Background(), domain)
 }
 func (h *CommandHandler) deleteZone(domain string) (*config.CF, error) {
 	var lastErr error
@@ -282,17 +760,54 @@ func (h *CommandHandler) sendText(msg string) {
 	_ = h.Sender.Send(context.Background(), msg)
 }
 
+// deriveDomainFromName 按 Public Suffix List 得到记录名所属的可注册域名，
+// 例如 a.shop.us.com → shop.us.com。
 func deriveDomainFromName(name string) string {
-	trimmed := strings.TrimSpace(name)
-	if trimmed == "" {
+	registrable, err := tools.RegistrableDomain(name)
+	if err != nil {
 		return ""
 	}
-	parts := strings.Split(trimmed, ".")
-	if len(parts) >= 2 {
-		return strings.Join(parts[len(parts)-2:], ".")
+	return registrable
+}
+func formatWhois(r lookup.Result) string {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format("2006-01-02 15:04:05 MST")
 	}
-	return ""
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	dnssec := "unsigned"
+	if r.DNSSEC {
+		dnssec = "signed"
+	}
+
+	var sb strings.Builder
+	source := strings.ToUpper(string(r.Protocol))
+	if r.Backend != "" && !strings.EqualFold(r.Backend, string(r.Protocol)) {
+		source = fmt.Sprintf("%s/%s", r.Backend, source)
+	}
+	sb.WriteString(fmt.Sprintf("【域名注册信息】\n域名: %s\n来源: %s (%s)\n", r.Domain, source, orDash(r.Server)))
+	sb.WriteString(fmt.Sprintf("注册商: %s", orDash(r.Registrar)))
+	if r.RegistrarIANAID != "" {
+		sb.WriteString(fmt.Sprintf(" (IANA %s)", r.RegistrarIANAID))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("注册局到期: %s\n", formatTime(r.RegistryExpiry)))
+	sb.WriteString(fmt.Sprintf("注册商到期: %s\n", formatTime(r.RegistrarExpiry)))
+	sb.WriteString(fmt.Sprintf("注册时间: %s\n", formatTime(r.Created)))
+	sb.WriteString(fmt.Sprintf("更新时间: %s\n", formatTime(r.Updated)))
+	sb.WriteString(fmt.Sprintf("状态: %s\n", orDash(strings.Join(r.Statuses, ", "))))
+	sb.WriteString(fmt.Sprintf("NS: %s\n", orDash(strings.Join(r.NameServers, ", "))))
+	sb.WriteString(fmt.Sprintf("DNSSEC: %s", dnssec))
+	return sb.String()
 }
+
 func formatOperator(u *tgbotapi.User) string {
 	if u == nil {
 		return "unknown"
@@ -0,0 +1,118 @@
+package telegram
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"DomainC/config"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// dnsPickTTL 为删除解析记录的选择按钮有效期。
+const dnsPickTTL = 10 * time.Minute
+
+// dnsPick 为等待选择删除的记录。回调数据最长 64 字节，放不下域名与记录 ID，
+// 因此按钮只携带短 token 与序号，记录保存在 dnsPicks 中。
+type dnsPick struct {
+	Account config.CF
+	Zone    string
+	Records []cloudflare.DNSRecord
+	expires time.Time
+}
+
+type dnsPicks struct {
+	mu    sync.Mutex
+	picks map[string]dnsPick
+}
+
+func (p *dnsPicks) put(pick dnsPick) string {
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.picks == nil {
+		p.picks = make(map[string]dnsPick)
+	}
+	now := time.Now()
+	for k, v := range p.picks {
+		if now.After(v.expires) {
+			delete(p.picks, k)
+		}
+	}
+	pick.expires = now.Add(dnsPickTTL)
+	p.picks[token] = pick
+	return token
+}
+
+// take 取出并删除 token 对应的记录，每组按钮只能使用一次，避免重复删除。
+func (p *dnsPicks) take(token string) (dnsPick, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pick, ok := p.picks[token]
+	delete(p.picks, token)
+	if !ok || time.Now().After(pick.expires) {
+		return dnsPick{}, false
+	}
+	return pick, true
+}
+
+// sendDNSPicker 发送删除解析记录的选择按钮，每条记录一行，最后一行为取消；只有一条记录时作为确认按钮。
+func (h *CommandHandler) sendDNSPicker(account config.CF, zone string, records []cloudflare.DNSRecord) {
+	token := h.picks.put(dnsPick{Account: account, Zone: zone, Records: records})
+	buttons := make([][]Button, 0, len(records)+1)
+	for i, r := range records {
+		buttons = append(buttons, []Button{{
+			Text:         fmt.Sprintf("%s %s", r.Type, r.Content),
+			CallbackData: fmt.Sprintf("dnsdel|%s|%d", token, i),
+		}})
+	}
+	buttons = append(buttons, []Button{{Text: "❌ 取消", CallbackData: fmt.Sprintf("dnsdel|%s|x", token)}})
+
+	prompt := fmt.Sprintf("%s 共有 %d 条记录，请选择要删除的一条：", records[0].Name, len(records))
+	if len(records) == 1 {
+		buttons[0][0].Text = "🗑 确认删除 " + buttons[0][0].Text
+		prompt = "确认删除以下记录？\n" + formatRecord(records[0])
+	}
+	msg := fmt.Sprintf("【删除解析记录】\n操作人: %s\n域名: %s\n账号: %s\n\n%s",
+		formatOperator(h.operator), zone, account.Label, prompt)
+	if err := h.Sender.SendWithButtons(context.Background(), msg, buttons); err != nil {
+		h.sendText(fmt.Sprintf("发送选择按钮失败: %v", err))
+	}
+}
+
+// HandleDNSPick 处理删除解析记录的选择按钮，回调数据为 dnsdel|token|序号，序号为 x 时取消。
+func (h *CommandHandler) HandleDNSPick(token, choice string, user *tgbotapi.User) {
+	pick, ok := h.picks.take(token)
+	if !ok {
+		h.sendText("选择已过期或已处理，请重新执行 /deldns。")
+		return
+	}
+	op := formatOperator(user)
+	if choice == "x" {
+		h.sendText(fmt.Sprintf("已取消删除解析记录: %s (操作人:%s)", pick.Zone, op))
+		return
+	}
+	i, err := strconv.Atoi(choice)
+	if err != nil || i < 0 || i >= len(pick.Records) {
+		h.sendText("无效的选择。")
+		return
+	}
+	h.deleteRecord(pick.Account, pick.Zone, pick.Records[i], op)
+}
+
+func (h *CommandHandler) deleteRecord(account config.CF, zone string, r cloudflare.DNSRecord, op string) {
+	if err := h.CFClient.DeleteDNSRecord(context.Background(), account, zone, r.ID); err != nil {
+		h.sendText(fmt.Sprintf("删除解析记录失败: %s (%v)", formatRecord(r), err))
+		return
+	}
+	h.sendText(fmt.Sprintf("✅ 已在账号 %s 删除记录: %s (操作人:%s)", account.Label, formatRecord(r), op))
+}
@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"DomainC/cfclient"
+)
+
+// recordOptionsUsage 为解析记录命令支持的可选参数说明。
+const recordOptionsUsage = `可选参数:
+  on|off 是否开启代理（仅 A/AAAA/CNAME）
+  ttl=300 TTL 秒数，默认自动
+  priority=10 MX、SRV 优先级
+  weight=5 port=443 SRV 权重与端口
+  flags=0 tag=issue CAA 标志与标签（issue、issuewild、iodef）
+  comment="备注" tags=a,b 记录备注与标签
+含空格的内容请用双引号括起，例如 TXT 记录 "v=spf1 include:_spf.example.com ~all"`
+
+// splitArgs 按空白拆分命令参数，双引号内的空白保留，引号本身去掉。
+func splitArgs(s string) []string {
+	var (
+		args    []string
+		cur     strings.Builder
+		quoted  bool
+		started bool
+	)
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '“' || r == '”':
+			quoted = !quoted
+			started = true
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if started {
+				args = append(args, cur.String())
+				cur.Reset()
+				started = false
+			}
+		default:
+			cur.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// applyRecordOptions 将 on|off 与 key=value 参数写入 params，返回无法识别的其余参数。
+func applyRecordOptions(params *cfclient.DNSRecordParams, opts []string) ([]string, error) {
+	var rest []string
+	for _, opt := range opts {
+		switch strings.ToLower(opt) {
+		case "on":
+			params.Proxied = true
+			continue
+		case "off":
+			params.Proxied = false
+			continue
+		}
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok {
+			rest = append(rest, opt)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "ttl":
+			if strings.EqualFold(value, "auto") {
+				params.TTL = 1
+				continue
+			}
+			ttl, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("无效的 TTL: %s", value)
+			}
+			params.TTL = ttl
+		case "priority":
+			n, err := strconv.ParseUint(value, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("无效的优先级: %s", value)
+			}
+			priority := uint16(n)
+			params.Priority = &priority
+		case "weight":
+			n, err := strconv.ParseUint(value, 10, 16)
+			if err != nil {
+				return nil, fmt.Errorf("无效的权重: %s", value)
+			}
+			params.Weight = uint16(n)
+		case "port":
+			n, err := strconv.ParseUint(value, 10, 16)
+			if err != nil || n == 0 {
+				return nil, fmt.Errorf("无效的端口: %s", value)
+			}
+			params.Port = uint16(n)
+		case "flags":
+			n, err := strconv.ParseUint(value, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("无效的 CAA flags: %s", value)
+			}
+			params.Flags = uint8(n)
+		case "tag":
+			params.Tag = strings.ToLower(value)
+		case "comment":
+			params.Comment = value
+		case "tags":
+			params.Tags = nil
+			for _, t := range strings.Split(value, ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					params.Tags = append(params.Tags, t)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("未知参数: %s", key)
+		}
+	}
+	return rest, nil
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+)
+
+// HourPrecisionWithin 内的倒计时按小时展示，避免 "剩余 1 天" 随任务执行时刻跳变。
+const HourPrecisionWithin = 48 * time.Hour
+
+// Countdown 为距离到期的剩余时间。
+type Countdown struct {
+	Remaining time.Duration
+	// Days 为时区内日历日之差，今天到期为 0，已过期为负数。
+	Days int
+}
+
+// CountdownTo 按 loc 时区计算 now 到 expiry 的倒计时，loc 为空时使用系统时区。
+func CountdownTo(expiry, now time.Time, loc *time.Location) Countdown {
+	if loc == nil {
+		loc = time.Local
+	}
+	ey, em, ed := expiry.In(loc).Date()
+	ny, nm, nd := now.In(loc).Date()
+	// 用 UTC 零点相减，避开夏令时切换日不足 24 小时的问题
+	days := time.Date(ey, em, ed, 0, 0, 0, 0, time.UTC).Sub(time.Date(ny, nm, nd, 0, 0, 0, 0, time.UTC))
+	return Countdown{Remaining: expiry.Sub(now), Days: int(days.Hours() / 24)}
+}
+
+// Expired 表示是否已经到期。
+func (c Countdown) Expired() bool {
+	return c.Remaining <= 0
+}
+
+// String 返回 "剩余 12 天"、"剩余 30 小时"、"已过期 2 天" 之类的描述，48 小时内精确到小时。
+func (c Countdown) String() string {
+	remaining := c.Remaining
+	prefix := "剩余"
+	if remaining <= 0 {
+		remaining = -remaining
+		prefix = "已过期"
+	}
+	if remaining < HourPrecisionWithin {
+		if remaining < time.Hour {
+			return fmt.Sprintf("%s %d 分钟", prefix, int(remaining.Minutes()))
+		}
+		return fmt.Sprintf("%s %d 小时", prefix, int(remaining.Hours()))
+	}
+	days := c.Days
+	if days < 0 {
+		days = -days
+	}
+	return fmt.Sprintf("%s %d 天", prefix, days)
+}
+
+// FormatExpiry 按 loc 时区展示到期时间，loc 为空时使用系统时区。
+func FormatExpiry(expiry time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = time.Local
+	}
+	return expiry.In(loc).Format("2006-01-02 15:04 MST")
+}
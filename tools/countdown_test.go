@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountdownUsesCalendarDaysInLocation(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	expiry := time.Date(2026, 1, 10, 0, 30, 0, 0, shanghai)
+
+	// 同一到期时间，无论任务在当天几点执行，剩余天数都一样
+	for _, hour := range []int{1, 12, 23} {
+		now := time.Date(2026, 1, 5, hour, 0, 0, 0, shanghai)
+		if got := CountdownTo(expiry, now, shanghai).Days; got != 5 {
+			t.Fatalf("at %02d:00 expected 5 days, got %d", hour, got)
+		}
+	}
+
+	// 按 UTC 计算时到期日落在 1 月 9 日
+	now := time.Date(2026, 1, 5, 1, 0, 0, 0, time.UTC)
+	if got := CountdownTo(expiry, now, time.UTC).Days; got != 4 {
+		t.Fatalf("expected 4 days in UTC, got %d", got)
+	}
+}
+
+func TestCountdownStringPrecision(t *testing.T) {
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		expiry time.Time
+		want   string
+	}{
+		{now.Add(10 * 24 * time.Hour), "剩余 10 天"},
+		{now.Add(47 * time.Hour), "剩余 47 小时"},
+		{now.Add(30 * time.Minute), "剩余 30 分钟"},
+		{now.Add(-3 * time.Hour), "已过期 3 小时"},
+		{now.Add(-72 * time.Hour), "已过期 3 天"},
+	}
+	for _, tc := range cases {
+		if got := CountdownTo(tc.expiry, now, time.UTC).String(); got != tc.want {
+			t.Fatalf("expiry %v: got %q, want %q", tc.expiry, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// NormalizeDomain 去掉首尾空白与点并转为小写。
+func NormalizeDomain(name string) string {
+	return strings.ToLower(strings.Trim(strings.TrimSpace(name), "."))
+}
+
+// PublicSuffix 返回名称的公共后缀（如 com、com.cn、us.com），依据内置的 Public Suffix List。
+func PublicSuffix(name string) string {
+	suffix, _ := publicsuffix.PublicSuffix(NormalizeDomain(name))
+	return suffix
+}
+
+// RegistrableDomain 返回名称对应的可注册域名（eTLD+1），
+// 例如 a.shop.us.com → shop.us.com，x.example.com.cn → example.com.cn。
+func RegistrableDomain(name string) (string, error) {
+	normalized := NormalizeDomain(name)
+	if normalized == "" {
+		return "", fmt.Errorf("域名为空")
+	}
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(normalized)
+	if err != nil {
+		return "", fmt.Errorf("无法识别可注册域名 %s: %v", normalized, err)
+	}
+	return registrable, nil
+}
+
+// IsSubdomain 判断名称是否为某个可注册域名下的子域名。
+func IsSubdomain(name string) bool {
+	registrable, err := RegistrableDomain(name)
+	return err == nil && registrable != NormalizeDomain(name)
+}
@@ -0,0 +1,26 @@
+package tools
+
+import "testing"
+
+func TestRegistrableDomain(t *testing.T) {
+	cases := map[string]string{
+		"a.shop.us.com":    "shop.us.com",
+		"x.example.com.cn": "example.com.cn",
+		"WWW.Example.COM.": "example.com",
+		"gamestore.us.com": "gamestore.us.com",
+		"deep.sub.vp88.ph": "vp88.ph",
+	}
+	for in, want := range cases {
+		got, err := RegistrableDomain(in)
+		if err != nil || got != want {
+			t.Errorf("RegistrableDomain(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	if _, err := RegistrableDomain("com.cn"); err == nil {
+		t.Errorf("expected error for bare public suffix")
+	}
+	if PublicSuffix("shop.us.com") != "us.com" {
+		t.Errorf("expected us.com suffix, got %s", PublicSuffix("shop.us.com"))
+	}
+}